@@ -1,5 +1,7 @@
 package goex
 
+import "context"
+
 // api interface
 
 type API interface {
@@ -21,3 +23,49 @@ type API interface {
 
 	GetExchangeName() string
 }
+
+// 支持订单类型,有效方式和自定义订单id的下单接口,
+// 交易所不支持的组合返回EX_ERR_NOT_SUPPORTED
+type OrderAPI interface {
+	PlaceOrder(req OrderRequest) (*Order, error)
+}
+
+// 个人成交记录, since为毫秒时间戳,0表示最近的成交
+type MyTradesAPI interface {
+	GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error)
+}
+
+// 充值提现接口, SubmitWithdraw返回交易所的提现id, 交易所不返回id时为空字符串
+type WalletAPI interface {
+	GetDepositAddress(currency Currency) (*DepositAddress, error)
+	SubmitWithdraw(req WithdrawRequest) (string, error)
+	CancelWithdraw(id string, currency Currency, safePwd string) (bool, error)
+	GetDepositHistory(currency Currency) ([]Deposit, error)
+	GetWithdrawHistory(currency Currency) ([]Withdrawal, error)
+}
+
+// 交易所交易对信息
+type MarketInfoAPI interface {
+	GetMarkets() ([]Market, error)
+}
+
+// 支持context的api interface, ctx取消或超时后调用立即返回,
+// 原有的方法等价于传入context.Background()
+type APIWithContext interface {
+	API
+
+	LimitBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	LimitSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	MarketBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	MarketSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	CancelOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (bool, error)
+	GetOneOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error)
+	GetUnfinishOrdersCtx(ctx context.Context, currency CurrencyPair) ([]Order, error)
+	GetOrderHistorysCtx(ctx context.Context, currency CurrencyPair, currentPage, pageSize int) ([]Order, error)
+	GetAccountCtx(ctx context.Context) (*Account, error)
+
+	GetTickerCtx(ctx context.Context, currency CurrencyPair) (*Ticker, error)
+	GetDepthCtx(ctx context.Context, size int, currency CurrencyPair) (*Depth, error)
+	GetKlineRecordsCtx(ctx context.Context, currency CurrencyPair, period, size, since int) ([]Kline, error)
+	GetTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error)
+}
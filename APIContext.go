@@ -0,0 +1,110 @@
+package goex
+
+import "context"
+
+/**
+ * 把任意API转换为APIWithContext
+ * 已原生支持context的交易所直接返回; 其余交易所只在发起请求前检查ctx,
+ * 请求发出后无法中途取消
+ */
+func WithContext(api API) APIWithContext {
+	if ctxApi, ok := api.(APIWithContext); ok {
+		return ctxApi
+	}
+	return &apiContextWrapper{api}
+}
+
+type apiContextWrapper struct {
+	API
+}
+
+func (w *apiContextWrapper) LimitBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.LimitBuy(amount, price, currency)
+}
+
+func (w *apiContextWrapper) LimitSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.LimitSell(amount, price, currency)
+}
+
+func (w *apiContextWrapper) MarketBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.MarketBuy(amount, price, currency)
+}
+
+func (w *apiContextWrapper) MarketSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.MarketSell(amount, price, currency)
+}
+
+func (w *apiContextWrapper) CancelOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return w.CancelOrder(orderId, currency)
+}
+
+func (w *apiContextWrapper) GetOneOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetOneOrder(orderId, currency)
+}
+
+func (w *apiContextWrapper) GetUnfinishOrdersCtx(ctx context.Context, currency CurrencyPair) ([]Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetUnfinishOrders(currency)
+}
+
+func (w *apiContextWrapper) GetOrderHistorysCtx(ctx context.Context, currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetOrderHistorys(currency, currentPage, pageSize)
+}
+
+func (w *apiContextWrapper) GetAccountCtx(ctx context.Context) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetAccount()
+}
+
+func (w *apiContextWrapper) GetTickerCtx(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetTicker(currency)
+}
+
+func (w *apiContextWrapper) GetDepthCtx(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetDepth(size, currency)
+}
+
+func (w *apiContextWrapper) GetKlineRecordsCtx(ctx context.Context, currency CurrencyPair, period, size, since int) ([]Kline, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetKlineRecords(currency, period, size, since)
+}
+
+func (w *apiContextWrapper) GetTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return w.GetTrades(currencyPair, since)
+}
@@ -1,6 +1,7 @@
 package goex
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"reflect"
@@ -8,6 +9,7 @@ import (
 )
 
 /**
+  Deprecated: 使用Retry, RE对所有错误都重试并且重试失败时panic
   @retry  重试次数
   @method 调用的函数，比如: api.GetTicker ,注意：不是api.GetTicker(...)
   @params 参数,顺序一定要按照实际调用函数入参顺序一样
@@ -59,6 +61,7 @@ _CALL:
 
 /**
  * call all unfinished orders
+ * 返回撤单成功的订单数
  */
 func CancelAllUnfinishedOrders(api API, currencyPair CurrencyPair) int {
 	if api == nil {
@@ -66,16 +69,35 @@ func CancelAllUnfinishedOrders(api API, currencyPair CurrencyPair) int {
 		return -1
 	}
 
-	orders := RE(10, api.GetUnfinishOrders, currencyPair)
+	var orders []Order
+	err := Retry(context.Background(), DEFAULT_RETRY_POLICY, func(ctx context.Context) (err error) {
+		orders, err = api.GetUnfinishOrders(currencyPair)
+		return
+	})
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
 	if orders != nil {
+		var orderIds []string
+		for _, ord := range orders {
+			orderIds = append(orderIds, ord.OrderID2)
+		}
+
+		results, err := BatchCancelOrders(api, currencyPair, orderIds)
+		if err != nil {
+			log.Println(err)
+			return 0
+		}
+
 		c := 0
-		for _, ord := range orders.([]Order) {
-			_, err := api.CancelOrder(ord.OrderID2, currencyPair)
-			if err != nil {
-				log.Println(err)
+		for _, r := range results {
+			if r.Err != nil {
+				log.Println(r.OrderID, r.Err)
+				continue
 			}
 			c++
-			time.Sleep(100 * time.Millisecond) //控制频率
 		}
 
 		return c
@@ -92,14 +114,21 @@ func CancelAllUnfinishedFutureOrders(api FutureRestAPI, contractType string, cur
 		return
 	}
 
-	orders := RE(10, api.GetUnfinishFutureOrders, currencyPair, contractType)
-	if orders != nil {
-		for _, ord := range orders.([]Order) {
-			_, err := api.FutureCancelOrder(currencyPair, contractType, fmt.Sprintf("%d", ord.OrderID))
-			if err != nil {
-				log.Println(err)
-			}
-			time.Sleep(100 * time.Millisecond) //控制频率
+	var orders []FutureOrder
+	err := Retry(context.Background(), DEFAULT_RETRY_POLICY, func(ctx context.Context) (err error) {
+		orders, err = api.GetUnfinishFutureOrders(currencyPair, contractType)
+		return
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, ord := range orders {
+		_, err := api.FutureCancelOrder(currencyPair, contractType, fmt.Sprintf("%d", ord.OrderID))
+		if err != nil {
+			log.Println(err)
 		}
+		time.Sleep(100 * time.Millisecond) //控制频率
 	}
 }
@@ -1,5 +1,14 @@
 package goex
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
 type ApiError struct {
 	ErrCode,
 	ErrMsg,
@@ -28,4 +37,77 @@ var (
 	EX_ERR_INVALID_CURRENCY_PAIR = ApiError{ErrCode: "EX_ERR_0007", ErrMsg: "invalid currency pair"}
 	EX_ERR_NOT_FIND_ORDER        = ApiError{ErrCode: "EX_ERR_0008", ErrMsg: "not find order"}
 	EX_ERR_SYMBOL_ERR            = ApiError{ErrCode: "EX_ERR_0009", ErrMsg: "symbol error"}
+	EX_ERR_NOT_SUPPORTED         = ApiError{ErrCode: "EX_ERR_0010", ErrMsg: "not supported"}
+	EX_ERR_AMOUNT_TOO_SMALL      = ApiError{ErrCode: "EX_ERR_0011", ErrMsg: "order amount below minimum"}
+	EX_ERR_NOTIONAL_TOO_SMALL    = ApiError{ErrCode: "EX_ERR_0012", ErrMsg: "order notional below minimum"}
+	EX_ERR_NOT_FIND_PASSPHRASE   = ApiError{ErrCode: "EX_ERR_0013", ErrMsg: "not find passphrase"}
 )
+
+//http状态码不是200时返回, Body是原始的响应内容, RetryAfter来自响应头Retry-After
+type HttpError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e HttpError) Error() string {
+	return fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", e.StatusCode, e.Body)
+}
+
+func isApiError(err error, target ApiError) bool {
+	var apiErr ApiError
+	return errors.As(err, &apiErr) && apiErr.ErrCode == target.ErrCode
+}
+
+func asHttpError(err error) (HttpError, bool) {
+	var httpErr HttpError
+	ok := errors.As(err, &httpErr)
+	return httpErr, ok
+}
+
+//交易所不支持该接口
+func IsNotSupported(err error) bool {
+	return isApiError(err, EX_ERR_NOT_SUPPORTED)
+}
+
+//请求频率超限, 418是binance的ip封禁
+func IsRateLimited(err error) bool {
+	if httpErr, ok := asHttpError(err); ok {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusTeapot
+	}
+	return isApiError(err, EX_ERR_API_LIMIT)
+}
+
+func IsInsufficientBalance(err error) bool {
+	return isApiError(err, EX_ERR_INSUFFICIENT_BALANCE)
+}
+
+func IsOrderNotFound(err error) bool {
+	return isApiError(err, EX_ERR_NOT_FIND_ORDER)
+}
+
+//临时性错误,稍后重试可能成功: 网络错误、http 5xx和限频, 签名、余额不足等错误重试没有意义
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if IsRateLimited(err) || isApiError(err, HTTP_ERR_CODE) {
+		return true
+	}
+
+	if httpErr, ok := asHttpError(err); ok {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusRequestTimeout
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+//交易所要求的等待时间, 没有时返回0
+func GetRetryAfter(err error) time.Duration {
+	if httpErr, ok := asHttpError(err); ok {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
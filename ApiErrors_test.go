@@ -0,0 +1,24 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClassification(t *testing.T) {
+	assert.True(t, IsRateLimited(EX_ERR_API_LIMIT.OriginErr("too many requests")))
+	assert.True(t, IsInsufficientBalance(EX_ERR_INSUFFICIENT_BALANCE))
+	assert.True(t, IsOrderNotFound(fmt.Errorf("cancel: %w", EX_ERR_NOT_FIND_ORDER)))
+	assert.False(t, IsOrderNotFound(EX_ERR_CANCEL_ORDER_FAIL))
+
+	assert.True(t, IsTransient(HTTP_ERR_CODE))
+	assert.True(t, IsTransient(HttpError{StatusCode: 502}))
+	assert.False(t, IsTransient(HttpError{StatusCode: 400}))
+	assert.False(t, IsTransient(EX_ERR_SIGN))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.False(t, IsTransient(errors.New("unknown")))
+}
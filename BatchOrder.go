@@ -0,0 +1,91 @@
+package goex
+
+import "sync"
+
+//批量下单结果,顺序与请求一致
+type BatchOrderResult struct {
+	Order *Order
+	Err   error
+}
+
+//批量撤单结果,顺序与请求一致
+type BatchCancelResult struct {
+	OrderID string
+	Err     error
+}
+
+// 交易所原生的批量下单接口,
+// 返回EX_ERR_NOT_SUPPORTED时(比如订单类型不支持)BatchPlaceOrders改为逐个下单
+type BatchPlaceOrderAPI interface {
+	BatchPlaceOrders(reqs []OrderRequest) ([]BatchOrderResult, error)
+}
+
+// 交易所原生的批量撤单接口
+type BatchCancelOrderAPI interface {
+	BatchCancelOrders(currency CurrencyPair, orderIds []string) ([]BatchCancelResult, error)
+}
+
+//交易所不支持批量接口时,同时进行的单个请求数
+var BATCH_ORDER_CONCURRENCY = 5
+
+/**
+ * 批量下单, 交易所支持时使用原生批量接口, 否则以BATCH_ORDER_CONCURRENCY并发逐个下单
+ * 返回的error表示整个批量请求失败, 单个订单的错误在BatchOrderResult.Err
+ */
+func BatchPlaceOrders(api API, reqs []OrderRequest) ([]BatchOrderResult, error) {
+	if batchApi, ok := api.(BatchPlaceOrderAPI); ok {
+		results, err := batchApi.BatchPlaceOrders(reqs)
+		if !IsNotSupported(err) {
+			return results, err
+		}
+	}
+
+	results := make([]BatchOrderResult, len(reqs))
+	runConcurrently(len(reqs), func(i int) {
+		results[i].Order, results[i].Err = PlaceOrder(api, reqs[i])
+	})
+	return results, nil
+}
+
+//批量撤单, 交易所支持时使用原生批量接口, 否则以BATCH_ORDER_CONCURRENCY并发逐个撤单
+func BatchCancelOrders(api API, currency CurrencyPair, orderIds []string) ([]BatchCancelResult, error) {
+	if batchApi, ok := api.(BatchCancelOrderAPI); ok {
+		results, err := batchApi.BatchCancelOrders(currency, orderIds)
+		if !IsNotSupported(err) {
+			return results, err
+		}
+	}
+
+	results := make([]BatchCancelResult, len(orderIds))
+	runConcurrently(len(orderIds), func(i int) {
+		results[i].OrderID = orderIds[i]
+		ok, err := api.CancelOrder(orderIds[i], currency)
+		if err == nil && !ok {
+			err = EX_ERR_CANCEL_ORDER_FAIL
+		}
+		results[i].Err = err
+	})
+	return results, nil
+}
+
+func runConcurrently(n int, fn func(i int)) {
+	concurrency := BATCH_ORDER_CONCURRENCY
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
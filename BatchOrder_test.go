@@ -0,0 +1,99 @@
+package goex
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchApi struct {
+	API
+	running, maxRunning int32
+	mu                  sync.Mutex
+	canceled            []string
+}
+
+func (a *batchApi) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	n := atomic.AddInt32(&a.running, 1)
+	defer atomic.AddInt32(&a.running, -1)
+	for {
+		max := atomic.LoadInt32(&a.maxRunning)
+		if n <= max || atomic.CompareAndSwapInt32(&a.maxRunning, max, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if price == "0" {
+		return nil, EX_ERR_PLACE_ORDER_FAIL
+	}
+	return &Order{OrderID2: price}, nil
+}
+
+func (a *batchApi) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	return []Order{{OrderID2: "1"}, {OrderID2: "2"}, {OrderID2: "3"}}, nil
+}
+
+func (a *batchApi) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.canceled = append(a.canceled, orderId)
+	if orderId == "2" {
+		return false, errors.New("cancel fail")
+	}
+	return true, nil
+}
+
+type nativeBatchApi struct {
+	batchApi
+}
+
+func (a *nativeBatchApi) BatchPlaceOrders(reqs []OrderRequest) ([]BatchOrderResult, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+func (a *nativeBatchApi) BatchCancelOrders(currency CurrencyPair, orderIds []string) ([]BatchCancelResult, error) {
+	return []BatchCancelResult{{OrderID: "native"}}, nil
+}
+
+func TestBatchPlaceOrders(t *testing.T) {
+	api := &batchApi{}
+	var reqs []OrderRequest
+	for i := 0; i < 12; i++ {
+		price := "1"
+		if i == 3 {
+			price = "0"
+		}
+		reqs = append(reqs, OrderRequest{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_LIMIT, Amount: "1", Price: price})
+	}
+
+	results, err := BatchPlaceOrders(api, reqs)
+	assert.Nil(t, err)
+	assert.Len(t, results, 12)
+	assert.Error(t, results[3].Err)
+	assert.Nil(t, results[4].Err)
+	assert.NotNil(t, results[4].Order)
+	assert.True(t, api.maxRunning <= int32(BATCH_ORDER_CONCURRENCY))
+
+	native := &nativeBatchApi{}
+	results, err = BatchPlaceOrders(native, reqs[:2])
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestBatchCancelOrders(t *testing.T) {
+	api := &batchApi{}
+	results, err := BatchCancelOrders(api, BTC_USDT, []string{"1", "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", results[0].OrderID)
+	assert.Nil(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+
+	assert.Equal(t, 2, CancelAllUnfinishedOrders(&batchApi{}, BTC_USDT))
+
+	results, _ = BatchCancelOrders(&nativeBatchApi{}, BTC_USDT, []string{"1"})
+	assert.Equal(t, "native", results[0].OrderID)
+}
@@ -0,0 +1,63 @@
+package goex
+
+import "sort"
+
+//交易所支持的功能,不支持的接口返回EX_ERR_NOT_SUPPORTED
+type Capabilities struct {
+	Ticker       bool
+	Depth        bool
+	Kline        bool
+	Trades       bool //整个交易所的交易记录
+	Orders       bool //下单,撤单,查询订单
+	OrderHistory bool
+	Account      bool
+	Ws           bool
+	KlinePeriods []int //支持的K线周期,KLINE_PERIOD_XXX
+	MaxDepthSize int   //0表示未知
+}
+
+type CapabilitiesAPI interface {
+	Capabilities() Capabilities
+}
+
+//未声明Capabilities的交易所,默认认为rest接口都支持
+var DEFAULT_CAPABILITIES = Capabilities{
+	Ticker:       true,
+	Depth:        true,
+	Kline:        true,
+	Trades:       true,
+	Orders:       true,
+	OrderHistory: true,
+	Account:      true}
+
+func GetCapabilities(api API) Capabilities {
+	if capApi, ok := api.(CapabilitiesAPI); ok {
+		return capApi.Capabilities()
+	}
+	return DEFAULT_CAPABILITIES
+}
+
+func (c Capabilities) SupportKlinePeriod(period int) bool {
+	if !c.Kline {
+		return false
+	}
+	if len(c.KlinePeriods) == 0 {
+		return true
+	}
+	for _, p := range c.KlinePeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+//取K线周期转换表的所有周期,升序
+func KlinePeriods(converter map[int]string) []int {
+	periods := make([]int, 0, len(converter))
+	for period := range converter {
+		periods = append(periods, period)
+	}
+	sort.Ints(periods)
+	return periods
+}
@@ -0,0 +1,25 @@
+package goex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKlinePeriods(t *testing.T) {
+	periods := KlinePeriods(map[int]string{KLINE_PERIOD_1DAY: "1d", KLINE_PERIOD_1MIN: "1m", KLINE_PERIOD_60MIN: "1h"})
+	assert.Equal(t, []int{KLINE_PERIOD_1MIN, KLINE_PERIOD_60MIN, KLINE_PERIOD_1DAY}, periods)
+
+	c := Capabilities{Kline: true, KlinePeriods: periods}
+	assert.True(t, c.SupportKlinePeriod(KLINE_PERIOD_60MIN))
+	assert.False(t, c.SupportKlinePeriod(KLINE_PERIOD_5MIN))
+	assert.False(t, Capabilities{}.SupportKlinePeriod(KLINE_PERIOD_1MIN))
+}
+
+func TestIsNotSupported(t *testing.T) {
+	assert.True(t, IsNotSupported(EX_ERR_NOT_SUPPORTED))
+	assert.True(t, IsNotSupported(EX_ERR_NOT_SUPPORTED.OriginErr("kline not supported")))
+	assert.False(t, IsNotSupported(EX_ERR_SIGN))
+	assert.False(t, IsNotSupported(nil))
+	assert.Equal(t, DEFAULT_CAPABILITIES, GetCapabilities(nil))
+}
@@ -41,6 +41,95 @@ const (
 	ORDER_CANCEL_ING
 )
 
+type OrderType int
+
+func (ot OrderType) String() string {
+	switch ot {
+	case 1:
+		return "LIMIT"
+	case 2:
+		return "MARKET"
+	case 3:
+		return "STOP_LIMIT"
+	case 4:
+		return "STOP_MARKET"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//订单类型
+const (
+	ORDER_TYPE_LIMIT = 1 + iota
+	ORDER_TYPE_MARKET
+	ORDER_TYPE_STOP_LIMIT  //触发价格后下限价单
+	ORDER_TYPE_STOP_MARKET //触发价格后下市价单
+)
+
+type TimeInForce int
+
+func (tif TimeInForce) String() string {
+	return timeInForceSymbol[tif]
+}
+
+var timeInForceSymbol = [...]string{"GTC", "IOC", "FOK", "POST_ONLY"}
+
+//订单有效方式
+const (
+	TIME_IN_FORCE_GTC       = iota //一直有效直到成交或撤销
+	TIME_IN_FORCE_IOC              //立即成交,未成交部分撤销
+	TIME_IN_FORCE_FOK              //全部立即成交,否则撤销
+	TIME_IN_FORCE_POST_ONLY        //只做maker,会立即成交时撤销
+)
+
+type DepositStatus int
+
+func (ds DepositStatus) String() string {
+	return depositStatusSymbol[ds]
+}
+
+var depositStatusSymbol = [...]string{"PENDING", "SUCCESS", "FAILED"}
+
+//充值状态
+const (
+	DEPOSIT_PENDING = iota //等待确认
+	DEPOSIT_SUCCESS
+	DEPOSIT_FAILED
+)
+
+type WithdrawStatus int
+
+func (ws WithdrawStatus) String() string {
+	return withdrawStatusSymbol[ws]
+}
+
+var withdrawStatusSymbol = [...]string{"PENDING", "PROCESSING", "SUCCESS", "FAILED", "CANCELED"}
+
+//提现状态
+const (
+	WITHDRAW_PENDING    = iota //已提交,等待审核
+	WITHDRAW_PROCESSING        //已审核,转账中
+	WITHDRAW_SUCCESS
+	WITHDRAW_FAILED
+	WITHDRAW_CANCELED
+)
+
+type MarketStatus int
+
+func (ms MarketStatus) String() string {
+	return marketStatusSymbol[ms]
+}
+
+var marketStatusSymbol = [...]string{"UNKNOWN", "TRADING", "HALT", "OFFLINE"}
+
+//交易对状态
+const (
+	MARKET_STATUS_UNKNOWN = iota
+	MARKET_STATUS_TRADING //正常交易
+	MARKET_STATUS_HALT    //暂停交易
+	MARKET_STATUS_OFFLINE //已下线
+)
+
 const (
 	OPEN_BUY   = 1 + iota //开多
 	OPEN_SELL             //开空
@@ -100,4 +189,5 @@ const (
 	HITBTC      = "hitbtc.com"
 	BITMEX      = "bitmex.com"
 	CRYPTOPIA   = "cryptopia.co.nz"
+	SIMULATED   = "simulated" //内存中的模拟交易所, 见simulated包
 )
@@ -0,0 +1,104 @@
+package goex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+/**
+ * 交易所的账户凭证, 各个交易所只用到其中的一部分
+ * 比如gdax需要Passphrase, bitstamp需要ClientId, huobi需要AccountId或者AccountType
+ */
+type Credentials struct {
+	AccessKey   string `json:"access_key" yaml:"access_key" toml:"access_key"`
+	SecretKey   string `json:"secret_key" yaml:"secret_key" toml:"secret_key"`
+	Passphrase  string `json:"passphrase" yaml:"passphrase" toml:"passphrase"`       //gdax等交易所创建api key时设置的密码
+	ClientId    string `json:"client_id" yaml:"client_id" toml:"client_id"`          //bitstamp等交易所需要
+	AccountId   string `json:"account_id" yaml:"account_id" toml:"account_id"`       //huobi, aex等交易所的账户id
+	SubAccount  string `json:"sub_account" yaml:"sub_account" toml:"sub_account"`    //子账户名称, 目前没有交易所支持, 设置后创建API返回EX_ERR_NOT_SUPPORTED
+	AccountType string `json:"account_type" yaml:"account_type" toml:"account_type"` //账户类型, 比如huobi的spot, point
+}
+
+//获取凭证, 在builder创建API时调用
+type CredentialsProvider interface {
+	Retrieve() (Credentials, error)
+}
+
+//固定的凭证也是一个CredentialsProvider
+func (c Credentials) Retrieve() (Credentials, error) {
+	return c, nil
+}
+
+/**
+ * 从环境变量读取凭证, 变量名为Prefix加上
+ * _ACCESS_KEY, _SECRET_KEY, _PASSPHRASE, _CLIENT_ID, _ACCOUNT_ID, _SUB_ACCOUNT, _ACCOUNT_TYPE
+ * 比如Prefix为GOEX_BINANCE时读取GOEX_BINANCE_ACCESS_KEY
+ */
+type EnvCredentialsProvider struct {
+	Prefix string
+}
+
+func NewEnvCredentialsProvider(prefix string) *EnvCredentialsProvider {
+	return &EnvCredentialsProvider{Prefix: prefix}
+}
+
+func (p *EnvCredentialsProvider) Retrieve() (Credentials, error) {
+	env := func(name string) string {
+		return os.Getenv(strings.ToUpper(p.Prefix + "_" + name))
+	}
+	c := Credentials{
+		AccessKey:   env("ACCESS_KEY"),
+		SecretKey:   env("SECRET_KEY"),
+		Passphrase:  env("PASSPHRASE"),
+		ClientId:    env("CLIENT_ID"),
+		AccountId:   env("ACCOUNT_ID"),
+		SubAccount:  env("SUB_ACCOUNT"),
+		AccountType: env("ACCOUNT_TYPE")}
+	if c.AccessKey == "" {
+		return c, fmt.Errorf("goex: env %s_ACCESS_KEY not set", strings.ToUpper(p.Prefix))
+	}
+	return c, nil
+}
+
+/**
+ * 从json文件读取凭证, Name为空时文件内容就是一个Credentials
+ * 否则文件是以名称为key的多个Credentials, 比如 {"binance-main": {"access_key": "..."}}
+ */
+type FileCredentialsProvider struct {
+	Path string
+	Name string
+}
+
+func NewFileCredentialsProvider(path, name string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{Path: path, Name: name}
+}
+
+func (p *FileCredentialsProvider) Retrieve() (Credentials, error) {
+	var c Credentials
+	data, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return c, err
+	}
+
+	if p.Name == "" {
+		err = json.Unmarshal(data, &c)
+		if err != nil {
+			return c, fmt.Errorf("goex: parse credentials file %s: %w", p.Path, err)
+		}
+		return c, nil
+	}
+
+	var all map[string]Credentials
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return c, fmt.Errorf("goex: parse credentials file %s: %w", p.Path, err)
+	}
+	c, ok := all[p.Name]
+	if !ok {
+		return c, fmt.Errorf("goex: credentials %s not found in %s", p.Name, p.Path)
+	}
+	return c, nil
+}
@@ -0,0 +1,46 @@
+package goex
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvCredentialsProvider(t *testing.T) {
+	os.Setenv("GOEX_TEST_ACCESS_KEY", "key")
+	os.Setenv("GOEX_TEST_PASSPHRASE", "pass")
+	defer os.Unsetenv("GOEX_TEST_ACCESS_KEY")
+	defer os.Unsetenv("GOEX_TEST_PASSPHRASE")
+
+	c, err := NewEnvCredentialsProvider("goex_test").Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "key", c.AccessKey)
+	assert.Equal(t, "pass", c.Passphrase)
+
+	_, err = NewEnvCredentialsProvider("GOEX_TEST_NONE").Retrieve()
+	assert.Error(t, err)
+}
+
+func TestFileCredentialsProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	single := filepath.Join(dir, "single.json")
+	ioutil.WriteFile(single, []byte(`{"access_key":"key","account_type":"point"}`), 0600)
+	c, err := NewFileCredentialsProvider(single, "").Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "point", c.AccountType)
+
+	multi := filepath.Join(dir, "multi.json")
+	ioutil.WriteFile(multi, []byte(`{"huobi-main":{"access_key":"key","account_id":"123"}}`), 0600)
+	c, err = NewFileCredentialsProvider(multi, "huobi-main").Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "123", c.AccountId)
+
+	_, err = NewFileCredentialsProvider(multi, "none").Retrieve()
+	assert.Error(t, err)
+}
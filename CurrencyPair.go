@@ -1,6 +1,9 @@
 package goex
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 // ETH_BTC --> ethbtc
 type Symbols map[CurrencyPair]string
@@ -8,9 +11,15 @@ type Symbols map[CurrencyPair]string
 // huobi.com --> symbols
 type ExSymbols map[string]Symbols
 
-var exSymbols ExSymbols
+var (
+	exSymbols     ExSymbols
+	exMarkets     map[string]map[CurrencyPair]Market
+	exSymbolsLock sync.RWMutex
+)
 
 func GetExSymbols(exName string) Symbols {
+	exSymbolsLock.RLock()
+	defer exSymbolsLock.RUnlock()
 	ret, ok := exSymbols[exName]
 	if !ok {
 		return nil
@@ -19,6 +28,12 @@ func GetExSymbols(exName string) Symbols {
 }
 
 func RegisterExSymbol(exName string, pair CurrencyPair) {
+	exSymbolsLock.Lock()
+	defer exSymbolsLock.Unlock()
+	registerExSymbol(exName, pair, pair.ToSymbol(""))
+}
+
+func registerExSymbol(exName string, pair CurrencyPair, symbol string) {
 	if exSymbols == nil {
 		exSymbols = make(ExSymbols)
 	}
@@ -27,7 +42,70 @@ func RegisterExSymbol(exName string, pair CurrencyPair) {
 		exSymbols[exName] = make(Symbols)
 	}
 
-	exSymbols[exName][pair] = pair.ToSymbol("")
+	exSymbols[exName][pair] = symbol
+}
+
+// 缓存交易所的交易对信息,同时注册交易对
+func RegisterExMarkets(exName string, markets []Market) {
+	exSymbolsLock.Lock()
+	defer exSymbolsLock.Unlock()
+
+	if exMarkets == nil {
+		exMarkets = make(map[string]map[CurrencyPair]Market)
+	}
+
+	exMarkets[exName] = make(map[CurrencyPair]Market, len(markets))
+	for _, m := range markets {
+		exMarkets[exName][m.Pair] = m
+		symbol := m.Symbol
+		if symbol == "" {
+			symbol = m.Pair.ToSymbol("")
+		}
+		registerExSymbol(exName, m.Pair, symbol)
+	}
+}
+
+func GetExMarkets(exName string) []Market {
+	exSymbolsLock.RLock()
+	defer exSymbolsLock.RUnlock()
+	ms, ok := exMarkets[exName]
+	if !ok {
+		return nil
+	}
+	markets := make([]Market, 0, len(ms))
+	for _, m := range ms {
+		markets = append(markets, m)
+	}
+	return markets
+}
+
+func GetExMarket(exName string, pair CurrencyPair) (Market, bool) {
+	exSymbolsLock.RLock()
+	defer exSymbolsLock.RUnlock()
+	m, ok := exMarkets[exName][pair]
+	return m, ok
+}
+
+// 取交易对信息,优先使用缓存,没有缓存时调用GetMarkets并缓存
+func LoadExMarkets(api API) ([]Market, error) {
+	if markets := GetExMarkets(api.GetExchangeName()); markets != nil {
+		return markets, nil
+	}
+	return ReloadExMarkets(api)
+}
+
+// 忽略缓存,重新调用GetMarkets
+func ReloadExMarkets(api API) ([]Market, error) {
+	marketApi, ok := api.(MarketInfoAPI)
+	if !ok {
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+	markets, err := marketApi.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	RegisterExMarkets(api.GetExchangeName(), markets)
+	return markets, nil
 }
 
 type Currency struct {
@@ -3,6 +3,8 @@ package goex
 import (
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCurrency2_String(t *testing.T) {
@@ -19,3 +21,56 @@ func TestCurrencyPair2_String(t *testing.T) {
 	t.Log(strings.ToUpper(btccny.String()))
 	t.Log(BTC_CNY)
 }
+
+type marketsApi struct {
+	API
+	calls int
+}
+
+func (m *marketsApi) GetExchangeName() string {
+	return "markets.test"
+}
+
+func (m *marketsApi) GetMarkets() ([]Market, error) {
+	m.calls++
+	return []Market{{Pair: BTC_USDT, Symbol: "BTC-USDT", TickSize: 0.01, Status: MARKET_STATUS_TRADING}}, nil
+}
+
+//删除缓存的市场信息, 否则-count大于1时LoadExMarkets不会再请求
+func clearExMarkets(exName string) {
+	exSymbolsLock.Lock()
+	defer exSymbolsLock.Unlock()
+	delete(exMarkets, exName)
+	delete(exSymbols, exName)
+}
+
+func TestLoadExMarkets(t *testing.T) {
+	defer clearExMarkets("markets.test")
+	api := &marketsApi{}
+	for i := 0; i < 2; i++ {
+		markets, err := LoadExMarkets(api)
+		assert.Nil(t, err)
+		assert.Len(t, markets, 1)
+	}
+	assert.Equal(t, 1, api.calls)
+
+	m, ok := GetExMarket("markets.test", BTC_USDT)
+	assert.True(t, ok)
+	assert.Equal(t, 0.01, m.TickSize)
+	assert.Equal(t, "BTC-USDT", GetExSymbols("markets.test")[BTC_USDT])
+
+	_, err := ReloadExMarkets(&apiContextWrapper{api})
+	assert.True(t, IsNotSupported(err))
+}
+
+func TestStepToPrecision(t *testing.T) {
+	assert.Equal(t, 8, StepToPrecision(0.00000001))
+	assert.Equal(t, 2, StepToPrecision(PrecisionToStep(2)))
+	assert.Equal(t, 0, StepToPrecision(1))
+}
+
+func TestToUnixSecond(t *testing.T) {
+	assert.Equal(t, uint64(1546300800), ToUnixSecond(1546300800))
+	assert.Equal(t, uint64(1546300800), ToUnixSecond(1546300800123))
+	assert.Equal(t, uint64(0), ToUnixSecond(0))
+}
@@ -1,5 +1,7 @@
 package goex
 
+import "context"
+
 type FutureRestAPI interface {
 	/**
 	 *获取交易所名字
@@ -111,3 +113,25 @@ type FutureRestAPI interface {
 	 */
 	GetTrades(contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error)
 }
+
+/**
+ * 支持context的期货api，不涉及网络请求的方法(GetFee,GetContractValue,GetDeliveryTime)沿用FutureRestAPI
+ */
+type FutureRestAPIWithContext interface {
+	FutureRestAPI
+
+	GetFutureEstimatedPriceCtx(ctx context.Context, currencyPair CurrencyPair) (float64, error)
+	GetFutureTickerCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) (*Ticker, error)
+	GetFutureDepthCtx(ctx context.Context, currencyPair CurrencyPair, contractType string, size int) (*Depth, error)
+	GetFutureIndexCtx(ctx context.Context, currencyPair CurrencyPair) (float64, error)
+	GetFutureUserinfoCtx(ctx context.Context) (*FutureAccount, error)
+	PlaceFutureOrderCtx(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice, leverRate int) (string, error)
+	FutureCancelOrderCtx(ctx context.Context, currencyPair CurrencyPair, contractType, orderId string) (bool, error)
+	GetFuturePositionCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FuturePosition, error)
+	GetFutureOrdersCtx(ctx context.Context, orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error)
+	GetFutureOrderCtx(ctx context.Context, orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error)
+	GetUnfinishFutureOrdersCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error)
+	GetExchangeRateCtx(ctx context.Context) (float64, error)
+	GetKlineRecordsCtx(ctx context.Context, contract_type string, currency CurrencyPair, period, size, since int) ([]FutureKline, error)
+	GetTradesCtx(ctx context.Context, contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error)
+}
@@ -2,18 +2,28 @@ package goex
 
 //http request 工具函数
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func NewHttpRequest(client *http.Client, reqType string, reqUrl string, postData string, requstHeaders map[string]string) ([]byte, error) {
-	req, _ := http.NewRequest(reqType, reqUrl, strings.NewReader(postData))
+	return NewHttpRequestCtx(context.Background(), client, reqType, reqUrl, postData, requstHeaders)
+}
+
+//同NewHttpRequest，ctx被取消或超时时请求立即返回ctx.Err()
+func NewHttpRequestCtx(ctx context.Context, client *http.Client, reqType string, reqUrl string, postData string, requstHeaders map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(reqType, reqUrl, strings.NewReader(postData))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 5.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/31.0.1650.63 Safari/537.36")
 
 	if requstHeaders != nil {
@@ -35,14 +45,21 @@ func NewHttpRequest(client *http.Client, reqType string, reqUrl string, postData
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(bodyData)))
+		return nil, HttpError{
+			StatusCode: resp.StatusCode,
+			Body:       string(bodyData),
+			RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
 
 	return bodyData, nil
 }
 
 func HttpGet(client *http.Client, reqUrl string) (map[string]interface{}, error) {
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", nil)
+	return HttpGetCtx(context.Background(), client, reqUrl)
+}
+
+func HttpGetCtx(ctx context.Context, client *http.Client, reqUrl string) (map[string]interface{}, error) {
+	respData, err := NewHttpRequestCtx(ctx, client, "GET", reqUrl, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,11 +74,15 @@ func HttpGet(client *http.Client, reqUrl string) (map[string]interface{}, error)
 }
 
 func HttpGet2(client *http.Client, reqUrl string, headers map[string]string) (map[string]interface{}, error) {
+	return HttpGet2Ctx(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet2Ctx(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) (map[string]interface{}, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestCtx(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -76,11 +97,15 @@ func HttpGet2(client *http.Client, reqUrl string, headers map[string]string) (ma
 }
 
 func HttpGet3(client *http.Client, reqUrl string, headers map[string]string) ([]interface{}, error) {
+	return HttpGet3Ctx(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet3Ctx(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) ([]interface{}, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestCtx(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -95,11 +120,15 @@ func HttpGet3(client *http.Client, reqUrl string, headers map[string]string) ([]
 }
 
 func HttpGet4(client *http.Client, reqUrl string, headers map[string]string, result interface{}) error {
+	return HttpGet4Ctx(context.Background(), client, reqUrl, headers, result)
+}
+
+func HttpGet4Ctx(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string, result interface{}) error {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestCtx(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return err
 	}
@@ -112,12 +141,17 @@ func HttpGet4(client *http.Client, reqUrl string, headers map[string]string, res
 
 	return nil
 }
+
 func HttpGet5(client *http.Client, reqUrl string, headers map[string]string) ([]byte, error) {
+	return HttpGet5Ctx(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet5Ctx(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestCtx(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -126,36 +160,104 @@ func HttpGet5(client *http.Client, reqUrl string, headers map[string]string) ([]
 }
 
 func HttpPostForm(client *http.Client, reqUrl string, postData url.Values) ([]byte, error) {
+	return HttpPostFormCtx(context.Background(), client, reqUrl, postData)
+}
+
+func HttpPostFormCtx(ctx context.Context, client *http.Client, reqUrl string, postData url.Values) ([]byte, error) {
 	headers := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded"}
-	return NewHttpRequest(client, "POST", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestCtx(ctx, client, "POST", reqUrl, postData.Encode(), headers)
 }
 
 func HttpPostForm2(client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
+	return HttpPostForm2Ctx(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm2Ctx(ctx context.Context, client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	return NewHttpRequest(client, "POST", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestCtx(ctx, client, "POST", reqUrl, postData.Encode(), headers)
 }
 
 func HttpPostForm3(client *http.Client, reqUrl string, postData string, headers map[string]string) ([]byte, error) {
-	return NewHttpRequest(client, "POST", reqUrl, postData, headers)
+	return HttpPostForm3Ctx(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm3Ctx(ctx context.Context, client *http.Client, reqUrl string, postData string, headers map[string]string) ([]byte, error) {
+	return NewHttpRequestCtx(ctx, client, "POST", reqUrl, postData, headers)
 }
 
 func HttpPostForm4(client *http.Client, reqUrl string, postData map[string]string, headers map[string]string) ([]byte, error) {
+	return HttpPostForm4Ctx(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm4Ctx(ctx context.Context, client *http.Client, reqUrl string, postData map[string]string, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/json"
 	data, _ := json.Marshal(postData)
-	return NewHttpRequest(client, "POST", reqUrl, string(data), headers)
+	return NewHttpRequestCtx(ctx, client, "POST", reqUrl, string(data), headers)
 }
 
 func HttpDeleteForm(client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
+	return HttpDeleteFormCtx(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpDeleteFormCtx(ctx context.Context, client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	return NewHttpRequest(client, "DELETE", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestCtx(ctx, client, "DELETE", reqUrl, postData.Encode(), headers)
+}
+
+//Retry-After可以是秒数或者http时间
+func ParseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+/**
+ * 用apiUrl替换默认地址的scheme和host, 保留默认地址的path和query, apiUrl为空时返回默认地址
+ * 比如 OverrideUrl("https://www.bitmex.com/api/v1/", "https://testnet.bitmex.com") 返回 https://testnet.bitmex.com/api/v1/
+ * 默认地址是websocket时, apiUrl的http, https转换为ws, wss
+ */
+func OverrideUrl(defaultUrl, apiUrl string) string {
+	if apiUrl == "" {
+		return defaultUrl
+	}
+	d, err := url.Parse(defaultUrl)
+	if err != nil {
+		return defaultUrl
+	}
+	o, err := url.Parse(apiUrl)
+	if err != nil || o.Host == "" {
+		return defaultUrl
+	}
+
+	scheme := o.Scheme
+	if d.Scheme == "ws" || d.Scheme == "wss" {
+		switch scheme {
+		case "http":
+			scheme = "ws"
+		case "https":
+			scheme = "wss"
+		}
+	}
+	d.Scheme, d.Host = scheme, o.Host
+	d.Path = strings.TrimSuffix(o.Path, "/") + d.Path
+	return d.String()
 }
@@ -0,0 +1,63 @@
+package goex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHttpRequestCtx_Cancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := HttpGetCtx(ctx, http.DefaultClient, srv.URL)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	api := WithContext(nil)
+	_, err := api.GetTickerCtx(ctx, BTC_USDT)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestNewHttpRequest_HttpError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":-1003}`))
+	}))
+	defer srv.Close()
+
+	_, err := HttpGet(http.DefaultClient, srv.URL)
+	httpErr, ok := err.(HttpError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	assert.Equal(t, `{"code":-1003}`, httpErr.Body)
+	assert.Equal(t, 3*time.Second, httpErr.RetryAfter)
+	assert.True(t, IsRateLimited(err))
+	assert.True(t, IsTransient(err))
+}
+
+func TestOverrideUrl(t *testing.T) {
+	assert.Equal(t, "https://testnet.bitmex.com/api/v1/", OverrideUrl("https://www.bitmex.com/api/v1/", "https://testnet.bitmex.com"))
+	assert.Equal(t, "http://127.0.0.1:8080/prefix/api/v1/", OverrideUrl("https://www.bitmex.com/api/v1/", "http://127.0.0.1:8080/prefix/"))
+	assert.Equal(t, "ws://127.0.0.1:8080/ws", OverrideUrl("wss://api.huobi.br.com/ws", "http://127.0.0.1:8080"))
+	assert.Equal(t, "https://www.bitmex.com/api/v1/", OverrideUrl("https://www.bitmex.com/api/v1/", ""))
+}
@@ -11,12 +11,15 @@ type Order struct {
 	AvgPrice,
 	DealAmount,
 	Fee float64
-	OrderID2  string
-	OrderID   int
-	OrderTime int
-	Status    TradeStatus
-	Currency  CurrencyPair
-	Side      TradeSide
+	OrderID2      string
+	OrderID       int
+	OrderTime     int
+	Status        TradeStatus
+	Currency      CurrencyPair
+	Side          TradeSide
+	Type          OrderType
+	TimeInForce   TimeInForce
+	ClientOrderID string
 }
 
 type Trade struct {
@@ -29,6 +32,20 @@ type Trade struct {
 	BigId  string       `json:"big_id"`			//增对于火币网的超大int
 }
 
+//个人成交记录,一个订单可能有多笔成交
+type Fill struct {
+	OrderID     string
+	TradeID     string
+	Pair        CurrencyPair
+	Side        TradeSide //BUY,SELL
+	Price       float64
+	Amount      float64
+	Fee         float64
+	FeeCurrency Currency
+	IsMaker     bool  //部分交易所不返回maker/taker,默认为taker
+	Time        int64 //毫秒
+}
+
 type SubAccount struct {
 	Currency Currency
 	Amount,
@@ -74,6 +91,7 @@ func (dr DepthRecords) Less(i, j int) bool {
 	return dr[i].Price < dr[j].Price
 }
 
+//AskList和BidList都按价格从高到低排列, AskList最后一个是卖一
 type Depth struct {
 	ContractType string //for future
 	Pair         CurrencyPair
@@ -83,10 +101,11 @@ type Depth struct {
 }
 
 type APIConfig struct {
-	HttpClient *http.Client
-	ApiUrl,
-	AccessKey,
-	SecretKey string
+	HttpClient    *http.Client
+	ApiUrl        string        //替换交易所rest接口的地址, 见OverrideUrl
+	WsUrl         string        //替换交易所websocket的地址
+	WsDialOptions WsDialOptions //websocket连接的代理, header等
+	Credentials
 }
 
 type Kline struct {
@@ -150,3 +169,68 @@ type FuturePosition struct {
 	ContractId     int64
 	ForceLiquPrice float64 //预估爆仓价
 }
+
+//交易对的交易规则
+type Market struct {
+	Pair            CurrencyPair
+	Symbol          string  //交易所的交易对名称
+	PricePrecision  int     //价格小数位
+	AmountPrecision int     //数量小数位
+	TickSize        float64 //价格最小变动单位
+	LotSize         float64 //数量最小变动单位
+	MinAmount       float64 //最小下单数量
+	MinNotional     float64 //最小下单金额(price*amount)
+	Status          MarketStatus
+}
+
+//PlaceOrder的下单参数
+type OrderRequest struct {
+	Currency      CurrencyPair
+	Side          TradeSide //BUY,SELL
+	Type          OrderType
+	TimeInForce   TimeInForce
+	Amount        string
+	Price         string //限价单价格
+	StopPrice     string //止损单触发价格
+	ClientOrderID string //用户自定义订单id,重试下单时可避免重复下单
+}
+
+//充值地址,Tag是部分币种(XRP,EOS等)需要的memo/payment id
+type DepositAddress struct {
+	Currency Currency
+	Address  string
+	Tag      string
+}
+
+type WithdrawRequest struct {
+	Currency Currency
+	Amount   string
+	Address  string
+	Tag      string //memo/payment id
+	Fee      string //手续费,部分交易所需要
+	SafePwd  string //资金密码,部分交易所需要
+}
+
+type Deposit struct {
+	Id            string
+	Currency      Currency
+	Amount        float64
+	Address       string
+	Tag           string
+	TxId          string
+	Confirmations int
+	Status        DepositStatus
+	Time          int64 //毫秒
+}
+
+type Withdrawal struct {
+	Id       string
+	Currency Currency
+	Amount   float64
+	Fee      float64
+	Address  string
+	Tag      string
+	TxId     string
+	Status   WithdrawStatus
+	Time     int64 //毫秒
+}
@@ -0,0 +1,228 @@
+package goex
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RoundMode int
+
+const (
+	ROUND_HALF = iota //四舍五入
+	ROUND_DOWN        //向下取整
+	ROUND_UP          //向上取整
+)
+
+/**
+ * 下单前按交易对信息(GetMarkets)规整价格和数量:
+ * 价格按TickSize取整,数量按LotSize取整,低于最小数量或最小金额的订单直接在本地拒绝,
+ * 不支持GetMarkets或查不到交易对信息时原样下单, GetMarkets出错时同样原样下单, MarketsRetryInterval之后才重新查询
+ * 市价单的amount在部分交易所表示金额,所以只规整限价单
+ * 只包装下单的接口, 其它可选接口(WalletAPI, SpotWsAPI等)通过Unwrap取得被包装的API调用
+ */
+type OrderNormalizer struct {
+	API
+	PriceRound           RoundMode
+	AmountRound          RoundMode
+	MarketsRetryInterval time.Duration //GetMarkets出错后多久重新查询
+
+	lock    sync.Mutex
+	retryAt time.Time //GetMarkets出错后, 这个时间之前不再查询
+}
+
+//默认价格四舍五入,数量向下取整, GetMarkets出错后一分钟内不再查询
+func NewOrderNormalizer(api API) *OrderNormalizer {
+	return &OrderNormalizer{API: api, PriceRound: ROUND_HALF, AmountRound: ROUND_DOWN, MarketsRetryInterval: time.Minute}
+}
+
+//被包装的API, 用于调用下单以外的可选接口
+func (n *OrderNormalizer) Unwrap() API {
+	return n.API
+}
+
+func (n *OrderNormalizer) Capabilities() Capabilities {
+	return GetCapabilities(n.API)
+}
+
+func (n *OrderNormalizer) GetMarkets() ([]Market, error) {
+	return LoadExMarkets(n.API)
+}
+
+func (n *OrderNormalizer) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	amount, price, err := n.Normalize(currency, amount, price)
+	if err != nil {
+		return nil, err
+	}
+	return n.API.LimitBuy(amount, price, currency)
+}
+
+func (n *OrderNormalizer) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	amount, price, err := n.Normalize(currency, amount, price)
+	if err != nil {
+		return nil, err
+	}
+	return n.API.LimitSell(amount, price, currency)
+}
+
+func (n *OrderNormalizer) LimitBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	amount, price, err := n.Normalize(currency, amount, price)
+	if err != nil {
+		return nil, err
+	}
+	return WithContext(n.API).LimitBuyCtx(ctx, amount, price, currency)
+}
+
+func (n *OrderNormalizer) LimitSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	amount, price, err := n.Normalize(currency, amount, price)
+	if err != nil {
+		return nil, err
+	}
+	return WithContext(n.API).LimitSellCtx(ctx, amount, price, currency)
+}
+
+//规整后下单,见NormalizeRequest
+func (n *OrderNormalizer) PlaceOrder(req OrderRequest) (*Order, error) {
+	req, err := n.NormalizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return PlaceOrder(n.API, req)
+}
+
+//逐个规整后批量下单, 规整失败的订单不发送, 错误在BatchOrderResult.Err
+func (n *OrderNormalizer) BatchPlaceOrders(reqs []OrderRequest) ([]BatchOrderResult, error) {
+	results := make([]BatchOrderResult, len(reqs))
+	var valid []OrderRequest
+	var index []int
+	for i, req := range reqs {
+		req, err := n.NormalizeRequest(req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, req)
+		index = append(index, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	validResults, err := BatchPlaceOrders(n.API, valid)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range validResults {
+		results[index[i]] = r
+	}
+	return results, nil
+}
+
+//限价单和止损限价单规整价格和数量, 止损单规整触发价格, 其他原样返回
+func (n *OrderNormalizer) NormalizeRequest(req OrderRequest) (OrderRequest, error) {
+	market, ok := n.market(req.Currency)
+	if !ok {
+		return req, nil
+	}
+
+	var err error
+	if req.Type == ORDER_TYPE_LIMIT || req.Type == ORDER_TYPE_STOP_LIMIT {
+		req.Amount, req.Price, err = NormalizeOrder(market, req.Amount, req.Price, n.PriceRound, n.AmountRound)
+		if err != nil {
+			return req, err
+		}
+	}
+	if req.StopPrice != "" && (req.Type == ORDER_TYPE_STOP_LIMIT || req.Type == ORDER_TYPE_STOP_MARKET) {
+		req.StopPrice, err = normalizePrice(market, req.StopPrice, n.PriceRound)
+	}
+	return req, err
+}
+
+//返回规整后的数量和价格
+func (n *OrderNormalizer) Normalize(currency CurrencyPair, amount, price string) (string, string, error) {
+	market, ok := n.market(currency)
+	if !ok {
+		return amount, price, nil
+	}
+	return NormalizeOrder(market, amount, price, n.PriceRound, n.AmountRound)
+}
+
+//查不到交易对信息时返回false, GetMarkets出错时不阻止下单, 也不在每次下单时重试
+func (n *OrderNormalizer) market(currency CurrencyPair) (Market, bool) {
+	n.lock.Lock()
+	retryAt := n.retryAt
+	n.lock.Unlock()
+	if time.Now().Before(retryAt) {
+		return Market{}, false
+	}
+
+	_, err := LoadExMarkets(n.API)
+	if err != nil {
+		if !IsNotSupported(err) {
+			log.Println("goex: load", n.API.GetExchangeName(), "markets error, order not normalized:", err)
+		}
+		n.lock.Lock()
+		n.retryAt = time.Now().Add(n.MarketsRetryInterval)
+		n.lock.Unlock()
+		return Market{}, false
+	}
+	return GetExMarket(n.API.GetExchangeName(), currency)
+}
+
+func NormalizeOrder(market Market, amount, price string, priceRound, amountRound RoundMode) (string, string, error) {
+	amountF, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid amount %s", amount)
+	}
+	price, err = normalizePrice(market, price, priceRound)
+	if err != nil {
+		return "", "", err
+	}
+	priceF, _ := strconv.ParseFloat(price, 64)
+
+	if market.LotSize > 0 {
+		amountF = RoundToStep(amountF, market.LotSize, amountRound)
+		amount = strconv.FormatFloat(amountF, 'f', StepToPrecision(market.LotSize), 64)
+	}
+
+	if amountF <= 0 || amountF < market.MinAmount {
+		return "", "", EX_ERR_AMOUNT_TOO_SMALL.OriginErr(fmt.Sprintf("order amount %s below minimum %v", amount, market.MinAmount))
+	}
+
+	if market.MinNotional > 0 && amountF*priceF < market.MinNotional {
+		return "", "", EX_ERR_NOTIONAL_TOO_SMALL.OriginErr(fmt.Sprintf("order notional %v below minimum %v", amountF*priceF, market.MinNotional))
+	}
+
+	return amount, price, nil
+}
+
+func normalizePrice(market Market, price string, priceRound RoundMode) (string, error) {
+	priceF, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid price %s", price)
+	}
+	if market.TickSize > 0 {
+		priceF = RoundToStep(priceF, market.TickSize, priceRound)
+		price = strconv.FormatFloat(priceF, 'f', StepToPrecision(market.TickSize), 64)
+	}
+	return price, nil
+}
+
+//按最小变动单位取整
+func RoundToStep(v, step float64, mode RoundMode) float64 {
+	//消除浮点误差,避免0.3/0.1=2.9999999999999996被向下取整为2
+	n := math.Round(v/step*1e8) / 1e8
+	switch mode {
+	case ROUND_DOWN:
+		n = math.Floor(n)
+	case ROUND_UP:
+		n = math.Ceil(n)
+	default:
+		n = math.Round(n)
+	}
+	return n * step
+}
@@ -0,0 +1,168 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type normalizerApi struct {
+	API
+	amount, price string
+}
+
+func (a *normalizerApi) GetExchangeName() string {
+	return "normalizer.test"
+}
+
+func (a *normalizerApi) GetMarkets() ([]Market, error) {
+	return []Market{{Pair: BTC_USDT, TickSize: 0.01, LotSize: 0.0001, MinAmount: 0.001, MinNotional: 10}}, nil
+}
+
+func (a *normalizerApi) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	a.amount, a.price = amount, price
+	return &Order{}, nil
+}
+
+func TestOrderNormalizer_LimitBuy(t *testing.T) {
+	api := &normalizerApi{}
+	n := NewOrderNormalizer(api)
+
+	_, err := n.LimitBuy("0.12345678", "6500.126", BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1234", api.amount)
+	assert.Equal(t, "6500.13", api.price)
+
+	n.PriceRound = ROUND_DOWN
+	n.LimitBuy("0.3", "6500.129", BTC_USDT)
+	assert.Equal(t, "0.3000", api.amount)
+	assert.Equal(t, "6500.12", api.price)
+
+	_, err = n.LimitBuy("0.0009", "6500", BTC_USDT)
+	assert.Equal(t, EX_ERR_AMOUNT_TOO_SMALL.ErrCode, err.(ApiError).ErrCode)
+
+	_, err = n.LimitBuy("0.001", "6500", BTC_USDT)
+	assert.Equal(t, EX_ERR_NOTIONAL_TOO_SMALL.ErrCode, err.(ApiError).ErrCode)
+
+	api.amount = ""
+	_, err = n.LimitBuy("0.123456", "1", LTC_BTC)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.123456", api.amount)
+}
+
+//实现了OrderAPI和MyTradesAPI
+type normalizerOrderApi struct {
+	normalizerApi
+	req OrderRequest
+}
+
+func (a *normalizerOrderApi) PlaceOrder(req OrderRequest) (*Order, error) {
+	a.req = req
+	return &Order{}, nil
+}
+
+func (a *normalizerOrderApi) GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	return []Fill{{Pair: currency}}, nil
+}
+
+//GetMarkets出错
+type normalizerFailApi struct {
+	normalizerApi
+	calls int
+}
+
+func (a *normalizerFailApi) GetExchangeName() string {
+	return "normalizer.fail.test"
+}
+
+func (a *normalizerFailApi) GetMarkets() ([]Market, error) {
+	a.calls++
+	return nil, errors.New("timeout")
+}
+
+func TestOrderNormalizer_PlaceOrder(t *testing.T) {
+	defer clearExMarkets("normalizer.test")
+	api := &normalizerOrderApi{}
+	n := NewOrderNormalizer(api)
+
+	_, err := n.PlaceOrder(OrderRequest{Currency: BTC_USDT, Side: SELL, Type: ORDER_TYPE_STOP_LIMIT, Amount: "0.12345", Price: "6400.126", StopPrice: "6450.004"})
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1234", api.req.Amount)
+	assert.Equal(t, "6400.13", api.req.Price)
+	assert.Equal(t, "6450.00", api.req.StopPrice)
+
+	//市价止损单只规整触发价格
+	_, err = n.PlaceOrder(OrderRequest{Currency: BTC_USDT, Side: SELL, Type: ORDER_TYPE_STOP_MARKET, Amount: "0.12345", StopPrice: "6450.004"})
+	assert.Nil(t, err)
+	assert.Equal(t, "0.12345", api.req.Amount)
+	assert.Equal(t, "6450.00", api.req.StopPrice)
+
+	results, err := n.BatchPlaceOrders([]OrderRequest{
+		{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_LIMIT, Amount: "0.0009", Price: "6500"},
+		{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_LIMIT, Amount: "0.12345", Price: "6500.126"}})
+	assert.Nil(t, err)
+	assert.Equal(t, EX_ERR_AMOUNT_TOO_SMALL.ErrCode, results[0].Err.(ApiError).ErrCode)
+	assert.Nil(t, results[1].Err)
+	assert.Equal(t, "6500.13", api.req.Price)
+}
+
+func TestOrderNormalizer_Ctx(t *testing.T) {
+	defer clearExMarkets("normalizer.test")
+	api := &normalizerOrderApi{}
+	n := NewOrderNormalizer(api)
+
+	_, err := n.LimitBuyCtx(context.Background(), "0.12345678", "6500.126", BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1234", api.amount)
+	assert.Equal(t, "6500.13", api.price)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = n.LimitBuyCtx(ctx, "0.1", "6500", BTC_USDT)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestOrderNormalizer_Unwrap(t *testing.T) {
+	inner := &normalizerOrderApi{}
+	n := NewOrderNormalizer(inner)
+
+	//不转发下单以外的可选接口, 类型断言的结果和被包装的API一致
+	var api API = n
+	_, ok := api.(MyTradesAPI)
+	assert.False(t, ok)
+	_, ok = api.(SpotWsAPI)
+	assert.False(t, ok)
+
+	fills, err := n.Unwrap().(MyTradesAPI).GetMyTrades(BTC_USDT, 0, 10)
+	assert.Nil(t, err)
+	assert.Len(t, fills, 1)
+}
+
+func TestOrderNormalizer_MarketsError(t *testing.T) {
+	api := &normalizerFailApi{}
+	n := NewOrderNormalizer(api)
+
+	//查询交易对信息失败时原样下单
+	_, err := n.LimitBuy("0.12345678", "6500.126", BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.12345678", api.amount)
+	assert.Equal(t, "6500.126", api.price)
+
+	//MarketsRetryInterval之内不再查询
+	n.LimitBuy("0.1", "6500", BTC_USDT)
+	assert.Equal(t, 1, api.calls)
+
+	n.MarketsRetryInterval = 0
+	n.retryAt = time.Time{}
+	n.LimitBuy("0.1", "6500", BTC_USDT)
+	assert.Equal(t, 2, api.calls)
+}
+
+func TestRoundToStep(t *testing.T) {
+	assert.InDelta(t, 0.3, RoundToStep(0.3, 0.1, ROUND_DOWN), 1e-12)
+	assert.InDelta(t, 0.4, RoundToStep(0.31, 0.1, ROUND_UP), 1e-12)
+	assert.InDelta(t, 5.0, RoundToStep(7.4, 5, ROUND_HALF), 1e-12)
+}
@@ -0,0 +1,66 @@
+package goex
+
+import "errors"
+
+/**
+ * 下单,交易所实现了OrderAPI时调用PlaceOrder,
+ * 否则普通限价单和市价单转为LimitBuy,MarketBuy等, 其他类型返回EX_ERR_NOT_SUPPORTED
+ */
+func PlaceOrder(api API, req OrderRequest) (*Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+
+	if orderApi, ok := api.(OrderAPI); ok {
+		return orderApi.PlaceOrder(req)
+	}
+
+	if req.TimeInForce != TIME_IN_FORCE_GTC || req.ClientOrderID != "" {
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		if req.Side == BUY {
+			return api.LimitBuy(req.Amount, req.Price, req.Currency)
+		}
+		return api.LimitSell(req.Amount, req.Price, req.Currency)
+	case ORDER_TYPE_MARKET:
+		if req.Side == BUY {
+			return api.MarketBuy(req.Amount, req.Price, req.Currency)
+		}
+		return api.MarketSell(req.Amount, req.Price, req.Currency)
+	}
+
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+//检查下单参数是否完整
+func (req OrderRequest) Check() error {
+	if req.Side != BUY && req.Side != SELL {
+		return errors.New("order side must be BUY or SELL")
+	}
+	if req.Amount == "" {
+		return errors.New("order amount is empty")
+	}
+
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		if req.Price == "" {
+			return errors.New("limit order price is empty")
+		}
+	case ORDER_TYPE_MARKET:
+	case ORDER_TYPE_STOP_LIMIT:
+		if req.Price == "" || req.StopPrice == "" {
+			return errors.New("stop-limit order price or stop price is empty")
+		}
+	case ORDER_TYPE_STOP_MARKET:
+		if req.StopPrice == "" {
+			return errors.New("stop-market order stop price is empty")
+		}
+	default:
+		return errors.New("unknown order type")
+	}
+
+	return nil
+}
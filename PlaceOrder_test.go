@@ -0,0 +1,44 @@
+package goex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type placeOrderApi struct {
+	API
+	called string
+}
+
+func (a *placeOrderApi) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	a.called = "LimitSell"
+	return &Order{}, nil
+}
+
+func (a *placeOrderApi) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	a.called = "MarketBuy"
+	return &Order{}, nil
+}
+
+func TestPlaceOrder(t *testing.T) {
+	api := &placeOrderApi{}
+
+	_, err := PlaceOrder(api, OrderRequest{Currency: BTC_USDT, Side: SELL, Type: ORDER_TYPE_LIMIT, Amount: "1", Price: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "LimitSell", api.called)
+
+	_, err = PlaceOrder(api, OrderRequest{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_MARKET, Amount: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "MarketBuy", api.called)
+
+	_, err = PlaceOrder(api, OrderRequest{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_LIMIT, Amount: "1", Price: "1", TimeInForce: TIME_IN_FORCE_IOC})
+	assert.True(t, IsNotSupported(err))
+
+	_, err = PlaceOrder(api, OrderRequest{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_STOP_MARKET, Amount: "1", StopPrice: "1"})
+	assert.True(t, IsNotSupported(err))
+
+	_, err = PlaceOrder(api, OrderRequest{Currency: BTC_USDT, Side: BUY, Type: ORDER_TYPE_STOP_LIMIT, Amount: "1", Price: "1"})
+	assert.Error(t, err)
+	assert.False(t, IsNotSupported(err))
+}
@@ -0,0 +1,233 @@
+package goex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateLimitPolicy int
+
+const (
+	RATE_LIMIT_BLOCK     RateLimitPolicy = iota //等待直到有足够的额度
+	RATE_LIMIT_FAIL_FAST                        //额度不足时直接返回EX_ERR_API_LIMIT
+)
+
+//令牌桶, 每个interval补充limit个令牌, weights为0的请求不占用这个桶
+type rateBucket struct {
+	limit    float64
+	rate     float64 //每纳秒补充的令牌数
+	tokens   float64
+	last     time.Time
+	method   string
+	prefix   string //只统计匹配的请求, 为空时统计全部请求
+	weights  []endpointWeight
+	pausedTo time.Time
+}
+
+type endpointWeight struct {
+	method string
+	prefix string
+	weight int
+}
+
+/**
+ * 交易所的请求频率限制, 按endpoint的权重扣减令牌, 比如binance每分钟1200权重, /api/v3/account权重为5
+ * 通过RateLimitTransport在http层生效, 同一个交易所的多个API实例应该共用一个RateLimiter
+ */
+type RateLimiter struct {
+	Policy RateLimitPolicy
+
+	//交易所返回的已用权重, 比如binance的X-MBX-USED-WEIGHT, 用于校正本地的令牌数
+	UsedWeightHeader string
+
+	lock    sync.Mutex
+	buckets []*rateBucket
+}
+
+func NewRateLimiter(limit int, interval time.Duration) *RateLimiter {
+	l := &RateLimiter{Policy: RATE_LIMIT_BLOCK}
+	l.AddLimit("", "", limit, interval)
+	return l
+}
+
+//额外的限制, 只统计method和path前缀匹配的请求, 比如binance下单每秒10次, method为空时匹配所有method
+func (l *RateLimiter) AddLimit(method, pathPrefix string, limit int, interval time.Duration) *RateLimiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.buckets = append(l.buckets, &rateBucket{
+		limit:  float64(limit),
+		rate:   float64(limit) / float64(interval),
+		tokens: float64(limit),
+		last:   time.Now(),
+		method: method,
+		prefix: pathPrefix})
+	return l
+}
+
+//修改额度不足时的处理方式, 可以在使用中调用
+func (l *RateLimiter) SetPolicy(policy RateLimitPolicy) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.Policy = policy
+}
+
+//第一个限制中endpoint的权重, 按最长前缀匹配, 默认为1
+func (l *RateLimiter) Weight(method, pathPrefix string, weight int) *RateLimiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	b := l.buckets[0]
+	b.weights = append(b.weights, endpointWeight{method, pathPrefix, weight})
+	return l
+}
+
+//等待请求需要的额度, ctx被取消时返回ctx.Err()
+func (l *RateLimiter) Wait(ctx context.Context, method, path string) error {
+	l.lock.Lock()
+	now := time.Now()
+	weights := make([]float64, len(l.buckets))
+	var wait time.Duration
+	for i, b := range l.buckets {
+		weights[i] = math.Min(b.weightOf(method, path), b.limit)
+		if weights[i] == 0 {
+			continue
+		}
+		b.refill(now)
+		if w := b.waitFor(now, weights[i]); w > wait {
+			wait = w
+		}
+	}
+
+	if wait > 0 && l.Policy == RATE_LIMIT_FAIL_FAST {
+		l.lock.Unlock()
+		return EX_ERR_API_LIMIT.OriginErr(fmt.Sprintf("rate limit exceeded, retry after %s", wait))
+	}
+
+	//先扣减令牌(可以为负), 后来的请求排在后面
+	for i, b := range l.buckets {
+		b.tokens -= weights[i]
+	}
+	l.lock.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.lock.Lock()
+		for i, b := range l.buckets {
+			b.tokens += weights[i]
+		}
+		l.lock.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//交易所返回的已用权重大于本地统计时, 减少第一个限制的剩余令牌
+func (l *RateLimiter) UpdateUsed(used int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	b := l.buckets[0]
+	b.refill(time.Now())
+	if remain := b.limit - float64(used); remain < b.tokens {
+		b.tokens = remain
+	}
+}
+
+//被交易所限频后暂停所有请求
+func (l *RateLimiter) Pause(d time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	to := time.Now().Add(d)
+	for _, b := range l.buckets {
+		if to.After(b.pausedTo) {
+			b.pausedTo = to
+		}
+	}
+}
+
+func (b *rateBucket) refill(now time.Time) {
+	b.tokens += float64(now.Sub(b.last)) * b.rate
+	if b.tokens > b.limit {
+		b.tokens = b.limit
+	}
+	b.last = now
+}
+
+func (b *rateBucket) waitFor(now time.Time, weight float64) time.Duration {
+	var wait time.Duration
+	if b.tokens < weight {
+		wait = time.Duration((weight - b.tokens) / b.rate)
+	}
+	if paused := b.pausedTo.Sub(now); paused > wait {
+		wait = paused
+	}
+	return wait
+}
+
+func (b *rateBucket) weightOf(method, path string) float64 {
+	if !matchEndpoint(b.method, b.prefix, method, path) {
+		return 0
+	}
+
+	weight, matched := 1, -1
+	for _, w := range b.weights {
+		if len(w.prefix) > matched && matchEndpoint(w.method, w.prefix, method, path) {
+			weight, matched = w.weight, len(w.prefix)
+		}
+	}
+	return float64(weight)
+}
+
+func matchEndpoint(method, prefix, reqMethod, reqPath string) bool {
+	return (method == "" || method == reqMethod) && strings.HasPrefix(reqPath, prefix)
+}
+
+//在http层做限频的RoundTripper
+type RateLimitTransport struct {
+	Transport http.RoundTripper
+	Limiter   *RateLimiter
+}
+
+func NewRateLimitTransport(transport http.RoundTripper, limiter *RateLimiter) *RateLimitTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &RateLimitTransport{Transport: transport, Limiter: limiter}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.Limiter.Wait(req.Context(), req.Method, req.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Limiter.UsedWeightHeader != "" {
+		if used, err := strconv.Atoi(resp.Header.Get(t.Limiter.UsedWeightHeader)); err == nil {
+			t.Limiter.UpdateUsed(used)
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		if retryAfter := ParseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+			t.Limiter.Pause(retryAfter)
+		}
+	}
+
+	return resp, nil
+}
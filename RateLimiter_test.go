@@ -0,0 +1,58 @@
+package goex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Weight(t *testing.T) {
+	l := NewRateLimiter(10, time.Minute).Weight("GET", "/api/v3/account", 5)
+	l.Policy = RATE_LIMIT_FAIL_FAST
+
+	assert.Nil(t, l.Wait(context.Background(), "GET", "/api/v3/account"))
+	assert.Nil(t, l.Wait(context.Background(), "GET", "/api/v3/ticker"))
+	assert.Nil(t, l.Wait(context.Background(), "POST", "/api/v3/account"))
+	err := l.Wait(context.Background(), "GET", "/api/v3/account")
+	assert.True(t, IsRateLimited(err))
+	assert.Nil(t, l.Wait(context.Background(), "GET", "/api/v3/ticker"))
+}
+
+func TestRateLimiter_Block(t *testing.T) {
+	l := NewRateLimiter(100, time.Second).AddLimit("POST", "/order", 1, 50*time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, l.Wait(context.Background(), "POST", "/order"))
+	}
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.Pause(time.Second)
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, "GET", "/ticker"))
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT", "10")
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	l := NewRateLimiter(10, time.Minute)
+	l.Policy = RATE_LIMIT_FAIL_FAST
+	l.UsedWeightHeader = "X-MBX-USED-WEIGHT"
+	client := &http.Client{Transport: NewRateLimitTransport(nil, l)}
+
+	_, err := HttpGet(client, srv.URL)
+	assert.Nil(t, err)
+
+	//交易所返回的已用权重已经达到上限
+	_, err = HttpGet(client, srv.URL)
+	assert.True(t, IsRateLimited(err))
+}
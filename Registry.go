@@ -0,0 +1,113 @@
+package goex
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+type ExchangeFactory func(config *APIConfig) API
+
+type FutureExchangeFactory func(config *APIConfig) FutureRestAPI
+
+var (
+	exchangesLock   sync.RWMutex
+	exchanges       = make(map[string]ExchangeFactory)
+	futureExchanges = make(map[string]FutureExchangeFactory)
+)
+
+/**
+ * 注册交易所, 各个交易所的包在init中调用, 外部实现的交易所也可以注册
+ * name一般和GetExchangeName()一致, 重复注册或factory为nil时panic
+ */
+func RegisterExchange(name string, factory ExchangeFactory) {
+	exchangesLock.Lock()
+	defer exchangesLock.Unlock()
+	if factory == nil {
+		panic("goex: register exchange factory is nil, " + name)
+	}
+	if _, dup := exchanges[name]; dup {
+		panic("goex: register exchange twice, " + name)
+	}
+	exchanges[name] = factory
+}
+
+func NewExchangeAPI(name string, config *APIConfig) (API, error) {
+	exchangesLock.RLock()
+	factory, ok := exchanges[name]
+	exchangesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("goex: unknown exchange %s (forgotten import?)", name)
+	}
+	c, err := newAPIConfig(name, config)
+	if err != nil {
+		return nil, err
+	}
+	return factory(c), nil
+}
+
+//已注册的交易所, 按名称排序
+func ListExchanges() []string {
+	exchangesLock.RLock()
+	defer exchangesLock.RUnlock()
+	names := make([]string, 0, len(exchanges))
+	for name := range exchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//注册期货交易所, 和现货分开注册, 名称可以相同
+func RegisterFutureExchange(name string, factory FutureExchangeFactory) {
+	exchangesLock.Lock()
+	defer exchangesLock.Unlock()
+	if factory == nil {
+		panic("goex: register future exchange factory is nil, " + name)
+	}
+	if _, dup := futureExchanges[name]; dup {
+		panic("goex: register future exchange twice, " + name)
+	}
+	futureExchanges[name] = factory
+}
+
+func NewFutureExchangeAPI(name string, config *APIConfig) (FutureRestAPI, error) {
+	exchangesLock.RLock()
+	factory, ok := futureExchanges[name]
+	exchangesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("goex: unknown future exchange %s (forgotten import?)", name)
+	}
+	c, err := newAPIConfig(name, config)
+	if err != nil {
+		return nil, err
+	}
+	return factory(c), nil
+}
+
+func ListFutureExchanges() []string {
+	exchangesLock.RLock()
+	defer exchangesLock.RUnlock()
+	names := make([]string, 0, len(futureExchanges))
+	for name := range futureExchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newAPIConfig(name string, config *APIConfig) (*APIConfig, error) {
+	c := APIConfig{}
+	if config != nil {
+		c = *config
+	}
+	//目前没有交易所实现子账户, 忽略的话请求会落到主账户上
+	if c.SubAccount != "" {
+		return nil, EX_ERR_NOT_SUPPORTED.OriginErr(name + " sub account not supported, use the sub account's own api key")
+	}
+	if c.HttpClient == nil {
+		c.HttpClient = http.DefaultClient
+	}
+	return &c, nil
+}
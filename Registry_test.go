@@ -0,0 +1,35 @@
+package goex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//测试结束后删除注册的交易所, 否则-count大于1时重复注册会panic
+func unregisterExchange(name string) {
+	exchangesLock.Lock()
+	defer exchangesLock.Unlock()
+	delete(exchanges, name)
+}
+
+func TestRegisterExchange(t *testing.T) {
+	defer unregisterExchange("test.registry")
+	RegisterExchange("test.registry", func(config *APIConfig) API {
+		return &placeOrderApi{called: config.AccessKey}
+	})
+	assert.Panics(t, func() {
+		RegisterExchange("test.registry", func(config *APIConfig) API { return nil })
+	})
+	assert.Contains(t, ListExchanges(), "test.registry")
+
+	api, err := NewExchangeAPI("test.registry", &APIConfig{Credentials: Credentials{AccessKey: "key"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "key", api.(*placeOrderApi).called)
+
+	_, err = NewExchangeAPI("test.unknown", nil)
+	assert.Error(t, err)
+
+	_, err = NewExchangeAPI("test.registry", &APIConfig{Credentials: Credentials{AccessKey: "key", SubAccount: "sub"}})
+	assert.True(t, IsNotSupported(err))
+}
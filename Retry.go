@@ -0,0 +1,103 @@
+package goex
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+)
+
+/**
+ * 重试策略, 重试间隔按Multiplier指数增长, 不超过MaxInterval, 并加上±Jitter比例的随机抖动
+ * MaxAttempts和MaxElapsedTime为0表示不限制, 两者都为0时只受ctx控制
+ */
+type RetryPolicy struct {
+	MaxAttempts     int           //最多调用次数(包含第一次)
+	InitialInterval time.Duration //第一次重试前的等待时间
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64       //0~1
+	MaxElapsedTime  time.Duration //从第一次调用开始的总耗时上限
+
+	//判断错误是否需要重试, 为nil时使用IsTransient
+	Retryable func(err error) bool
+}
+
+var DEFAULT_RETRY_POLICY = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     5 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2,
+	MaxElapsedTime:  30 * time.Second}
+
+/**
+ * 调用fn直到成功、遇到不需要重试的错误、重试次数或时间用完、或者ctx被取消
+ * 返回最后一次调用的错误, ctx被取消时返回ctx.Err()
+ * 结果通过闭包返回, 比如:
+ *   var ticker *Ticker
+ *   err := Retry(ctx, DEFAULT_RETRY_POLICY, func(ctx context.Context) (err error) {
+ *   	ticker, err = api.GetTicker(BTC_USDT)
+ *   	return
+ *   })
+ */
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	start := time.Now()
+	interval := policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn(ctx)
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		wait := policy.jitter(interval)
+		if retryAfter := GetRetryAfter(err); retryAfter > wait {
+			wait = retryAfter
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(start)+wait > policy.MaxElapsedTime {
+			return err
+		}
+
+		log.Printf("retry [%d] after %s, error: %s", attempt, wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = policy.next(interval)
+	}
+}
+
+func (p RetryPolicy) next(interval time.Duration) time.Duration {
+	if p.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * p.Multiplier)
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+func (p RetryPolicy) jitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 || interval <= 0 {
+		return interval
+	}
+	delta := p.Jitter * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
@@ -0,0 +1,70 @@
+package goex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, Multiplier: 2, Jitter: 0.5}
+
+	calls := 0
+	err := Retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return EX_ERR_API_LIMIT
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = Retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return HTTP_ERR_CODE
+	})
+	assert.Equal(t, HTTP_ERR_CODE, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = Retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return EX_ERR_INSUFFICIENT_BALANCE
+	})
+	assert.Equal(t, EX_ERR_INSUFFICIENT_BALANCE, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetry_Limits(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: 20 * time.Millisecond, MaxElapsedTime: 50 * time.Millisecond}
+	calls := 0
+	err := Retry(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return HTTP_ERR_CODE
+	})
+	assert.Equal(t, HTTP_ERR_CODE, err)
+	assert.True(t, calls >= 2 && calls <= 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Retry(ctx, RetryPolicy{InitialInterval: time.Second}, func(ctx context.Context) error {
+		return HTTP_ERR_CODE
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRetryPolicy_next(t *testing.T) {
+	p := RetryPolicy{Multiplier: 2, MaxInterval: 300 * time.Millisecond}
+	assert.Equal(t, 200*time.Millisecond, p.next(100*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, p.next(200*time.Millisecond))
+
+	p.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		d := p.jitter(100 * time.Millisecond)
+		assert.True(t, d >= 80*time.Millisecond && d <= 120*time.Millisecond)
+	}
+}
@@ -3,6 +3,8 @@ package goex
 import (
 	"strconv"
 	"encoding/json"
+	"math"
+	"strings"
 )
 
 func ToFloat64(v interface{}) float64 {
@@ -67,3 +69,26 @@ func ToUint64(v interface{}) uint64 {
 		panic("to uint64 error.")
 	}
 }
+
+//秒或毫秒的时间戳统一转换为秒, 用于文档没有说明时间单位的交易所
+func ToUnixSecond(ts uint64) uint64 {
+	if ts > 1e12 {
+		return ts / 1000
+	}
+	return ts
+}
+
+//小数位转换为最小变动单位, 2 --> 0.01
+func PrecisionToStep(precision int) float64 {
+	return math.Pow10(-precision)
+}
+
+//最小变动单位转换为小数位, 0.01 --> 2
+func StepToPrecision(step float64) int {
+	str := strconv.FormatFloat(step, 'f', -1, 64)
+	idx := strings.Index(str, ".")
+	if idx < 0 {
+		return 0
+	}
+	return len(str) - idx - 1
+}
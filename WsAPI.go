@@ -0,0 +1,45 @@
+package goex
+
+//现货websocket行情, handle在接收消息的goroutine中调用
+//同一个交易对的同一种行情重复订阅时, 新的handle替换旧的; 交易所不支持的行情返回EX_ERR_NOT_SUPPORTED
+//实现了WsHandleAPI的交易所重复订阅时新增handle, 所有handle都会收到消息
+type SpotWsAPI interface {
+	GetExchangeName() string
+	GetTickerWithWs(pair CurrencyPair, handle func(*Ticker)) error
+	GetDepthWithWs(pair CurrencyPair, handle func(*Depth)) error
+	GetTradeWithWs(pair CurrencyPair, handle func(*Trade)) error
+	GetKLineWithWs(pair CurrencyPair, period int, handle func(*Kline)) error //period是KLINE_PERIOD_XXX
+
+	//取消订阅, 重连后也不会再订阅
+	UnsubscribeTicker(pair CurrencyPair) error
+	UnsubscribeDepth(pair CurrencyPair) error
+	UnsubscribeTrade(pair CurrencyPair) error
+	UnsubscribeKLine(pair CurrencyPair, period int) error
+}
+
+/**
+ * 一个行情可以有多个handle的websocket, 第一个handle添加时向交易所订阅, 最后一个handle删除时取消订阅
+ * event是UNSUB_TICKER, UNSUB_ORDERBOOK, UNSUB_TRADE, UNSUB_KLINE, period只在UNSUB_KLINE时使用
+ * handle是对应的func(*Ticker), func(*Depth), func(*Trade), func(*Kline)
+ * SpotWsAPI的UnsubscribeXXX删除这个行情的所有handle
+ */
+type WsHandleAPI interface {
+	AddWsHandle(event int, pair CurrencyPair, period int, handle interface{}) (int64, error) //返回handle的id
+	RemoveWsHandle(id int64) error                                                           //只删除这个handle, id不存在时不做任何事
+}
+
+//期货websocket行情
+type FutureWsAPI interface {
+	GetExchangeName() string
+	GetTickerWithWs(pair CurrencyPair, contractType string, handle func(*Ticker)) error
+	GetDepthWithWs(pair CurrencyPair, contractType string, handle func(*Depth)) error
+}
+
+/**
+ * 期货行情的多个handle, 同WsHandleAPI, event只支持UNSUB_TICKER和UNSUB_ORDERBOOK
+ * contractType是THIS_WEEK_CONTRACT, QUARTER_CONTRACT等
+ */
+type FutureWsHandleAPI interface {
+	AddWsHandle(event int, pair CurrencyPair, contractType string, handle interface{}) (int64, error)
+	RemoveWsHandle(id int64) error
+}
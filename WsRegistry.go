@@ -0,0 +1,159 @@
+package goex
+
+import "sync"
+
+/**
+ * websocket频道的handle表, 并发安全, 一个频道可以有多个handle
+ * 交易所的实现在订阅时调用Subscribe, 在接收消息的goroutine中调用Dispatch
+ * handle支持func(*Ticker), func(*Depth), func(*Trade), func(*Kline)和func(interface{})
+ */
+type WsRegistry struct {
+	lock     sync.RWMutex
+	subLock  sync.Mutex //保证向交易所订阅和取消订阅的顺序和handle表一致
+	channels map[string]*wsChannel
+	ids      map[int64]string //handle id所在的频道
+	nextId   int64
+}
+
+type wsChannel struct {
+	handlers    []wsHandler
+	unsubscribe func() error
+}
+
+type wsHandler struct {
+	id     int64
+	handle interface{}
+}
+
+func NewWsRegistry() *WsRegistry {
+	return &WsRegistry{channels: make(map[string]*wsChannel), ids: make(map[int64]string)}
+}
+
+/**
+ * 添加handle, 返回handle的id, 用于Remove
+ * 是频道的第一个handle时调用subscribe向交易所订阅, 订阅失败时删除handle
+ * unsubscribe在频道的最后一个handle删除时调用
+ */
+func (r *WsRegistry) Subscribe(channel string, handle interface{}, subscribe, unsubscribe func() error) (int64, error) {
+	r.subLock.Lock()
+	defer r.subLock.Unlock()
+
+	r.lock.Lock()
+	r.nextId++
+	id := r.nextId
+	ch, ok := r.channels[channel]
+	if !ok {
+		ch = &wsChannel{unsubscribe: unsubscribe}
+		r.channels[channel] = ch
+	}
+	//Dispatch可能正在读旧的slice, 不在原slice上修改
+	ch.handlers = append(ch.handlers[:len(ch.handlers):len(ch.handlers)], wsHandler{id, handle})
+	r.ids[id] = channel
+	r.lock.Unlock()
+
+	if !ok {
+		err := subscribe()
+		if err != nil {
+			r.remove(id)
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+//删除一个handle, 频道没有handle时取消交易所的订阅; id不存在时不做任何事
+func (r *WsRegistry) Remove(id int64) error {
+	r.subLock.Lock()
+	defer r.subLock.Unlock()
+
+	if ch := r.remove(id); ch != nil {
+		return ch.unsubscribe()
+	}
+	return nil
+}
+
+//删除频道的所有handle并取消交易所的订阅, 频道没有订阅时不做任何事
+func (r *WsRegistry) Unsubscribe(channel string) error {
+	r.subLock.Lock()
+	defer r.subLock.Unlock()
+
+	r.lock.Lock()
+	ch, ok := r.channels[channel]
+	if ok {
+		for _, h := range ch.handlers {
+			delete(r.ids, h.id)
+		}
+		delete(r.channels, channel)
+	}
+	r.lock.Unlock()
+
+	if ok {
+		return ch.unsubscribe()
+	}
+	return nil
+}
+
+func (r *WsRegistry) Has(channel string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	_, ok := r.channels[channel]
+	return ok
+}
+
+//按订阅顺序调用频道的所有handle, handle的参数类型和v不一致时跳过
+func (r *WsRegistry) Dispatch(channel string, v interface{}) {
+	var handlers []wsHandler
+	r.lock.RLock()
+	if ch, ok := r.channels[channel]; ok {
+		handlers = ch.handlers
+	}
+	r.lock.RUnlock()
+
+	for _, h := range handlers {
+		switch handle := h.handle.(type) {
+		case func(*Ticker):
+			if ticker, ok := v.(*Ticker); ok {
+				handle(ticker)
+			}
+		case func(*Depth):
+			if depth, ok := v.(*Depth); ok {
+				handle(depth)
+			}
+		case func(*Trade):
+			if trade, ok := v.(*Trade); ok {
+				handle(trade)
+			}
+		case func(*Kline):
+			if kline, ok := v.(*Kline); ok {
+				handle(kline)
+			}
+		case func(interface{}):
+			handle(v)
+		}
+	}
+}
+
+//删除handle, 频道已经没有handle时返回被删除的频道
+func (r *WsRegistry) remove(id int64) *wsChannel {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	channel, ok := r.ids[id]
+	if !ok {
+		return nil
+	}
+	delete(r.ids, id)
+	ch := r.channels[channel]
+	remain := make([]wsHandler, 0, len(ch.handlers))
+	for _, h := range ch.handlers {
+		if h.id != id {
+			remain = append(remain, h)
+		}
+	}
+	if len(remain) > 0 {
+		ch.handlers = remain
+		return nil
+	}
+	delete(r.channels, channel)
+	return ch
+}
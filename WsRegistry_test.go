@@ -0,0 +1,79 @@
+package goex
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestWsRegistry_Subscribe(t *testing.T) {
+	r := NewWsRegistry()
+	subscribed, unsubscribed := 0, 0
+	subscribe := func() error { subscribed++; return nil }
+	unsubscribe := func() error { unsubscribed++; return nil }
+
+	var lasts []float64
+	id1, err := r.Subscribe("ticker", func(ticker *Ticker) { lasts = append(lasts, ticker.Last) }, subscribe, unsubscribe)
+	assert.Nil(t, err)
+	id2, err := r.Subscribe("ticker", func(ticker *Ticker) { lasts = append(lasts, ticker.Last*10) }, subscribe, unsubscribe)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, subscribed)
+
+	r.Dispatch("ticker", &Ticker{Last: 1})
+	r.Dispatch("ticker", &Depth{}) //类型不一致的handle跳过
+	r.Dispatch("depth", &Ticker{Last: 2})
+	assert.Equal(t, []float64{1, 10}, lasts)
+
+	assert.Nil(t, r.Remove(id1))
+	assert.Equal(t, 0, unsubscribed)
+	r.Dispatch("ticker", &Ticker{Last: 3})
+	assert.Equal(t, []float64{1, 10, 30}, lasts)
+
+	assert.Nil(t, r.Remove(id2))
+	assert.Nil(t, r.Remove(id2))
+	assert.Equal(t, 1, unsubscribed)
+	assert.False(t, r.Has("ticker"))
+
+	//订阅失败时不保留handle
+	_, err = r.Subscribe("depth", func(depth *Depth) {}, func() error { return errors.New("closed") }, unsubscribe)
+	assert.EqualError(t, err, "closed")
+	assert.False(t, r.Has("depth"))
+
+	r.Subscribe("trade", func(v interface{}) {}, subscribe, unsubscribe)
+	id, _ := r.Subscribe("trade", func(trade *Trade) {}, subscribe, unsubscribe)
+	assert.Nil(t, r.Unsubscribe("trade"))
+	assert.Nil(t, r.Unsubscribe("trade"))
+	assert.Equal(t, 2, unsubscribed)
+	assert.Nil(t, r.Remove(id)) //Unsubscribe后id已经失效
+	assert.Equal(t, 2, unsubscribed)
+}
+
+func TestWsRegistry_Concurrent(t *testing.T) {
+	r := NewWsRegistry()
+	subscribed := 0
+	subscribe := func() error { subscribed++; return nil }
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			r.Dispatch("kline", &Kline{})
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := r.Subscribe("kline", func(kline *Kline) {}, subscribe, func() error { return nil })
+			assert.Nil(t, err)
+			r.Remove(id)
+		}()
+	}
+	wg.Wait()
+	<-done
+	assert.False(t, r.Has("kline"))
+	assert.True(t, subscribed >= 1)
+}
@@ -0,0 +1,48 @@
+package goex
+
+import "time"
+
+//websocket连接的状态
+type WsState int
+
+const (
+	WS_CONNECTING   WsState = 1 + iota //正在建立连接, 包括重连
+	WS_CONNECTED                       //连接成功, 重连时还没有重新订阅
+	WS_RESUBSCRIBED                    //重连后已经重新订阅, 行情恢复
+	WS_DISCONNECTED                    //连接断开或者重连失败, 之后会按重连策略继续重连
+	WS_CLOSED                          //调用了CloseWs或者重连次数用完, 不会再重连
+)
+
+func (s WsState) String() string {
+	switch s {
+	case WS_CONNECTING:
+		return "connecting"
+	case WS_CONNECTED:
+		return "connected"
+	case WS_RESUBSCRIBED:
+		return "resubscribed"
+	case WS_DISCONNECTED:
+		return "disconnected"
+	case WS_CLOSED:
+		return "closed"
+	}
+	return "unknown"
+}
+
+type WsStateEvent struct {
+	Url     string
+	State   WsState
+	Attempt int   //第几次重连, 第一次连接为0
+	Err     error //断开或者重连失败的原因
+	Time    time.Time
+}
+
+/**
+ * websocket断开后的重连策略, 只使用RetryPolicy的InitialInterval, MaxInterval, Multiplier, Jitter和MaxAttempts
+ * MaxAttempts为0时一直重连, 直到CloseWs
+ */
+var DEFAULT_WS_RECONNECT_POLICY = RetryPolicy{
+	InitialInterval: time.Second,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2}
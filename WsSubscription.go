@@ -0,0 +1,267 @@
+package goex
+
+import (
+	"fmt"
+	"sync"
+)
+
+//channel缓冲满时的处理方式
+type WsOverflowPolicy int
+
+const (
+	WS_OVERFLOW_BLOCK       WsOverflowPolicy = iota //等待消费, 会阻塞接收消息的goroutine
+	WS_OVERFLOW_DROP_OLDEST                         //丢弃最早的消息
+	WS_OVERFLOW_CONFLATE                            //只保留最新的一条, 适合ticker和深度
+)
+
+//channel方式订阅的选项
+type WsChanOptions struct {
+	Buffer   int //缓冲的消息数, 小于1时为1, WS_OVERFLOW_CONFLATE时忽略
+	Overflow WsOverflowPolicy
+}
+
+/**
+ * SubscribeXXX返回的订阅句柄, channel方式订阅时Unsubscribe同时关闭channel
+ * 交易所实现了WsHandleAPI时同一个行情可以有多个订阅, Unsubscribe只删除自己的handle
+ * 没有实现WsHandleAPI时同一个行情只能有一个订阅, Unsubscribe取消交易所的订阅
+ */
+type WsSubscription struct {
+	Pair   CurrencyPair
+	Event  int //取消订阅的事件, UNSUB_TICKER, UNSUB_ORDERBOOK, UNSUB_TRADE, UNSUB_KLINE
+	Period int //K线周期, Event为UNSUB_KLINE时使用
+
+	api   SpotWsAPI
+	id    int64 //WsHandleAPI返回的handle id
+	queue *wsQueue
+	once  sync.Once
+	err   error
+}
+
+//可以重复调用, 只有第一次有效
+func (sub *WsSubscription) Unsubscribe() error {
+	sub.once.Do(func() {
+		if sub.queue != nil {
+			sub.queue.close()
+		}
+		sub.err = sub.unsubscribe()
+	})
+	return sub.err
+}
+
+//交易所实现了WsHandleAPI时添加handle, 否则调用subscribe
+func (sub *WsSubscription) subscribe(handle interface{}, subscribe func() error) (*WsSubscription, error) {
+	var err error
+	if handleApi, ok := sub.api.(WsHandleAPI); ok {
+		sub.id, err = handleApi.AddWsHandle(sub.Event, sub.Pair, sub.Period, handle)
+	} else {
+		err = subscribe()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (sub *WsSubscription) unsubscribe() error {
+	if handleApi, ok := sub.api.(WsHandleAPI); ok {
+		return handleApi.RemoveWsHandle(sub.id)
+	}
+
+	switch sub.Event {
+	case UNSUB_TICKER:
+		return sub.api.UnsubscribeTicker(sub.Pair)
+	case UNSUB_ORDERBOOK:
+		return sub.api.UnsubscribeDepth(sub.Pair)
+	case UNSUB_TRADE:
+		return sub.api.UnsubscribeTrade(sub.Pair)
+	case UNSUB_KLINE:
+		return sub.api.UnsubscribeKLine(sub.Pair, sub.Period)
+	}
+	return EX_ERR_NOT_SUPPORTED.OriginErr(fmt.Sprintf("unsubscribe event %d", sub.Event))
+}
+
+//handle在接收消息的goroutine中调用, 同GetTickerWithWs
+func SubscribeTicker(api SpotWsAPI, pair CurrencyPair, handle func(*Ticker)) (*WsSubscription, error) {
+	sub := &WsSubscription{Pair: pair, Event: UNSUB_TICKER, api: api}
+	return sub.subscribe(handle, func() error {
+		return api.GetTickerWithWs(pair, handle)
+	})
+}
+
+func SubscribeDepth(api SpotWsAPI, pair CurrencyPair, handle func(*Depth)) (*WsSubscription, error) {
+	sub := &WsSubscription{Pair: pair, Event: UNSUB_ORDERBOOK, api: api}
+	return sub.subscribe(handle, func() error {
+		return api.GetDepthWithWs(pair, handle)
+	})
+}
+
+func SubscribeTrade(api SpotWsAPI, pair CurrencyPair, handle func(*Trade)) (*WsSubscription, error) {
+	sub := &WsSubscription{Pair: pair, Event: UNSUB_TRADE, api: api}
+	return sub.subscribe(handle, func() error {
+		return api.GetTradeWithWs(pair, handle)
+	})
+}
+
+func SubscribeKLine(api SpotWsAPI, pair CurrencyPair, period int, handle func(*Kline)) (*WsSubscription, error) {
+	sub := &WsSubscription{Pair: pair, Event: UNSUB_KLINE, Period: period, api: api}
+	return sub.subscribe(handle, func() error {
+		return api.GetKLineWithWs(pair, period, handle)
+	})
+}
+
+/**
+ * channel方式订阅, 消息按opts缓存, 在单独的goroutine中发送到channel
+ * 消费慢时按opts.Overflow处理, 除了WS_OVERFLOW_BLOCK都不会阻塞接收消息的goroutine
+ */
+func SubscribeTickerChan(api SpotWsAPI, pair CurrencyPair, opts WsChanOptions) (<-chan *Ticker, *WsSubscription, error) {
+	ch := make(chan *Ticker)
+	queue := newWsQueue(opts, func(v interface{}, done <-chan struct{}) {
+		select {
+		case ch <- v.(*Ticker):
+		case <-done:
+		}
+	}, func() { close(ch) })
+	sub, err := SubscribeTicker(api, pair, func(ticker *Ticker) { queue.push(ticker) })
+	if err != nil {
+		queue.close()
+		return nil, nil, err
+	}
+	sub.queue = queue
+	return ch, sub, nil
+}
+
+func SubscribeDepthChan(api SpotWsAPI, pair CurrencyPair, opts WsChanOptions) (<-chan *Depth, *WsSubscription, error) {
+	ch := make(chan *Depth)
+	queue := newWsQueue(opts, func(v interface{}, done <-chan struct{}) {
+		select {
+		case ch <- v.(*Depth):
+		case <-done:
+		}
+	}, func() { close(ch) })
+	sub, err := SubscribeDepth(api, pair, func(depth *Depth) { queue.push(depth) })
+	if err != nil {
+		queue.close()
+		return nil, nil, err
+	}
+	sub.queue = queue
+	return ch, sub, nil
+}
+
+func SubscribeTradeChan(api SpotWsAPI, pair CurrencyPair, opts WsChanOptions) (<-chan *Trade, *WsSubscription, error) {
+	ch := make(chan *Trade)
+	queue := newWsQueue(opts, func(v interface{}, done <-chan struct{}) {
+		select {
+		case ch <- v.(*Trade):
+		case <-done:
+		}
+	}, func() { close(ch) })
+	sub, err := SubscribeTrade(api, pair, func(trade *Trade) { queue.push(trade) })
+	if err != nil {
+		queue.close()
+		return nil, nil, err
+	}
+	sub.queue = queue
+	return ch, sub, nil
+}
+
+func SubscribeKLineChan(api SpotWsAPI, pair CurrencyPair, period int, opts WsChanOptions) (<-chan *Kline, *WsSubscription, error) {
+	ch := make(chan *Kline)
+	queue := newWsQueue(opts, func(v interface{}, done <-chan struct{}) {
+		select {
+		case ch <- v.(*Kline):
+		case <-done:
+		}
+	}, func() { close(ch) })
+	sub, err := SubscribeKLine(api, pair, period, func(kline *Kline) { queue.push(kline) })
+	if err != nil {
+		queue.close()
+		return nil, nil, err
+	}
+	sub.queue = queue
+	return ch, sub, nil
+}
+
+//按溢出策略缓存消息的队列, 在单独的goroutine中调用deliver, 关闭后调用onClose
+type wsQueue struct {
+	lock     sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    []interface{}
+	size     int
+	overflow WsOverflowPolicy
+	done     chan struct{}
+}
+
+func newWsQueue(opts WsChanOptions, deliver func(v interface{}, done <-chan struct{}), onClose func()) *wsQueue {
+	q := &wsQueue{size: opts.Buffer, overflow: opts.Overflow, done: make(chan struct{})}
+	if q.size < 1 || q.overflow == WS_OVERFLOW_CONFLATE {
+		q.size = 1
+	}
+	q.notEmpty = sync.NewCond(&q.lock)
+	q.notFull = sync.NewCond(&q.lock)
+
+	go func() {
+		defer onClose()
+		for {
+			v, ok := q.pop()
+			if !ok {
+				return
+			}
+			deliver(v, q.done)
+		}
+	}()
+	return q
+}
+
+func (q *wsQueue) push(v interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for !q.isClosed() && len(q.items) >= q.size {
+		if q.overflow == WS_OVERFLOW_BLOCK {
+			q.notFull.Wait()
+		} else {
+			q.items = q.items[1:]
+		}
+	}
+	if q.isClosed() {
+		return
+	}
+	q.items = append(q.items, v)
+	q.notEmpty.Signal()
+}
+
+func (q *wsQueue) pop() (interface{}, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for !q.isClosed() && len(q.items) == 0 {
+		q.notEmpty.Wait()
+	}
+	if q.isClosed() {
+		return nil, false
+	}
+	v := q.items[0]
+	q.items = q.items[1:]
+	q.notFull.Signal()
+	return v, true
+}
+
+func (q *wsQueue) isClosed() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *wsQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.isClosed() {
+		close(q.done)
+		q.notEmpty.Broadcast()
+		q.notFull.Broadcast()
+	}
+}
@@ -0,0 +1,163 @@
+package goex
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+//记录订阅和取消订阅的SpotWsAPI
+type fakeSpotWs struct {
+	lock   sync.Mutex
+	ticker func(*Ticker)
+	kline  func(*Kline)
+	unsubs []string
+}
+
+func (ws *fakeSpotWs) GetExchangeName() string { return "fake" }
+
+func (ws *fakeSpotWs) GetTickerWithWs(pair CurrencyPair, handle func(*Ticker)) error {
+	ws.ticker = handle
+	return nil
+}
+
+func (ws *fakeSpotWs) GetDepthWithWs(pair CurrencyPair, handle func(*Depth)) error {
+	return EX_ERR_NOT_SUPPORTED
+}
+
+func (ws *fakeSpotWs) GetTradeWithWs(pair CurrencyPair, handle func(*Trade)) error {
+	return EX_ERR_NOT_SUPPORTED
+}
+
+func (ws *fakeSpotWs) GetKLineWithWs(pair CurrencyPair, period int, handle func(*Kline)) error {
+	ws.kline = handle
+	return nil
+}
+
+func (ws *fakeSpotWs) UnsubscribeTicker(pair CurrencyPair) error { return ws.unsub("ticker " + pair.String()) }
+func (ws *fakeSpotWs) UnsubscribeDepth(pair CurrencyPair) error  { return ws.unsub("depth " + pair.String()) }
+func (ws *fakeSpotWs) UnsubscribeTrade(pair CurrencyPair) error  { return ws.unsub("trade " + pair.String()) }
+
+func (ws *fakeSpotWs) UnsubscribeKLine(pair CurrencyPair, period int) error {
+	return ws.unsub("kline " + pair.String())
+}
+
+func (ws *fakeSpotWs) unsub(s string) error {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	ws.unsubs = append(ws.unsubs, s)
+	return nil
+}
+
+//用WsRegistry实现WsHandleAPI
+type fakeHandleWs struct {
+	fakeSpotWs
+	handlers *WsRegistry
+}
+
+func (ws *fakeHandleWs) AddWsHandle(event int, pair CurrencyPair, period int, handle interface{}) (int64, error) {
+	channel := fmt.Sprintf("%d %s", event, pair)
+	return ws.handlers.Subscribe(channel, handle, func() error { return nil }, func() error { return ws.unsub(channel) })
+}
+
+func (ws *fakeHandleWs) RemoveWsHandle(id int64) error {
+	return ws.handlers.Remove(id)
+}
+
+//读取channel直到收到last, 返回收到的所有Last
+func receiveTickers(t *testing.T, ch <-chan *Ticker, last float64) []float64 {
+	var received []float64
+	for {
+		select {
+		case ticker := <-ch:
+			received = append(received, ticker.Last)
+			if ticker.Last == last {
+				return received
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("ticker %f not received, got %v", last, received)
+			return received
+		}
+	}
+}
+
+func TestSubscribeTickerChan(t *testing.T) {
+	api := &fakeSpotWs{}
+
+	ch, sub, err := SubscribeTickerChan(api, BTC_USDT, WsChanOptions{Buffer: 2, Overflow: WS_OVERFLOW_DROP_OLDEST})
+	assert.Nil(t, err)
+	for i := 1; i <= 10; i++ {
+		api.ticker(&Ticker{Last: float64(i)})
+	}
+	received := receiveTickers(t, ch, 10)
+	assert.True(t, len(received) <= 3, "received %v", received) //缓冲的2条加上正在发送的1条
+	assert.Equal(t, 10.0, received[len(received)-1])
+
+	assert.Nil(t, sub.Unsubscribe())
+	assert.Nil(t, sub.Unsubscribe())
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, []string{"ticker BTC_USDT"}, api.unsubs)
+	api.ticker(&Ticker{Last: 11}) //取消后收到的消息被丢弃
+
+	ch, sub, _ = SubscribeTickerChan(api, BTC_USDT, WsChanOptions{Overflow: WS_OVERFLOW_CONFLATE})
+	for i := 1; i <= 10; i++ {
+		api.ticker(&Ticker{Last: float64(i)})
+	}
+	received = receiveTickers(t, ch, 10)
+	assert.True(t, len(received) <= 2, "received %v", received)
+	sub.Unsubscribe()
+
+	//WS_OVERFLOW_BLOCK不丢消息
+	ch, sub, _ = SubscribeTickerChan(api, BTC_USDT, WsChanOptions{Buffer: 1, Overflow: WS_OVERFLOW_BLOCK})
+	go func() {
+		for i := 1; i <= 10; i++ {
+			api.ticker(&Ticker{Last: float64(i)})
+		}
+	}()
+	assert.Equal(t, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, receiveTickers(t, ch, 10))
+	sub.Unsubscribe()
+
+	_, _, err = SubscribeDepthChan(api, BTC_USDT, WsChanOptions{})
+	assert.True(t, IsNotSupported(err))
+}
+
+func TestSubscribeKLine(t *testing.T) {
+	api := &fakeSpotWs{}
+	var klines []*Kline
+	sub, err := SubscribeKLine(api, BTC_USDT, KLINE_PERIOD_1MIN, func(kline *Kline) {
+		klines = append(klines, kline)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, UNSUB_KLINE, sub.Event)
+	assert.Equal(t, KLINE_PERIOD_1MIN, sub.Period)
+
+	api.kline(&Kline{Close: 1})
+	assert.Len(t, klines, 1)
+	assert.Nil(t, sub.Unsubscribe())
+	assert.Equal(t, []string{"kline BTC_USDT"}, api.unsubs)
+}
+
+func TestSubscribeTicker_WsHandleAPI(t *testing.T) {
+	api := &fakeHandleWs{handlers: NewWsRegistry()}
+	channel := fmt.Sprintf("%d %s", UNSUB_TICKER, BTC_USDT)
+
+	var lasts1, lasts2 []float64
+	sub1, err := SubscribeTicker(api, BTC_USDT, func(ticker *Ticker) { lasts1 = append(lasts1, ticker.Last) })
+	assert.Nil(t, err)
+	sub2, err := SubscribeTicker(api, BTC_USDT, func(ticker *Ticker) { lasts2 = append(lasts2, ticker.Last) })
+	assert.Nil(t, err)
+
+	api.handlers.Dispatch(channel, &Ticker{Last: 1})
+	assert.Nil(t, sub1.Unsubscribe())
+	assert.Empty(t, api.unsubs) //还有其他订阅, 不取消交易所的订阅
+	api.handlers.Dispatch(channel, &Ticker{Last: 2})
+	assert.Equal(t, []float64{1}, lasts1)
+	assert.Equal(t, []float64{1, 2}, lasts2)
+
+	assert.Nil(t, sub2.Unsubscribe())
+	assert.Equal(t, []string{channel}, api.unsubs)
+	assert.False(t, api.handlers.Has(channel))
+}
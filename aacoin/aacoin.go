@@ -7,6 +7,7 @@ import (
 
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"sort"
 	"strconv"
@@ -22,16 +23,33 @@ type Aacoin struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	host       string
+}
+
+func init() {
+	RegisterExchange("aacoin.com", func(config *APIConfig) API {
+		aa := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		aa.host = OverrideUrl(aa.host, config.ApiUrl)
+		return aa
+	})
 }
 
 func New(client *http.Client, api_key, secret_key string) *Aacoin {
-	return &Aacoin{accessKey: api_key, secretKey: secret_key, httpClient: client}
+	return &Aacoin{accessKey: api_key, secretKey: secret_key, httpClient: client, host: host}
 }
 
 func (aa *Aacoin) GetExchangeName() string {
 	return "aacoin.com"
 }
 
+func (aa *Aacoin) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (aa *Aacoin) buildSigned(params *url.Values) string {
 
 	//log.Println("params", params.Encode())
@@ -43,7 +61,7 @@ func (aa *Aacoin) buildSigned(params *url.Values) string {
 }
 
 func (aa *Aacoin) GetAccount() (*Account, error) {
-	api_url := host + "/account/accounts"
+	api_url := aa.host + "/account/accounts"
 
 	params := url.Values{}
 	//params.Set("accessKey", aa.accessKey)
@@ -63,7 +81,7 @@ func (aa *Aacoin) GetAccount() (*Account, error) {
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, aa.adaptError(bodyDataMap)
 	}
 
 	balances, isok := bodyDataMap["data"].([]interface{})
@@ -100,7 +118,7 @@ func (aa *Aacoin) GetAccount() (*Account, error) {
 }
 
 func (aa *Aacoin) placeOrder(amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
-	path := host + "/order/place"
+	path := aa.host + "/order/place"
 	params := url.Values{}
 	params.Set("symbol", pair.String())
 	params.Set("type", orderSide+"-"+orderType)
@@ -122,7 +140,7 @@ func (aa *Aacoin) placeOrder(amount, price string, pair CurrencyPair, orderType,
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, aa.adaptError(bodyDataMap)
 	}
 
 	side := BUY
@@ -159,7 +177,7 @@ func (aa *Aacoin) MarketSell(amount, price string, currencyPair CurrencyPair) (*
 }
 
 func (aa *Aacoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
-	path := host + "/order/cancel"
+	path := aa.host + "/order/cancel"
 	params := url.Values{}
 	params.Set("orderId", orderId)
 	aa.buildSigned(&params)
@@ -179,14 +197,14 @@ func (aa *Aacoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, aa.adaptError(bodyDataMap)
 	}
 
 	return true, nil
 }
 
 func (aa *Aacoin) CancelOrders(orderId []string) (bool, error) {
-	path := host + "/order/batchCancel"
+	path := aa.host + "/order/batchCancel"
 	params := url.Values{}
 
 	orders := strings.Join(orderId, ",")
@@ -209,7 +227,7 @@ func (aa *Aacoin) CancelOrders(orderId []string) (bool, error) {
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, aa.adaptError(bodyDataMap)
 	}
 
 	return true, nil
@@ -264,7 +282,7 @@ func (aa *Aacoin) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order
 	return nil, nil
 }
 func (aa *Aacoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
-	path := host + "/order/currentOrders"
+	path := aa.host + "/order/currentOrders"
 	params := url.Values{}
 
 	params.Set("symbol", currencyPair.String())
@@ -285,7 +303,7 @@ func (aa *Aacoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, aa.adaptError(bodyDataMap)
 	}
 	data := bodyDataMap["data"].(map[string]interface{})
 	list := data["list"].([]interface{})
@@ -310,10 +328,10 @@ func (aa *Aacoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	return nil, nil
 }
 func (aa *Aacoin) GetOrderHistorys(currencyPair CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (aa *Aacoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error) {
-	path := host + "/market/depth"
+	path := aa.host + "/market/depth"
 	params := url.Values{}
 
 	params.Set("symbol", currencyPair.String())
@@ -334,7 +352,7 @@ func (aa *Aacoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, aa.adaptError(bodyDataMap)
 	}
 
 	dep := bodyDataMap["data"].(map[string]interface{})
@@ -370,16 +388,18 @@ func (aa *Aacoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 		//	break
 		//}
 	}
+	//先按价格从低到高取最优的size档, 再倒序成AskList最后一个是卖一
 	sort.Sort(depth.AskList)
 	sort.Sort(sort.Reverse(depth.BidList))
 	depth.AskList = depth.AskList[0:size]
 	depth.BidList = depth.BidList[0:size]
+	sort.Sort(sort.Reverse(depth.AskList))
 	//log.Println(depth)
 	return depth, nil
 }
 
 func (aa *Aacoin) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
-	path := host + "/market/detail"
+	path := aa.host + "/market/detail"
 	params := url.Values{}
 
 	params.Set("symbol", currencyPair.String())
@@ -399,7 +419,7 @@ func (aa *Aacoin) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, aa.adaptError(bodyDataMap)
 	}
 	tickerMap := bodyDataMap["data"].(map[string]interface{})
 	var ticker Ticker
@@ -416,12 +436,12 @@ func (aa *Aacoin) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 }
 
 func (aa *Aacoin) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (aa *Aacoin) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func orderTypeAdapter(t string) TradeSide {
@@ -443,3 +463,21 @@ func orderStatusAdapter(s string) TradeStatus {
 	}
 	return ORDER_FINISH
 }
+
+//错误是 {"status":"1001","msg":"..."}, 错误码没有文档, 按msg判断
+func (aa *Aacoin) adaptError(bodyDataMap map[string]interface{}) ApiError {
+	msg := fmt.Sprint(bodyDataMap["msg"])
+	lower := strings.ToLower(msg)
+
+	apiErr := API_ERR
+	switch {
+	case strings.Contains(msg, "余额不足"), strings.Contains(lower, "insufficient"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(msg, "订单不存在"), strings.Contains(lower, "order not exist"), strings.Contains(lower, "order not found"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(msg, "签名"), strings.Contains(lower, "signature"):
+		apiErr = EX_ERR_SIGN
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(bodyDataMap["status"])
+	return apiErr.OriginErr(msg)
+}
@@ -27,18 +27,32 @@ type Acx struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	apiV1      string
+}
+
+func init() {
+	RegisterExchange(EXCHANGE_NAME, func(config *APIConfig) API {
+		acx := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		acx.apiV1 = OverrideUrl(acx.apiV1, config.ApiUrl)
+		return acx
+	})
 }
 
 func New(client *http.Client, api_key, secret_key string) *Acx {
-	return &Acx{api_key, secret_key, client}
+	return &Acx{api_key, secret_key, client, API_V1}
 }
 
 func (acx *Acx) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (acx *Acx) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
 func (acx *Acx) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	tickerUri := API_V1 + fmt.Sprintf(TICKER_URI, strings.ToLower(currency.ToSymbol("")))
+	tickerUri := acx.apiV1 + fmt.Sprintf(TICKER_URI, strings.ToLower(currency.ToSymbol("")))
 	bodyDataMap, err := HttpGet(acx.httpClient, tickerUri)
 
 	if err != nil {
@@ -68,49 +82,49 @@ func (acx *Acx) GetTickerInBuf(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (acx *Acx) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (acx *Acx) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (acx *Acx) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (acx *Acx) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
@@ -30,16 +30,30 @@ type Aex struct {
 	secretKey,
 	accountId string
 	httpClient *http.Client
+	apiV1      string
+}
+
+func init() {
+	RegisterExchange(EXCHANGE_NAME, func(config *APIConfig) API {
+		aex := New(config.HttpClient, config.AccessKey, config.SecretKey, config.AccountId)
+		aex.apiV1 = OverrideUrl(aex.apiV1, config.ApiUrl)
+		return aex
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey, accountId string) *Aex {
-	return &Aex{accessKey, secretKey, accountId, client}
+	return &Aex{accessKey, secretKey, accountId, client, API_V1}
 }
 
 func (aex *Aex) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (aex *Aex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
 func (aex *Aex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	cur := currency.CurrencyA.String()
 	money := currency.CurrencyB.String()
@@ -47,7 +61,7 @@ func (aex *Aex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 		//log.Println("Unsupport The CurrencyPair")
 		return nil, errors.New("Unsupport The CurrencyPair")
 	}
-	tickerUri := API_V1 + fmt.Sprintf(TICKER_URI, cur, money)
+	tickerUri := aex.apiV1 + fmt.Sprintf(TICKER_URI, cur, money)
 	timestamp := time.Now().Unix()
 
 	bodyDataMap, err := HttpGet(aex.httpClient, tickerUri)
@@ -88,49 +102,49 @@ func (aex *Aex) GetTickerInBuf(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (aex *Aex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (aex *Aex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (aex *Aex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (aex *Aex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
@@ -2,7 +2,7 @@ package allcoin
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"log"
 	"net/http"
@@ -30,6 +30,7 @@ type Allcoin struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	baseUrl    string
 }
 
 func (ac *Allcoin) buildParamsSigned(postForm *url.Values) error {
@@ -42,14 +43,30 @@ func (ac *Allcoin) buildParamsSigned(postForm *url.Values) error {
 	return nil
 }
 
+func init() {
+	RegisterExchange("allcoin.com", func(config *APIConfig) API {
+		ac := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		ac.baseUrl = OverrideUrl(ac.baseUrl, config.ApiUrl)
+		return ac
+	})
+}
+
 func New(client *http.Client, api_key, secret_key string) *Allcoin {
-	return &Allcoin{api_key, secret_key, client}
+	return &Allcoin{api_key, secret_key, client, API_BASE_URL}
 }
 
 func (ac *Allcoin) GetExchangeName() string {
 	return "allcoin.com"
 }
 
+func (ac *Allcoin) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (ac *Allcoin) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	//wg := sync.WaitGroup{}
 	//wg.Add(2)
@@ -87,7 +104,7 @@ func (ac *Allcoin) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	params := url.Values{}
 	params.Set("part", strings.ToLower(currency2.CurrencyB.String()))
 	params.Set("coin", strings.ToLower(currency2.CurrencyA.String()))
-	path := API_BASE_URL + TICKER_URI
+	path := ac.baseUrl + TICKER_URI
 	resp, err := HttpPostForm(ac.httpClient, path, params)
 	//log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
@@ -121,7 +138,7 @@ func (ac *Allcoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	currency2 := ac.adaptCurrencyPair(currencyPair)
 	params := url.Values{}
 	params.Set("symbol", strings.ToLower(currency2.ToSymbol("2")))
-	path := API_BASE_URL + DEPTH_URI
+	path := ac.baseUrl + DEPTH_URI
 	resp, err := HttpPostForm(ac.httpClient, path, params)
 	//log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
@@ -138,7 +155,7 @@ func (ac *Allcoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	msg := respmap["msg"].(string)
 	log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ac.adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 	log.Println("1", data)
@@ -165,13 +182,13 @@ func (ac *Allcoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 		dr := DepthRecord{Amount: amount, Price: price}
 		depth.AskList = append(depth.AskList, dr)
 	}
-	sort.Sort(depth.AskList)
+	sort.Sort(sort.Reverse(depth.AskList))
 	return depth, nil
 }
 
 func (ac *Allcoin) placeOrder(amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
 	pair = ac.adaptCurrencyPair(pair)
-	path := API_BASE_URL + ORDER_URI
+	path := ac.baseUrl + ORDER_URI
 	params := url.Values{}
 	params.Set("api_key", ac.accessKey)
 	params.Set("symbol", strings.ToLower(pair.ToSymbol("2")))
@@ -196,7 +213,7 @@ func (ac *Allcoin) placeOrder(amount, price string, pair CurrencyPair, orderType
 	}
 	code := respmap["code"].(float64)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ac.adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 
@@ -225,7 +242,7 @@ func (ac *Allcoin) GetAccount() (*Account, error) {
 	params.Set("api_key", ac.accessKey)
 	ac.buildParamsSigned(&params)
 	//log.Println("params=", params)
-	path := API_BASE_URL + ACCOUNT_URI
+	path := ac.baseUrl + ACCOUNT_URI
 	resp, err := HttpPostForm(ac.httpClient, path, params)
 	//log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
@@ -242,7 +259,7 @@ func (ac *Allcoin) GetAccount() (*Account, error) {
 	//msg := respmap["msg"].(string)
 	//log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ac.adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 
@@ -289,7 +306,7 @@ func (ac *Allcoin) MarketSell(amount, price string, currencyPair CurrencyPair) (
 
 func (ac *Allcoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
 	currencyPair = ac.adaptCurrencyPair(currencyPair)
-	path := API_BASE_URL + ORDER_CANCEL_URI
+	path := ac.baseUrl + ORDER_CANCEL_URI
 	params := url.Values{}
 	params.Set("api_key", ac.accessKey)
 	params.Set("symbol", strings.ToLower(currencyPair.ToSymbol("2")))
@@ -310,9 +327,9 @@ func (ac *Allcoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 		log.Println(string(resp))
 		return false, err
 	}
-	code := respmap["code"].(int)
+	code := ToInt(respmap["code"])
 	if code != 0 {
-		return false, errors.New(respmap["msg"].(string))
+		return false, ac.adaptError(respmap)
 	}
 
 	//orderIdCanceled := ToInt(respmap["orderId"])
@@ -325,7 +342,7 @@ func (ac *Allcoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 
 func (ac *Allcoin) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order, error) {
 	currencyPair = ac.adaptCurrencyPair(currencyPair)
-	path := API_BASE_URL + ORDER_INFO_URI
+	path := ac.baseUrl + ORDER_INFO_URI
 	params := url.Values{}
 	params.Set("api_key", ac.accessKey)
 	params.Set("symbol", strings.ToLower(currencyPair.ToSymbol("2")))
@@ -348,7 +365,7 @@ func (ac *Allcoin) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	}
 	code := respmap["code"].(float64)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ac.adaptError(respmap)
 	}
 
 	data := respmap["data"].(map[string]interface{})
@@ -390,7 +407,7 @@ func (ac *Allcoin) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 
 func (ac *Allcoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
 	currencyPair = ac.adaptCurrencyPair(currencyPair)
-	path := API_BASE_URL + UNFINISHED_ORDERS_INFO
+	path := ac.baseUrl + UNFINISHED_ORDERS_INFO
 	params := url.Values{}
 	params.Set("api_key", ac.accessKey)
 	params.Set("symbol", strings.ToLower(currencyPair.ToSymbol("2")))
@@ -415,7 +432,7 @@ func (ac *Allcoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	//msg := respmap["msg"].(string)
 	//log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ac.adaptError(respmap)
 	}
 	data, isok := respmap["data"].([]map[string]interface{})
 
@@ -446,17 +463,35 @@ func (ac *Allcoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 }
 
 func (ac *Allcoin) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (ac *Allcoin) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ac *Allcoin) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (ba *Allcoin) adaptCurrencyPair(pair CurrencyPair) CurrencyPair {
 	return pair
 }
+
+//错误是 {"code":10005,"msg":"..."}, 没有公开的错误码列表, 只能按msg分类
+func (ac *Allcoin) adaptError(respmap map[string]interface{}) ApiError {
+	msg := fmt.Sprint(respmap["msg"])
+	lower := strings.ToLower(msg)
+
+	apiErr := API_ERR
+	switch {
+	case strings.Contains(lower, "insufficient"), strings.Contains(msg, "余额不足"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(lower, "order not exist"), strings.Contains(msg, "订单不存在"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(lower, "signature"), strings.Contains(msg, "签名"):
+		apiErr = EX_ERR_SIGN
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(respmap["code"])
+	return apiErr.OriginErr(msg)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"log"
+	"sort"
 	"time"
 
 	"github.com/nntaoli-project/GoEx"
@@ -28,16 +29,40 @@ type Bigone struct {
 	secretKey string
 	httpClient *http.Client
 	uid        string
+	tickerUri,
+	depthUri,
+	accountUri,
+	ordersUri string
+}
+
+func init() {
+	goex.RegisterExchange(goex.BIGONE, func(config *goex.APIConfig) goex.API {
+		bo := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		bo.tickerUri = goex.OverrideUrl(bo.tickerUri, config.ApiUrl)
+		bo.depthUri = goex.OverrideUrl(bo.depthUri, config.ApiUrl)
+		bo.accountUri = goex.OverrideUrl(bo.accountUri, config.ApiUrl)
+		bo.ordersUri = goex.OverrideUrl(bo.ordersUri, config.ApiUrl)
+		return bo
+	})
 }
 
 func New(client *http.Client, api_key, secret_key string) *Bigone {
-	return &Bigone{api_key, secret_key, client, uuid.New().String()}
+	return &Bigone{api_key, secret_key, client, uuid.New().String(), TICKER_URI, DEPTH_URI, ACCOUNT_URI, ORDERS_URI}
 }
 
 func (bo *Bigone) GetExchangeName() string {
 	return goex.BIGONE
 }
 
+func (bo *Bigone) Capabilities() goex.Capabilities {
+	return goex.Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Orders:       true,
+		OrderHistory: true,
+		Account:      true}
+}
+
 type TickerResp struct {
 	Errors []struct {
 		Code      int `json:"code"`
@@ -71,7 +96,7 @@ type TickerResp struct {
 }
 
 func (bo *Bigone) GetTicker(currency goex.CurrencyPair) (*goex.Ticker, error) {
-	tickerURI := fmt.Sprintf(TICKER_URI, currency.ToSymbol("-"))
+	tickerURI := fmt.Sprintf(bo.tickerUri, currency.ToSymbol("-"))
 
 	var resp TickerResp
 	log.Printf("GetTicker -> %s", tickerURI)
@@ -81,6 +106,9 @@ func (bo *Bigone) GetTicker(currency goex.CurrencyPair) (*goex.Ticker, error) {
 		log.Printf("GetTicker - HttpGet4 failed : %v", err)
 		return nil, err
 	}
+	if len(resp.Errors) > 0 {
+		return nil, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
+	}
 
 	var ticker goex.Ticker
 	ticker.Date = uint64(time.Now().Unix())
@@ -120,7 +148,7 @@ type PlaceOrderResp struct {
 }
 
 func (bo *Bigone) placeOrder(amount, price string, pair goex.CurrencyPair, orderType, orderSide string) (*goex.Order, error) {
-	path := ORDERS_URI
+	path := bo.ordersUri
 	params := make(map[string]string)
 	params["market_id"] = pair.ToSymbol("-")
 	params["side"] = orderSide
@@ -143,7 +171,7 @@ func (bo *Bigone) placeOrder(amount, price string, pair goex.CurrencyPair, order
 
 	if len(resp.Errors) > 0 {
 		log.Printf("placeOrder - failed : %v", resp.Errors)
-		return nil, fmt.Errorf(resp.Errors[0].Message)
+		return nil, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 
 	side := goex.BUY
@@ -172,11 +200,11 @@ func (bo *Bigone) LimitSell(amount, price string, currency goex.CurrencyPair) (*
 }
 
 func (bo *Bigone) MarketBuy(amount, price string, currency goex.CurrencyPair) (*goex.Order, error) {
-	panic("not implements")
+	return nil, goex.EX_ERR_NOT_SUPPORTED
 }
 
 func (bo *Bigone) MarketSell(amount, price string, currency goex.CurrencyPair) (*goex.Order, error) {
-	panic("not implements")
+	return nil, goex.EX_ERR_NOT_SUPPORTED
 }
 
 func (bo *Bigone) privateHeader() map[string]string {
@@ -233,7 +261,7 @@ type OrderListResp struct {
 func (bo *Bigone) getOrdersList(currencyPair goex.CurrencyPair, size int, sts goex.TradeStatus) ([]goex.Order, error) {
 	apiURL := ""
 	apiURL = fmt.Sprintf("%s?market_id=%s",
-		ORDERS_URI, currencyPair.ToSymbol("-"))
+		bo.ordersUri, currencyPair.ToSymbol("-"))
 
 	if sts == goex.ORDER_FINISH {
 		apiURL += "&state=FILLED"
@@ -246,6 +274,9 @@ func (bo *Bigone) getOrdersList(currencyPair goex.CurrencyPair, size int, sts go
 		log.Printf("getOrdersList - HttpGet4 failed : %v", err)
 		return nil, err
 	}
+	if len(resp.Errors) > 0 {
+		return nil, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
+	}
 
 	orders := make([]goex.Order, 0)
 	for _, edge := range resp.Data.Edges {
@@ -310,7 +341,7 @@ type CancelOrderResp struct {
 }
 
 func (bo *Bigone) CancelOrder(orderId string, currency goex.CurrencyPair) (bool, error) {
-	path := ORDERS_URI + "/" + orderId + "/cancel"
+	path := bo.ordersUri + "/" + orderId + "/cancel"
 	params := make(map[string]string)
 	params["order_id"] = orderId
 
@@ -327,7 +358,7 @@ func (bo *Bigone) CancelOrder(orderId string, currency goex.CurrencyPair) (bool,
 	}
 	if len(resp.Errors) > 0 {
 		log.Printf("getOrdersList - response error : %v", resp.Errors)
-		return false, fmt.Errorf("%s", resp.Errors[0].Message)
+		return false, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 	return true, nil
 }
@@ -364,13 +395,16 @@ type AccountResp struct {
 
 func (bo *Bigone) GetAccount() (*goex.Account, error) {
 	var resp AccountResp
-	apiUrl := ACCOUNT_URI
+	apiUrl := bo.accountUri
 
 	err := goex.HttpGet4(bo.httpClient, apiUrl, bo.privateHeader(), &resp)
 	if err != nil {
 		log.Println("GetAccount error:", err)
 		return nil, err
 	}
+	if len(resp.Errors) > 0 {
+		return nil, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
+	}
 
 	acc := goex.Account{}
 	acc.Exchange = bo.GetExchangeName()
@@ -418,12 +452,15 @@ type DepthResp struct {
 
 func (bo *Bigone) GetDepth(size int, currencyPair goex.CurrencyPair) (*goex.Depth, error) {
 	var resp DepthResp
-	apiURL := fmt.Sprintf(DEPTH_URI, currencyPair.ToSymbol("-"))
+	apiURL := fmt.Sprintf(bo.depthUri, currencyPair.ToSymbol("-"))
 	err := goex.HttpGet4(bo.httpClient, apiURL, nil, &resp)
 	if err != nil {
 		log.Println("GetDepth error:", err)
 		return nil, err
 	}
+	if len(resp.Errors) > 0 {
+		return nil, bo.adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
+	}
 
 	depth := new(goex.Depth)
 
@@ -441,14 +478,24 @@ func (bo *Bigone) GetDepth(size int, currencyPair goex.CurrencyPair) (*goex.Dept
 		depth.AskList = append(depth.AskList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return depth, nil
 }
 
 func (bo *Bigone) GetKlineRecords(currency goex.CurrencyPair, period, size, since int) ([]goex.Kline, error) {
-	panic("not implements")
+	return nil, goex.EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (bo *Bigone) GetTrades(currencyPair goex.CurrencyPair, since int64) ([]goex.Trade, error) {
-	panic("not implements")
+	return nil, goex.EX_ERR_NOT_SUPPORTED
+}
+
+//返回的errors中第一个错误的code, 错误码没有确认过, 不映射到EX_ERR_*
+func (bo *Bigone) adaptError(code int, message string) goex.ApiError {
+	apiErr := goex.API_ERR
+	apiErr.OriginErrMsg = fmt.Sprint(code)
+	return apiErr.OriginErr(message)
 }
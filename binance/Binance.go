@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,7 +9,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,7 +27,9 @@ const (
 	ORDER_URI              = "order?"
 	UNFINISHED_ORDERS_INFO = "openOrders?"
 	KLINE_URI              = "klines"
-	SERVER_TIME_URL        = "api/v1/time"
+	EXCHANGE_INFO_URI      = "exchangeInfo"
+	MY_TRADES_URI          = "myTrades?"
+	SERVER_TIME_URL        = "api/v3/time"
 )
 
 var _INERNAL_KLINE_PERIOD_CONVERTER = map[int]string{
@@ -50,6 +55,8 @@ type Binance struct {
 	secretKey string
 	httpClient *http.Client
 	timeoffset int64 //nanosecond
+	baseUrl,
+	apiV3 string
 }
 
 func (bn *Binance) buildParamsSigned(postForm *url.Values) error {
@@ -62,8 +69,19 @@ func (bn *Binance) buildParamsSigned(postForm *url.Values) error {
 	return nil
 }
 
+func init() {
+	RegisterExchange(BINANCE, func(config *APIConfig) API {
+		return newBinance(config.HttpClient, config.AccessKey, config.SecretKey, OverrideUrl(API_BASE_URL, config.ApiUrl))
+	})
+}
+
 func New(client *http.Client, api_key, secret_key string) *Binance {
-	bn := &Binance{accessKey: api_key, secretKey: secret_key, httpClient: client}
+	return newBinance(client, api_key, secret_key, API_BASE_URL)
+}
+
+func newBinance(client *http.Client, api_key, secret_key, baseUrl string) *Binance {
+	bn := &Binance{accessKey: api_key, secretKey: secret_key, httpClient: client,
+		baseUrl: baseUrl, apiV3: baseUrl + "api/v3/"}
 	bn.setTimeOffset()
 	return bn
 }
@@ -72,8 +90,19 @@ func (bn *Binance) GetExchangeName() string {
 	return BINANCE
 }
 
+func (bn *Binance) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Kline:        true,
+		Orders:       true,
+		Account:      true,
+		KlinePeriods: KlinePeriods(_INERNAL_KLINE_PERIOD_CONVERTER),
+		MaxDepthSize: 100}
+}
+
 func (bn *Binance) setTimeOffset() error {
-	respmap, err := HttpGet(bn.httpClient, API_BASE_URL+SERVER_TIME_URL)
+	respmap, err := HttpGet(bn.httpClient, bn.baseUrl+SERVER_TIME_URL)
 	if err != nil {
 		return err
 	}
@@ -87,9 +116,13 @@ func (bn *Binance) setTimeOffset() error {
 }
 
 func (bn *Binance) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	return bn.GetTickerCtx(context.Background(), currency)
+}
+
+func (bn *Binance) GetTickerCtx(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
 	currency2 := bn.adaptCurrencyPair(currency)
-	tickerUri := API_V1 + fmt.Sprintf(TICKER_URI, currency2.ToSymbol(""))
-	tickerMap, err := HttpGet(bn.httpClient, tickerUri)
+	tickerUri := bn.apiV3 + fmt.Sprintf(TICKER_URI, currency2.ToSymbol(""))
+	tickerMap, err := HttpGetCtx(ctx, bn.httpClient, tickerUri)
 
 	if err != nil {
 		log.Println("GetTicker error:", err)
@@ -110,6 +143,10 @@ func (bn *Binance) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (bn *Binance) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error) {
+	return bn.GetDepthCtx(context.Background(), size, currencyPair)
+}
+
+func (bn *Binance) GetDepthCtx(ctx context.Context, size int, currencyPair CurrencyPair) (*Depth, error) {
 	if size > 100 {
 		size = 100
 	} else if size < 5 {
@@ -117,15 +154,15 @@ func (bn *Binance) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	}
 	currencyPair2 := bn.adaptCurrencyPair(currencyPair)
 
-	apiUrl := fmt.Sprintf(API_V1+DEPTH_URI, currencyPair2.ToSymbol(""), size)
-	resp, err := HttpGet(bn.httpClient, apiUrl)
+	apiUrl := fmt.Sprintf(bn.apiV3+DEPTH_URI, currencyPair2.ToSymbol(""), size)
+	resp, err := HttpGetCtx(ctx, bn.httpClient, apiUrl)
 	if err != nil {
 		log.Println("GetDepth error:", err)
-		return nil, err
+		return nil, bn.adaptError(err)
 	}
 
 	if _, isok := resp["code"]; isok {
-		return nil, errors.New(resp["msg"].(string))
+		return nil, bn.adaptErrorCode(ToInt(resp["code"]), resp["msg"].(string))
 	}
 
 	bids := resp["bids"].([]interface{})
@@ -152,12 +189,14 @@ func (bn *Binance) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 		depth.AskList = append(depth.AskList, dr)
 	}
 
+	sort.Sort(sort.Reverse(depth.AskList))
+
 	return depth, nil
 }
 
-func (bn *Binance) placeOrder(amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
+func (bn *Binance) placeOrder(ctx context.Context, amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
 	pair = bn.adaptCurrencyPair(pair)
-	path := API_V3 + ORDER_URI
+	path := bn.apiV3 + ORDER_URI
 	params := url.Values{}
 	params.Set("symbol", pair.ToSymbol(""))
 	params.Set("side", orderSide)
@@ -174,11 +213,11 @@ func (bn *Binance) placeOrder(amount, price string, pair CurrencyPair, orderType
 
 	bn.buildParamsSigned(&params)
 
-	resp, err := HttpPostForm2(bn.httpClient, path, params,
+	resp, err := HttpPostForm2Ctx(ctx, bn.httpClient, path, params,
 		map[string]string{"X-MBX-APIKEY": bn.accessKey})
 	//log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
-		return nil, err
+		return nil, bn.adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -190,7 +229,7 @@ func (bn *Binance) placeOrder(amount, price string, pair CurrencyPair, orderType
 
 	orderId := ToInt(respmap["orderId"])
 	if orderId <= 0 {
-		return nil, errors.New(string(resp))
+		return nil, bn.adaptErrorBody(resp, errors.New(string(resp)))
 	}
 
 	side := BUY
@@ -211,18 +250,119 @@ func (bn *Binance) placeOrder(amount, price string, pair CurrencyPair, orderType
 		OrderTime:  int(time.Now().Unix())}, nil
 }
 
+func (bn *Binance) PlaceOrder(req OrderRequest) (*Order, error) {
+	return bn.PlaceOrderCtx(context.Background(), req)
+}
+
+func (bn *Binance) PlaceOrderCtx(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+
+	pair := bn.adaptCurrencyPair(req.Currency)
+	params := url.Values{}
+	params.Set("symbol", pair.ToSymbol(""))
+	params.Set("side", "BUY")
+	if req.Side == SELL {
+		params.Set("side", "SELL")
+	}
+	params.Set("quantity", req.Amount)
+
+	timeInForce := ""
+	switch req.TimeInForce {
+	case TIME_IN_FORCE_GTC:
+		timeInForce = "GTC"
+	case TIME_IN_FORCE_IOC:
+		timeInForce = "IOC"
+	case TIME_IN_FORCE_FOK:
+		timeInForce = "FOK"
+	}
+
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		params.Set("price", req.Price)
+		if req.TimeInForce == TIME_IN_FORCE_POST_ONLY {
+			params.Set("type", "LIMIT_MAKER")
+		} else {
+			params.Set("type", "LIMIT")
+			params.Set("timeInForce", timeInForce)
+		}
+	case ORDER_TYPE_MARKET:
+		if req.TimeInForce != TIME_IN_FORCE_GTC {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		params.Set("type", "MARKET")
+	case ORDER_TYPE_STOP_LIMIT:
+		if req.TimeInForce == TIME_IN_FORCE_POST_ONLY {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		params.Set("type", "STOP_LOSS_LIMIT")
+		params.Set("timeInForce", timeInForce)
+		params.Set("price", req.Price)
+		params.Set("stopPrice", req.StopPrice)
+	case ORDER_TYPE_STOP_MARKET:
+		if req.TimeInForce != TIME_IN_FORCE_GTC {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		params.Set("type", "STOP_LOSS")
+		params.Set("stopPrice", req.StopPrice)
+	}
+
+	if req.ClientOrderID != "" {
+		params.Set("newClientOrderId", req.ClientOrderID)
+	}
+
+	bn.buildParamsSigned(&params)
+
+	resp, err := HttpPostForm2Ctx(ctx, bn.httpClient, bn.apiV3+ORDER_URI, params,
+		map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	if err != nil {
+		return nil, bn.adaptError(err)
+	}
+
+	respmap := make(map[string]interface{})
+	err = json.Unmarshal(resp, &respmap)
+	if err != nil {
+		log.Println(string(resp))
+		return nil, err
+	}
+
+	orderId := ToInt(respmap["orderId"])
+	if orderId <= 0 {
+		return nil, bn.adaptErrorBody(resp, errors.New(string(resp)))
+	}
+
+	clientOrderId, _ := respmap["clientOrderId"].(string)
+	return &Order{
+		Currency:      req.Currency,
+		OrderID:       orderId,
+		OrderID2:      fmt.Sprint(orderId),
+		Price:         ToFloat64(req.Price),
+		Amount:        ToFloat64(req.Amount),
+		Side:          req.Side,
+		Status:        ORDER_UNFINISH,
+		OrderTime:     int(time.Now().Unix()),
+		Type:          req.Type,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: clientOrderId}, nil
+}
+
 func (bn *Binance) GetAccount() (*Account, error) {
+	return bn.GetAccountCtx(context.Background())
+}
+
+func (bn *Binance) GetAccountCtx(ctx context.Context) (*Account, error) {
 	params := url.Values{}
 	bn.buildParamsSigned(&params)
-	path := API_V3 + ACCOUNT_URI + params.Encode()
-	respmap, err := HttpGet2(bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	path := bn.apiV3 + ACCOUNT_URI + params.Encode()
+	respmap, err := HttpGet2Ctx(ctx, bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
 	if err != nil {
 		log.Println(err)
-		return nil, err
+		return nil, bn.adaptError(err)
 	}
 	//log.Println("respmap:", respmap)
 	if _, isok := respmap["code"]; isok == true {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, bn.adaptErrorCode(ToInt(respmap["code"]), respmap["msg"].(string))
 	}
 	acc := Account{}
 	acc.Exchange = bn.GetExchangeName()
@@ -244,35 +384,55 @@ func (bn *Binance) GetAccount() (*Account, error) {
 }
 
 func (bn *Binance) LimitBuy(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return bn.placeOrder(amount, price, currencyPair, "LIMIT", "BUY")
+	return bn.LimitBuyCtx(context.Background(), amount, price, currencyPair)
+}
+
+func (bn *Binance) LimitBuyCtx(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return bn.placeOrder(ctx, amount, price, currencyPair, "LIMIT", "BUY")
 }
 
 func (bn *Binance) LimitSell(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return bn.placeOrder(amount, price, currencyPair, "LIMIT", "SELL")
+	return bn.LimitSellCtx(context.Background(), amount, price, currencyPair)
+}
+
+func (bn *Binance) LimitSellCtx(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return bn.placeOrder(ctx, amount, price, currencyPair, "LIMIT", "SELL")
 }
 
 func (bn *Binance) MarketBuy(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return bn.placeOrder(amount, price, currencyPair, "MARKET", "BUY")
+	return bn.MarketBuyCtx(context.Background(), amount, price, currencyPair)
+}
+
+func (bn *Binance) MarketBuyCtx(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return bn.placeOrder(ctx, amount, price, currencyPair, "MARKET", "BUY")
 }
 
 func (bn *Binance) MarketSell(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return bn.placeOrder(amount, price, currencyPair, "MARKET", "SELL")
+	return bn.MarketSellCtx(context.Background(), amount, price, currencyPair)
+}
+
+func (bn *Binance) MarketSellCtx(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return bn.placeOrder(ctx, amount, price, currencyPair, "MARKET", "SELL")
 }
 
 func (bn *Binance) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
+	return bn.CancelOrderCtx(context.Background(), orderId, currencyPair)
+}
+
+func (bn *Binance) CancelOrderCtx(ctx context.Context, orderId string, currencyPair CurrencyPair) (bool, error) {
 	currencyPair = bn.adaptCurrencyPair(currencyPair)
-	path := API_V3 + ORDER_URI
+	path := bn.apiV3 + ORDER_URI
 	params := url.Values{}
 	params.Set("symbol", currencyPair.ToSymbol(""))
 	params.Set("orderId", orderId)
 
 	bn.buildParamsSigned(&params)
 
-	resp, err := HttpDeleteForm(bn.httpClient, path, params, map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	resp, err := HttpDeleteFormCtx(ctx, bn.httpClient, path, params, map[string]string{"X-MBX-APIKEY": bn.accessKey})
 
 	//log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
-		return false, err
+		return false, bn.adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -284,13 +444,17 @@ func (bn *Binance) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 
 	orderIdCanceled := ToInt(respmap["orderId"])
 	if orderIdCanceled <= 0 {
-		return false, errors.New(string(resp))
+		return false, bn.adaptErrorBody(resp, errors.New(string(resp)))
 	}
 
 	return true, nil
 }
 
 func (bn *Binance) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order, error) {
+	return bn.GetOneOrderCtx(context.Background(), orderId, currencyPair)
+}
+
+func (bn *Binance) GetOneOrderCtx(ctx context.Context, orderId string, currencyPair CurrencyPair) (*Order, error) {
 	params := url.Values{}
 	currencyPair = bn.adaptCurrencyPair(currencyPair)
 	params.Set("symbol", currencyPair.ToSymbol(""))
@@ -300,12 +464,12 @@ func (bn *Binance) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	params.Set("orderId", orderId)
 
 	bn.buildParamsSigned(&params)
-	path := API_V3 + ORDER_URI + params.Encode()
+	path := bn.apiV3 + ORDER_URI + params.Encode()
 
-	respmap, err := HttpGet2(bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	respmap, err := HttpGet2Ctx(ctx, bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
 	//log.Println(respmap)
 	if err != nil {
-		return nil, err
+		return nil, bn.adaptError(err)
 	}
 	status := respmap["status"].(string)
 	side := respmap["side"].(string)
@@ -345,17 +509,21 @@ func (bn *Binance) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 }
 
 func (bn *Binance) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
+	return bn.GetUnfinishOrdersCtx(context.Background(), currencyPair)
+}
+
+func (bn *Binance) GetUnfinishOrdersCtx(ctx context.Context, currencyPair CurrencyPair) ([]Order, error) {
 	params := url.Values{}
 	currencyPair = bn.adaptCurrencyPair(currencyPair)
 	params.Set("symbol", currencyPair.ToSymbol(""))
 
 	bn.buildParamsSigned(&params)
-	path := API_V3 + UNFINISHED_ORDERS_INFO + params.Encode()
+	path := bn.apiV3 + UNFINISHED_ORDERS_INFO + params.Encode()
 
-	respmap, err := HttpGet3(bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	respmap, err := HttpGet3Ctx(ctx, bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
 	//log.Println("respmap", respmap, "err", err)
 	if err != nil {
-		return nil, err
+		return nil, bn.adaptError(err)
 	}
 
 	orders := make([]Order, 0)
@@ -381,6 +549,10 @@ func (bn *Binance) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 }
 
 func (bn *Binance) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
+	return bn.GetKlineRecordsCtx(context.Background(), currency, period, size, since)
+}
+
+func (bn *Binance) GetKlineRecordsCtx(ctx context.Context, currency CurrencyPair, period, size, since int) ([]Kline, error) {
 	currency2 := bn.adaptCurrencyPair(currency)
 	params := url.Values{}
 	params.Set("symbol", currency2.ToSymbol(""))
@@ -389,9 +561,9 @@ func (bn *Binance) GetKlineRecords(currency CurrencyPair, period, size, since in
 	params.Set("endTime", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
 	params.Set("limit", fmt.Sprintf("%d", size))
 
-	klineUrl := API_V1 + KLINE_URI + "?" + params.Encode()
+	klineUrl := bn.apiV3 + KLINE_URI + "?" + params.Encode()
 	fmt.Println(klineUrl)
-	klines, err := HttpGet3(bn.httpClient, klineUrl, nil)
+	klines, err := HttpGet3Ctx(ctx, bn.httpClient, klineUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -425,12 +597,164 @@ func (bn *Binance) GetKlineRecords(currency CurrencyPair, period, size, since in
 
 //非个人，整个交易所的交易记录
 func (bn *Binance) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return bn.GetTradesCtx(context.Background(), currencyPair, since)
+}
+
+func (bn *Binance) GetTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bn *Binance) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return bn.GetOrderHistorysCtx(context.Background(), currency, currentPage, pageSize)
+}
+
+func (bn *Binance) GetOrderHistorysCtx(ctx context.Context, currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+func (bn *Binance) GetMyTrades(currencyPair CurrencyPair, since int64, limit int) ([]Fill, error) {
+	return bn.GetMyTradesCtx(context.Background(), currencyPair, since, limit)
+}
+
+func (bn *Binance) GetMyTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64, limit int) ([]Fill, error) {
+	pair := bn.adaptCurrencyPair(currencyPair)
+	params := url.Values{}
+	params.Set("symbol", pair.ToSymbol(""))
+	if since > 0 {
+		params.Set("startTime", fmt.Sprint(since))
+	}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprint(limit))
+	}
+
+	bn.buildParamsSigned(&params)
+	path := bn.apiV3 + MY_TRADES_URI + params.Encode()
+
+	resp, err := HttpGet3Ctx(ctx, bn.httpClient, path, map[string]string{"X-MBX-APIKEY": bn.accessKey})
+	if err != nil {
+		return nil, bn.adaptError(err)
+	}
+
+	var fills []Fill
+	for _, v := range resp {
+		t := v.(map[string]interface{})
+		fill := Fill{
+			OrderID:     fmt.Sprint(ToUint64(t["orderId"])),
+			TradeID:     fmt.Sprint(ToUint64(t["id"])),
+			Pair:        currencyPair,
+			Side:        SELL,
+			Price:       ToFloat64(t["price"]),
+			Amount:      ToFloat64(t["qty"]),
+			Fee:         ToFloat64(t["commission"]),
+			FeeCurrency: NewCurrency(t["commissionAsset"].(string), ""),
+			Time:        int64(ToUint64(t["time"]))}
+		if isBuyer, _ := t["isBuyer"].(bool); isBuyer {
+			fill.Side = BUY
+		}
+		fill.IsMaker, _ = t["isMaker"].(bool)
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
+}
+
+func (bn *Binance) GetMarkets() ([]Market, error) {
+	respmap, err := HttpGet(bn.httpClient, bn.apiV3+EXCHANGE_INFO_URI)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, isok := respmap["symbols"].([]interface{})
+	if !isok {
+		return nil, errors.New("response format error")
+	}
+
+	var markets []Market
+	for _, v := range symbols {
+		symbol := v.(map[string]interface{})
+		m := Market{
+			Pair: NewCurrencyPair(NewCurrency(symbol["baseAsset"].(string), "").AdaptBccToBch(),
+				NewCurrency(symbol["quoteAsset"].(string), "")),
+			Symbol: symbol["symbol"].(string),
+			Status: MARKET_STATUS_HALT}
+		if symbol["status"] == "TRADING" {
+			m.Status = MARKET_STATUS_TRADING
+		}
+
+		filters, _ := symbol["filters"].([]interface{})
+		for _, f := range filters {
+			filter := f.(map[string]interface{})
+			switch filter["filterType"] {
+			case "PRICE_FILTER":
+				m.TickSize = ToFloat64(filter["tickSize"])
+				m.PricePrecision = StepToPrecision(m.TickSize)
+			case "LOT_SIZE":
+				m.LotSize = ToFloat64(filter["stepSize"])
+				m.AmountPrecision = StepToPrecision(m.LotSize)
+				m.MinAmount = ToFloat64(filter["minQty"])
+			case "MIN_NOTIONAL":
+				m.MinNotional = ToFloat64(filter["minNotional"])
+			}
+		}
+		markets = append(markets, m)
+	}
+
+	return markets, nil
 }
+
 func (ba *Binance) adaptCurrencyPair(pair CurrencyPair) CurrencyPair {
 	return pair.AdaptBchToBcc().AdaptUsdToUsdt()
 }
+
+//非200响应的body是 {"code":-2010,"msg":"..."}, 限频时保留HttpError以便读取Retry-After
+func (bn *Binance) adaptError(err error) error {
+	if httpErr, ok := err.(HttpError); ok && !IsRateLimited(err) {
+		return bn.adaptErrorBody([]byte(httpErr.Body), err)
+	}
+	return err
+}
+
+func (bn *Binance) adaptErrorBody(body []byte, defErr error) error {
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Code == 0 {
+		return defErr
+	}
+	return bn.adaptErrorCode(resp.Code, resp.Msg)
+}
+
+//https://github.com/binance-exchange/binance-official-api-docs/blob/master/errors.md
+func (bn *Binance) adaptErrorCode(code int, msg string) ApiError {
+	apiErr := API_ERR
+	switch code {
+	case -1003, -1015:
+		apiErr = EX_ERR_API_LIMIT
+	case -1022:
+		apiErr = EX_ERR_SIGN
+	case -2014, -2015:
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case -1121:
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case -2013:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case -2010:
+		apiErr = EX_ERR_PLACE_ORDER_FAIL
+		if strings.Contains(msg, "insufficient balance") {
+			apiErr = EX_ERR_INSUFFICIENT_BALANCE
+		}
+	case -2011:
+		apiErr = EX_ERR_CANCEL_ORDER_FAIL
+		if strings.Contains(msg, "Unknown order") {
+			apiErr = EX_ERR_NOT_FIND_ORDER
+		}
+	case -1013:
+		if strings.Contains(msg, "MIN_NOTIONAL") {
+			apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+		} else if strings.Contains(msg, "LOT_SIZE") {
+			apiErr = EX_ERR_AMOUNT_TOO_SMALL
+		}
+	}
+	apiErr.OriginErrMsg = fmt.Sprintf("%d:%s", code, msg)
+	return apiErr.OriginErr(msg)
+}
@@ -2,6 +2,7 @@ package binance
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"net/http"
 	"testing"
 	"time"
@@ -45,3 +46,38 @@ func TestBinance_GetKlineRecords(t *testing.T) {
 
 	t.Log(ba.GetKlineRecords(goex.ETH_BTC, goex.KLINE_PERIOD_1MIN, 100, int(time.Now().Add(-2*time.Hour).UnixNano())))
 }
+
+func TestBinance_adaptError(t *testing.T) {
+	err := ba.adaptError(goex.HttpError{StatusCode: 400, Body: `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`})
+	if !goex.IsInsufficientBalance(err) {
+		t.Fatal(err)
+	}
+
+	err = ba.adaptError(goex.HttpError{StatusCode: 400, Body: `{"code":-2011,"msg":"Unknown order sent."}`})
+	if !goex.IsOrderNotFound(err) {
+		t.Fatal(err)
+	}
+
+	err = ba.adaptError(goex.HttpError{StatusCode: 429, Body: `{"code":-1003,"msg":"Too many requests."}`})
+	if _, ok := err.(goex.HttpError); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestBinance_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return New(client, "", "")
+	}, goextest.SpotFixtures{
+		Pair:        goex.ETH_BTC,
+		Ticker:      true,
+		DepthSize:   5,
+		Depth:       &goex.Depth{AskList: goex.DepthRecords{{Price: 0.0337, Amount: 3.1}, {Price: 0.0336, Amount: 0.8}}, BidList: goex.DepthRecords{{Price: 0.0335, Amount: 1.5}, {Price: 0.0334, Amount: 2}}},
+		KlinePeriod: goex.KLINE_PERIOD_1MIN,
+		KlineSize:   2,
+		KlineSince:  1546300800000 * 1000000,
+		Klines:      []goex.Kline{{Pair: goex.ETH_BTC, Timestamp: 1546300800, Open: 0.0335, High: 0.0338, Low: 0.0334, Close: 0.0336, Vol: 120.5}},
+		Order: &goex.Order{OrderID2: "28", Status: goex.ORDER_PART_FINISH, Side: goex.SELL, Amount: 1.5, DealAmount: 0.5,
+			Price: 0.033},
+		UnfinishOrders: true,
+		MissingOrderId: "99"}, "endTime")
+}
@@ -46,7 +46,7 @@ type LendTicker struct {
 
 func (bfx *Bitfinex) GetLendTickers() ([]LendTicker, error) {
 
-	resp, err := bfx.httpClient.Get("https://api.bitfinex.com/v2/tickers?symbols=ALL")
+	resp, err := bfx.httpClient.Get(bfx.baseUrlV2 + "/tickers?symbols=ALL")
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +84,7 @@ func (bfx *Bitfinex) GetDepositWalletBalance() (*Account, error) {
 
 func (bfx *Bitfinex) GetLendBook(currency Currency) (error, *LendBook) {
 	path := fmt.Sprintf("/lendbook/%s", currency.Symbol)
-	resp, err := bfx.httpClient.Get(BASE_URL + path)
+	resp, err := bfx.httpClient.Get(bfx.baseUrl + path)
 	if err != nil {
 		return err, nil
 	}
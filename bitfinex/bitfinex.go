@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,25 +18,45 @@ type Bitfinex struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	baseUrl,
+	baseUrlV2 string
 }
 
 const (
-	BASE_URL = "https://api.bitfinex.com/v1"
+	BASE_URL    = "https://api.bitfinex.com/v1"
+	BASE_URL_V2 = "https://api.bitfinex.com/v2"
 )
 
+func init() {
+	RegisterExchange(BITFINEX, func(config *APIConfig) API {
+		bfx := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		bfx.baseUrl = OverrideUrl(bfx.baseUrl, config.ApiUrl)
+		bfx.baseUrlV2 = OverrideUrl(bfx.baseUrlV2, config.ApiUrl)
+		return bfx
+	})
+}
+
 func New(client *http.Client, accessKey, secretKey string) *Bitfinex {
-	return &Bitfinex{client, accessKey, secretKey}
+	return &Bitfinex{client, accessKey, secretKey, BASE_URL, BASE_URL_V2}
 }
 
 func (bfx *Bitfinex) GetExchangeName() string {
 	return BITFINEX
 }
 
+func (bfx *Bitfinex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (bfx *Bitfinex) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	//pubticker
-	currencyPair = bfx.adaptCurrencyPair(currencyPair)
+	pair := bfx.adaptCurrencyPair(currencyPair)
 
-	apiUrl := fmt.Sprintf("%s/pubticker/%s", BASE_URL, strings.ToLower(currencyPair.ToSymbol("")))
+	apiUrl := fmt.Sprintf("%s/pubticker/%s", bfx.baseUrl, strings.ToLower(pair.ToSymbol("")))
 	resp, err := HttpGet(bfx.httpClient, apiUrl)
 	if err != nil {
 		return nil, err
@@ -46,6 +68,7 @@ func (bfx *Bitfinex) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 
 	//fmt.Println(resp)
 	ticker := new(Ticker)
+	ticker.Pair = currencyPair
 	ticker.Last = ToFloat64(resp["last_price"])
 	ticker.Vol = ToFloat64(resp["volume"])
 	ticker.High = ToFloat64(resp["high"])
@@ -57,7 +80,7 @@ func (bfx *Bitfinex) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 }
 
 func (bfx *Bitfinex) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error) {
-	apiUrl := fmt.Sprintf("%s/book/%s?limit_bids=%d&limit_asks=%d", BASE_URL, bfx.currencyPairToSymbol(currencyPair), size, size)
+	apiUrl := fmt.Sprintf("%s/book/%s?limit_bids=%d&limit_asks=%d", bfx.baseUrl, bfx.currencyPairToSymbol(currencyPair), size, size)
 	resp, err := HttpGet(bfx.httpClient, apiUrl)
 	if err != nil {
 		return nil, err
@@ -67,6 +90,7 @@ func (bfx *Bitfinex) GetDepth(size int, currencyPair CurrencyPair) (*Depth, erro
 	asks := resp["asks"].([]interface{})
 
 	depth := new(Depth)
+	depth.Pair = currencyPair
 
 	for _, bid := range bids {
 		_bid := bid.(map[string]interface{})
@@ -84,17 +108,78 @@ func (bfx *Bitfinex) GetDepth(size int, currencyPair CurrencyPair) (*Depth, erro
 		depth.AskList = append(depth.AskList, dr)
 	}
 
+	sort.Sort(sort.Reverse(depth.AskList))
+
 	return depth, nil
 }
 
 func (bfx *Bitfinex) GetKlineRecords(currencyPair CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 
 func (bfx *Bitfinex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+func (bfx *Bitfinex) GetMarkets() ([]Market, error) {
+	resp, err := HttpGet3(bfx.httpClient, bfx.baseUrl+"/symbols_details", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var markets []Market
+	for _, v := range resp {
+		symbol := v.(map[string]interface{})
+		pair := symbol["pair"].(string)
+		//price_precision是有效数字位数,不是小数位,无法换算为TickSize
+		markets = append(markets, Market{
+			Pair:      bfx.symbolToCurrencyPair(pair),
+			Symbol:    pair,
+			MinAmount: ToFloat64(symbol["minimum_order_size"]),
+			Status:    MARKET_STATUS_TRADING})
+	}
+
+	return markets, nil
+}
+
+//mytrades不返回maker/taker
+func (bfx *Bitfinex) GetMyTrades(currencyPair CurrencyPair, since int64, limit int) ([]Fill, error) {
+	params := map[string]interface{}{
+		"symbol": bfx.currencyPairToSymbol(bfx.adaptCurrencyPair(currencyPair))}
+	if since > 0 {
+		params["timestamp"] = fmt.Sprintf("%.3f", float64(since)/1000)
+	}
+	if limit > 0 {
+		params["limit_trades"] = limit
+	}
+
+	var resp []map[string]interface{}
+	err := bfx.doAuthenticatedRequest("POST", "mytrades", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var fills []Fill
+	for _, t := range resp {
+		fill := Fill{
+			OrderID:     fmt.Sprint(ToUint64(t["order_id"])),
+			TradeID:     fmt.Sprint(ToUint64(t["tid"])),
+			Pair:        currencyPair,
+			Side:        SELL,
+			Price:       ToFloat64(t["price"]),
+			Amount:      ToFloat64(t["amount"]),
+			Fee:         math.Abs(ToFloat64(t["fee_amount"])),
+			FeeCurrency: NewCurrency(fmt.Sprint(t["fee_currency"]), ""),
+			Time:        int64(ToFloat64(t["timestamp"]) * 1000)}
+		if t["type"] == "Buy" {
+			fill.Side = BUY
+		}
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
 }
 
 func (bfx *Bitfinex) GetWalletBalances() (map[string]*Account, error) {
@@ -194,6 +279,141 @@ func (bfx *Bitfinex) placeOrder(orderType, side, amount, price string, pair Curr
 	return order, nil
 }
 
+//v1接口不支持IOC,止损限价单和自定义订单id
+func (bfx *Bitfinex) PlaceOrder(req OrderRequest) (*Order, error) {
+	params, err := bfx.orderParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var respmap map[string]interface{}
+	err = bfx.doAuthenticatedRequest("POST", "order/new", params, &respmap)
+	if err != nil {
+		return nil, err
+	}
+
+	order := bfx.toOrder(respmap)
+	order.Currency = req.Currency
+	order.Type = req.Type
+	order.TimeInForce = req.TimeInForce
+	return order, nil
+}
+
+func (bfx *Bitfinex) orderParams(req OrderRequest) (map[string]interface{}, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	if req.ClientOrderID != "" {
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	price := req.Price
+	orderType := ""
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		switch req.TimeInForce {
+		case TIME_IN_FORCE_GTC, TIME_IN_FORCE_POST_ONLY:
+			orderType = "exchange limit"
+		case TIME_IN_FORCE_FOK:
+			orderType = "exchange fill-or-kill"
+		}
+	case ORDER_TYPE_MARKET:
+		if req.TimeInForce == TIME_IN_FORCE_GTC {
+			orderType = "exchange market"
+			price = "1" //市价单price必须是正数,不起作用
+		}
+	case ORDER_TYPE_STOP_MARKET:
+		if req.TimeInForce == TIME_IN_FORCE_GTC {
+			orderType = "exchange stop"
+			price = req.StopPrice
+		}
+	}
+	if orderType == "" {
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	side := "buy"
+	if req.Side == SELL {
+		side = "sell"
+	}
+
+	return map[string]interface{}{
+		"symbol":      bfx.currencyPairToSymbol(bfx.adaptCurrencyPair(req.Currency)),
+		"amount":      req.Amount,
+		"price":       price,
+		"side":        side,
+		"type":        orderType,
+		"exchange":    "bitfinex",
+		"is_postonly": req.TimeInForce == TIME_IN_FORCE_POST_ONLY}, nil
+}
+
+//order/new/multi每次最多10个订单,不支持post-only
+func (bfx *Bitfinex) BatchPlaceOrders(reqs []OrderRequest) ([]BatchOrderResult, error) {
+	var orders []interface{}
+	for _, req := range reqs {
+		params, err := bfx.orderParams(req)
+		if err != nil || req.TimeInForce == TIME_IN_FORCE_POST_ONLY {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		delete(params, "is_postonly")
+		orders = append(orders, params)
+	}
+
+	results := make([]BatchOrderResult, len(reqs))
+	for i := 0; i < len(reqs); i += 10 {
+		end := i + 10
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+
+		var respmap map[string]interface{}
+		err := bfx.doAuthenticatedRequest("POST", "order/new/multi", map[string]interface{}{"orders": orders[i:end]}, &respmap)
+		if err == nil && respmap["status"] != "success" {
+			err = bfx.adaptErrorMessage(fmt.Sprint(respmap["message"]), EX_ERR_PLACE_ORDER_FAIL)
+		}
+
+		orderList, _ := respmap["order_ids"].([]interface{})
+		for j := i; j < end; j++ {
+			if err != nil {
+				results[j].Err = err
+				continue
+			}
+			if j-i >= len(orderList) {
+				results[j].Err = EX_ERR_PLACE_ORDER_FAIL
+				continue
+			}
+			order := bfx.toOrder(orderList[j-i].(map[string]interface{}))
+			order.Currency = reqs[j].Currency
+			order.Type = reqs[j].Type
+			order.TimeInForce = reqs[j].TimeInForce
+			results[j].Order = order
+		}
+	}
+
+	return results, nil
+}
+
+//order/cancel/multi不返回每个订单的撤单结果
+func (bfx *Bitfinex) BatchCancelOrders(currencyPair CurrencyPair, orderIds []string) ([]BatchCancelResult, error) {
+	var ids []int
+	for _, id := range orderIds {
+		ids = append(ids, ToInt(id))
+	}
+
+	var respmap map[string]interface{}
+	err := bfx.doAuthenticatedRequest("POST", "order/cancel/multi", map[string]interface{}{"order_ids": ids}, &respmap)
+	if err == nil && respmap["result"] == nil {
+		err = bfx.adaptErrorMessage(fmt.Sprint(respmap["message"]), EX_ERR_CANCEL_ORDER_FAIL)
+	}
+
+	results := make([]BatchCancelResult, len(orderIds))
+	for i, id := range orderIds {
+		results[i].OrderID = id
+		results[i].Err = err
+	}
+	return results, nil
+}
+
 func (bfx *Bitfinex) LimitBuy(amount, price string, currencyPair CurrencyPair) (*Order, error) {
 	return bfx.placeOrder("exchange limit", "buy", amount, price, currencyPair)
 }
@@ -267,7 +487,7 @@ func (bfx *Bitfinex) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, erro
 		return nil, err
 	}
 
-	var orders []Order
+	orders := make([]Order, 0, len(ordersmap))
 	for _, v := range ordersmap {
 		ordermap := v.(map[string]interface{})
 		orders = append(orders, *bfx.toOrder(ordermap))
@@ -276,7 +496,7 @@ func (bfx *Bitfinex) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, erro
 }
 
 func (bfx *Bitfinex) GetOrderHistorys(currencyPair CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bfx *Bitfinex) doAuthenticatedRequest(method, path string, payload map[string]interface{}, ret interface{}) error {
@@ -297,7 +517,7 @@ func (bfx *Bitfinex) doAuthenticatedRequest(method, path string, payload map[str
 	sign, _ := GetParamHmacSha384Sign(bfx.secretKey, encoded)
 	//log.Println(BASE_URL + "/" + path)
 
-	resp, err := NewHttpRequest(bfx.httpClient, method, BASE_URL+"/"+path, "", map[string]string{
+	resp, err := NewHttpRequest(bfx.httpClient, method, bfx.baseUrl+"/"+path, "", map[string]string{
 		"Content-Type":    "application/json",
 		"Accept":          "application/json",
 		"X-BFX-APIKEY":    bfx.accessKey,
@@ -305,13 +525,54 @@ func (bfx *Bitfinex) doAuthenticatedRequest(method, path string, payload map[str
 		"X-BFX-SIGNATURE": sign})
 
 	if err != nil {
-		return err
+		return bfx.adaptError(err)
 	}
 	//print(string(resp))
 	err = json.Unmarshal(resp, ret)
 	return err
 }
 
+//非200响应的body是 {"message":"..."}, 限频时保留HttpError以便读取Retry-After
+func (bfx *Bitfinex) adaptError(err error) error {
+	httpErr, ok := err.(HttpError)
+	if !ok || IsRateLimited(err) {
+		return err
+	}
+
+	var respmap map[string]interface{}
+	if json.Unmarshal([]byte(httpErr.Body), &respmap) != nil {
+		return err
+	}
+	msg, _ := respmap["message"].(string)
+	if msg == "" {
+		return err
+	}
+	return bfx.adaptErrorMessage(msg, API_ERR)
+}
+
+//没有错误码只能按内容判断
+func (bfx *Bitfinex) adaptErrorMessage(msg string, defErr ApiError) ApiError {
+	apiErr := defErr
+	switch {
+	case strings.Contains(msg, "not enough") && strings.Contains(msg, "balance"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(msg, "minimum size"):
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case strings.Contains(msg, "No such order"), strings.Contains(msg, "Order could not be found"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(msg, "X-BFX-SIGNATURE"):
+		apiErr = EX_ERR_SIGN
+	case strings.Contains(msg, "X-BFX-APIKEY"):
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case strings.Contains(msg, "Unknown symbol"), strings.Contains(msg, "Invalid symbol"):
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case strings.Contains(msg, "Ratelimit"):
+		apiErr = EX_ERR_API_LIMIT
+	}
+	apiErr.OriginErrMsg = msg
+	return apiErr.OriginErr(msg)
+}
+
 func (bfx *Bitfinex) currencyPairToSymbol(currencyPair CurrencyPair) string {
 	return strings.ToUpper(currencyPair.ToSymbol(""))
 }
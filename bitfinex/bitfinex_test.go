@@ -2,6 +2,7 @@ package bitfinex
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"net/http"
 	"testing"
 )
@@ -18,3 +19,17 @@ func TestBitfinex_GetDepth(t *testing.T) {
 	t.Log(dep.AskList)
 	t.Log(dep.BidList)
 }
+
+func TestBitfinex_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return New(client, "", "")
+	}, goextest.SpotFixtures{
+		Pair:      goex.ETH_BTC,
+		Ticker:    true,
+		DepthSize: 2,
+		Depth:     &goex.Depth{AskList: goex.DepthRecords{{Price: 0.0337, Amount: 7.3}, {Price: 0.0336, Amount: 2}}, BidList: goex.DepthRecords{{Price: 0.0335, Amount: 4.5}, {Price: 0.0334, Amount: 1.2}}},
+		Order: &goex.Order{OrderID2: "448411153", Status: goex.ORDER_PART_FINISH, Side: goex.SELL, Amount: 2, DealAmount: 0.5,
+			OrderID: 448411153, AvgPrice: 0.0331, OrderTime: 1546300800},
+		UnfinishOrders: true,
+		MissingOrderId: "1"})
+}
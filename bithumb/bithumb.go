@@ -10,22 +10,31 @@ import (
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
-	"errors"
 )
 
 type Bithumb struct {
 	client *http.Client
 	accesskey,
-	secretkey string
+	secretkey,
+	baseUrl string
 }
 
 var (
 	baseUrl = "https://api.bithumb.com"
 )
 
+func init() {
+	RegisterExchange(BITHUMB, func(config *APIConfig) API {
+		bit := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		bit.baseUrl = OverrideUrl(bit.baseUrl, config.ApiUrl)
+		return bit
+	})
+}
+
 func New(client *http.Client, accesskey, secretkey string) *Bithumb {
-	return &Bithumb{client: client, accesskey: accesskey, secretkey: secretkey}
+	return &Bithumb{client: client, accesskey: accesskey, secretkey: secretkey, baseUrl: baseUrl}
 }
 
 func (bit *Bithumb) placeOrder(side, amount, price string, pair CurrencyPair) (*Order, error) {
@@ -38,7 +47,7 @@ func (bit *Bithumb) placeOrder(side, amount, price string, pair CurrencyPair) (*
 	}
 	if retmap["status"].(string) != "0000" {
 		log.Println(retmap)
-		return nil, errors.New(retmap["status"].(string))
+		return nil, bit.adaptError(retmap)
 	}
 
 	var tradeSide TradeSide
@@ -68,15 +77,15 @@ func (bit *Bithumb) LimitSell(amount, price string, currency CurrencyPair) (*Ord
 }
 
 func (bit *Bithumb) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bit *Bithumb) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bit *Bithumb) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("please invoke the CancelOrder2 method.")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 /*补丁*/
@@ -90,11 +99,11 @@ func (bit *Bithumb) CancelOrder2(side, orderId string, currency CurrencyPair) (b
 	if retmap["status"].(string) == "0000" {
 		return true, nil
 	}
-	return false, errors.New(retmap["status"].(string))
+	return false, bit.adaptError(retmap)
 }
 
 func (bit *Bithumb) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("please invoke the GetOneOrder2 method.")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 /*补丁*/
@@ -107,12 +116,8 @@ func (bit *Bithumb) GetOneOrder2(side, orderId string, currency CurrencyPair) (*
 	}
 
 	if retmap["status"].(string) != "0000" {
-		message := retmap["message"].(string)
-		if "거래 체결내역이 존재하지 않습니다." == message {
-			return nil, EX_ERR_NOT_FIND_ORDER
-		}
 		log.Println(retmap)
-		return nil, errors.New(retmap["status"].(string))
+		return nil, bit.adaptError(retmap)
 	}
 
 	order := new(Order)
@@ -156,7 +161,7 @@ func (bit *Bithumb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 		if "거래 진행중인 내역이 존재하지 않습니다." == message {
 			return []Order{}, nil
 		}
-		return nil, errors.New(fmt.Sprintf("[%s]%s", retmap["status"].(string), message))
+		return nil, bit.adaptError(retmap)
 	}
 
 	var orders []Order
@@ -200,7 +205,7 @@ func (bit *Bithumb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (bit *Bithumb) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bit *Bithumb) GetAccount() (*Account, error) {
@@ -209,6 +214,9 @@ func (bit *Bithumb) GetAccount() (*Account, error) {
 	if err != nil {
 		return nil, err
 	}
+	if retmap["status"] != "0000" {
+		return nil, bit.adaptError(retmap)
+	}
 	datamap := retmap["data"].(map[string]interface{})
 	acc := new(Account)
 	acc.SubAccounts = make(map[Currency]SubAccount)
@@ -260,7 +268,7 @@ func (bit *Bithumb) doAuthenticatedRequest(uri, params string, ret interface{})
 	content_length_str := strconv.Itoa(len(params))
 
 	// Connects to Bithumb API server and returns JSON result value.
-	resp, err := NewHttpRequest(bit.client, "POST", baseUrl+uri,
+	resp, err := NewHttpRequest(bit.client, "POST", bit.baseUrl+uri,
 		bytes.NewBufferString(params).String(), map[string]string{
 			"Api-Key":        bit.accesskey,
 			"Api-Sign":       api_sign,
@@ -279,17 +287,12 @@ func (bit *Bithumb) doAuthenticatedRequest(uri, params string, ret interface{})
 }
 
 func (bit *Bithumb) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	respmap, err := HttpGet(bit.client, fmt.Sprintf("%s/public/ticker/%s", baseUrl, currency.CurrencyA))
+	respmap, err := HttpGet(bit.client, fmt.Sprintf("%s/public/ticker/%s", bit.baseUrl, currency.CurrencyA))
 	if err != nil {
 		return nil, err
 	}
-	s, isok := respmap["status"].(string)
-	if s != "0000" || isok != true {
-		msg := "ticker error"
-		if isok {
-			msg = s
-		}
-		return nil, errors.New(msg)
+	if respmap["status"] != "0000" {
+		return nil, bit.adaptError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -305,13 +308,13 @@ func (bit *Bithumb) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (bit *Bithumb) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	resp, err := HttpGet(bit.client, fmt.Sprintf("%s/public/orderbook/%s", baseUrl, currency.CurrencyA))
+	resp, err := HttpGet(bit.client, fmt.Sprintf("%s/public/orderbook/%s", bit.baseUrl, currency.CurrencyA))
 	if err != nil {
 		return nil, err
 	}
 
-	if resp["status"].(string) != "0000" {
-		return nil, errors.New(resp["status"].(string))
+	if resp["status"] != "0000" {
+		return nil, bit.adaptError(resp)
 	}
 
 	datamap := resp["data"].(map[string]interface{})
@@ -336,14 +339,45 @@ func (bit *Bithumb) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (bit *Bithumb) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (bit *Bithumb) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bit *Bithumb) GetExchangeName() string {
 	return BITHUMB
 }
+
+func (bit *Bithumb) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
+//错误是 {"status":"5600","message":"..."}, 5600的具体原因只在message中
+func (bit *Bithumb) adaptError(retmap map[string]interface{}) ApiError {
+	status := fmt.Sprint(retmap["status"])
+	message, _ := retmap["message"].(string)
+	if message == "" {
+		message = status
+	}
+
+	apiErr := API_ERR
+	switch {
+	case status == "5300":
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case message == "거래 체결내역이 존재하지 않습니다.":
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(message, "사용가능") && strings.Contains(message, "초과"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.HasPrefix(message, "최소 주문수량"):
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	}
+	apiErr.OriginErrMsg = status
+	return apiErr.OriginErr(message)
+}
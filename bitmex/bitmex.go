@@ -1,9 +1,11 @@
 package bitmex
 
 import (
+	"encoding/json"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,57 +19,66 @@ var (
 type Bitmex struct {
 	httpClient *http.Client
 	accessKey,
-	secretKey string
+	secretKey,
+	baseUrl string
+}
+
+func init() {
+	RegisterExchange(BITMEX, func(config *APIConfig) API {
+		bm := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		bm.baseUrl = OverrideUrl(bm.baseUrl, config.ApiUrl)
+		return bm
+	})
 }
 
 func New(client *http.Client, accesskey, secretkey string) *Bitmex {
-	return &Bitmex{client, accesskey, secretkey}
+	return &Bitmex{client, accesskey, secretkey, base_url}
 }
 
 func (Bitmex *Bitmex) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (Bitmex *Bitmex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	uri := fmt.Sprintf("orderBook/L2?symbol=%s&depth=%d", Bitmex.pairToSymbol(currency), size)
-	resp, err := HttpGet3(Bitmex.httpClient, base_url+uri, nil)
+	resp, err := HttpGet3(Bitmex.httpClient, Bitmex.baseUrl+uri, nil)
 	if err != nil {
-		return nil, HTTP_ERR_CODE.OriginErr(err.Error())
+		return nil, Bitmex.adaptError(err)
 	}
 
 	//log.Println(resp)
@@ -86,25 +97,71 @@ func (Bitmex *Bitmex) GetDepth(size int, currency CurrencyPair) (*Depth, error)
 		}
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(dep.AskList))
+	sort.Sort(sort.Reverse(dep.BidList))
 	return dep, nil
 }
 
 func (Bitmex *Bitmex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (Bitmex *Bitmex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (Bitmex *Bitmex) GetExchangeName() string {
 	return BITMEX
 }
 
+func (Bitmex *Bitmex) Capabilities() Capabilities {
+	return Capabilities{
+		Depth: true}
+}
+
 func (mex *Bitmex) pairToSymbol(pair CurrencyPair) string {
 	if pair.CurrencyA.Symbol == BTC.Symbol {
 		return NewCurrencyPair(XBT, USD).ToSymbol("")
 	}
 	return pair.AdaptUsdtToUsd().ToSymbol("")
 }
+
+//错误的body是 {"error":{"message":"Signature not valid.","name":"HTTPError"}}, 限频时保留HttpError
+func (mex *Bitmex) adaptError(err error) error {
+	httpErr, ok := err.(HttpError)
+	if !ok {
+		return HTTP_ERR_CODE.OriginErr(err.Error())
+	}
+	if IsRateLimited(err) {
+		return err
+	}
+
+	var resp struct {
+		Error struct {
+			Message string `json:"message"`
+			Name    string `json:"name"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(httpErr.Body), &resp) != nil || resp.Error.Message == "" {
+		return err
+	}
+
+	msg := resp.Error.Message
+	apiErr := API_ERR
+	switch {
+	case strings.Contains(msg, "insufficient Available Balance"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.HasPrefix(msg, "Signature not valid"):
+		apiErr = EX_ERR_SIGN
+	case strings.HasPrefix(msg, "Invalid API Key"):
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case strings.HasPrefix(msg, "Not Found"), strings.HasPrefix(msg, "Invalid orderID"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.HasPrefix(msg, "Invalid symbol"):
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	}
+	apiErr.OriginErrMsg = httpErr.Body
+	return apiErr.OriginErr(msg)
+}
@@ -30,3 +30,14 @@ func TestBitmex_GetDepth(t *testing.T) {
 	assert.Nil(t, err)
 	t.Log(dep)
 }
+
+func TestBitmex_adaptError(t *testing.T) {
+	err := mex.adaptError(goex.HttpError{StatusCode: 400, Body: `{"error":{"message":"Account has insufficient Available Balance, 100 XBt required","name":"ValidationError"}}`})
+	assert.True(t, goex.IsInsufficientBalance(err))
+
+	err = mex.adaptError(goex.HttpError{StatusCode: 404, Body: `{"error":{"message":"Not Found","name":"HTTPError"}}`})
+	assert.True(t, goex.IsOrderNotFound(err))
+
+	err = mex.adaptError(goex.HttpError{StatusCode: 429, Body: `{"error":{"message":"Rate limit exceeded, retry in 1 seconds.","name":"RateLimitError"}}`})
+	assert.True(t, goex.IsRateLimited(err))
+}
@@ -17,6 +17,7 @@ import (
 
 var (
 	BASE_URL = "https://www.bitstamp.net/api/"
+	WS_URL   = "wss://ws.pusherapp.com/app/de504dc5763aeef9ff52?protocol=7&client=js&version=2.1.6&flash=false"
 )
 
 type Bitstamp struct {
@@ -24,14 +25,27 @@ type Bitstamp struct {
 	clientId,
 	accessKey,
 	secretkey string
-	ws                *WsConn
-	createWsLock      sync.Mutex
-	wsTickerHandleMap map[string]func(*Ticker)
-	wsDepthHandleMap  map[string]func(*Depth)
+	baseUrl,
+	wsUrl string
+	ws            *WsConn
+	wsDialOptions WsDialOptions
+	createWsLock  sync.Mutex
+	wsHandlers    *WsRegistry
+}
+
+func init() {
+	RegisterExchange(BITSTAMP, func(config *APIConfig) API {
+		bitstamp := NewBitstamp(config.HttpClient, config.AccessKey, config.SecretKey, config.ClientId)
+		bitstamp.baseUrl = OverrideUrl(bitstamp.baseUrl, config.ApiUrl)
+		bitstamp.wsUrl = OverrideUrl(bitstamp.wsUrl, config.WsUrl)
+		bitstamp.wsDialOptions = config.WsDialOptions
+		return bitstamp
+	})
 }
 
 func NewBitstamp(client *http.Client, accessKey, secertkey, clientId string) *Bitstamp {
-	return &Bitstamp{client: client, accessKey: accessKey, secretkey: secertkey, clientId: clientId}
+	return &Bitstamp{client: client, accessKey: accessKey, secretkey: secertkey, clientId: clientId,
+		baseUrl: BASE_URL, wsUrl: WS_URL, wsHandlers: NewWsRegistry()}
 }
 
 func (bitstamp *Bitstamp) buildPostForm(params *url.Values) {
@@ -46,7 +60,7 @@ func (bitstamp *Bitstamp) buildPostForm(params *url.Values) {
 }
 
 func (bitstamp *Bitstamp) GetAccount() (*Account, error) {
-	urlStr := fmt.Sprintf("%s%s", BASE_URL, "v2/balance/")
+	urlStr := fmt.Sprintf("%s%s", bitstamp.baseUrl, "v2/balance/")
 	params := url.Values{}
 	bitstamp.buildPostForm(&params)
 	resp, err := HttpPostForm(bitstamp.client, urlStr, params)
@@ -62,6 +76,9 @@ func (bitstamp *Bitstamp) GetAccount() (*Account, error) {
 		log.Println(err)
 		return nil, err
 	}
+	if respmap["status"] == "error" {
+		return nil, bitstamp.adaptError(resp)
+	}
 
 	acc := Account{}
 	acc.Exchange = bitstamp.GetExchangeName()
@@ -132,7 +149,7 @@ func (bitstamp *Bitstamp) placeOrder(side string, pair CurrencyPair, amount, pri
 
 	orderId, isok := respmap["id"].(string)
 	if !isok {
-		return nil, errors.New(string(resp))
+		return nil, bitstamp.adaptError(resp)
 	}
 
 	orderSide := BUY
@@ -159,13 +176,13 @@ func (bitstamp *Bitstamp) placeOrder(side string, pair CurrencyPair, amount, pri
 }
 
 func (bitstamp *Bitstamp) placeLimitOrder(side string, pair CurrencyPair, amount, price string) (*Order, error) {
-	urlStr := fmt.Sprintf("%sv2/%s/%s/", BASE_URL, side, strings.ToLower(pair.ToSymbol("")))
+	urlStr := fmt.Sprintf("%sv2/%s/%s/", bitstamp.baseUrl, side, strings.ToLower(pair.ToSymbol("")))
 	//println(urlStr)
 	return bitstamp.placeOrder(side, pair, amount, price, urlStr)
 }
 
 func (bitstamp *Bitstamp) placeMarketOrder(side string, pair CurrencyPair, amount string) (*Order, error) {
-	urlStr := fmt.Sprintf("%sv2/%s/market/%s/", BASE_URL, side, strings.ToLower(pair.ToSymbol("")))
+	urlStr := fmt.Sprintf("%sv2/%s/market/%s/", bitstamp.baseUrl, side, strings.ToLower(pair.ToSymbol("")))
 	//println(urlStr)
 	return bitstamp.placeOrder(side, pair, amount, "", urlStr)
 }
@@ -191,7 +208,7 @@ func (bitstamp *Bitstamp) CancelOrder(orderId string, currency CurrencyPair) (bo
 	params.Set("id", orderId)
 	bitstamp.buildPostForm(&params)
 
-	urlStr := BASE_URL + "v2/cancel_order/"
+	urlStr := bitstamp.baseUrl + "v2/cancel_order/"
 	resp, err := HttpPostForm(bitstamp.client, urlStr, params)
 	if err != nil {
 		return false, err
@@ -204,7 +221,7 @@ func (bitstamp *Bitstamp) CancelOrder(orderId string, currency CurrencyPair) (bo
 	}
 
 	if respmap["error"] != nil {
-		return false, errors.New(string(resp))
+		return false, bitstamp.adaptError(resp)
 	}
 
 	println(string(resp))
@@ -216,7 +233,7 @@ func (bitstamp *Bitstamp) GetOneOrder(orderId string, currency CurrencyPair) (*O
 	params.Set("id", orderId)
 	bitstamp.buildPostForm(&params)
 
-	urlStr := BASE_URL + "order_status/"
+	urlStr := bitstamp.baseUrl + "order_status/"
 	resp, err := HttpPostForm(bitstamp.client, urlStr, params)
 	if err != nil {
 		return nil, err
@@ -231,7 +248,7 @@ func (bitstamp *Bitstamp) GetOneOrder(orderId string, currency CurrencyPair) (*O
 
 	transactions, isok := respmap["transactions"].([]interface{})
 	if !isok {
-		return nil, errors.New(string(resp))
+		return nil, bitstamp.adaptError(resp)
 	}
 
 	status := respmap["status"].(string)
@@ -286,7 +303,7 @@ func (bitstamp *Bitstamp) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 	params := url.Values{}
 	bitstamp.buildPostForm(&params)
 
-	urlStr := BASE_URL + "v2/open_orders/" + strings.ToLower(currency.ToSymbol("")) + "/"
+	urlStr := bitstamp.baseUrl + "v2/open_orders/" + strings.ToLower(currency.ToSymbol("")) + "/"
 	resp, err := HttpPostForm(bitstamp.client, urlStr, params)
 	if err != nil {
 		return nil, err
@@ -324,13 +341,13 @@ func (bitstamp *Bitstamp) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 }
 
 func (bitstamp *Bitstamp) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //
 
 func (bitstamp *Bitstamp) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	urlStr := BASE_URL + "v2/ticker/" + strings.ToLower(currency.ToSymbol(""))
+	urlStr := bitstamp.baseUrl + "v2/ticker/" + strings.ToLower(currency.ToSymbol(""))
 	respmap, err := HttpGet(bitstamp.client, urlStr)
 	if err != nil {
 		return nil, err
@@ -348,7 +365,7 @@ func (bitstamp *Bitstamp) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (bitstamp *Bitstamp) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	urlStr := BASE_URL + "v2/order_book/" + strings.ToLower(currency.ToSymbol(""))
+	urlStr := bitstamp.baseUrl + "v2/order_book/" + strings.ToLower(currency.ToSymbol(""))
 	//println(urlStr)
 	respmap, err := HttpGet(bitstamp.client, urlStr)
 	if err != nil {
@@ -368,7 +385,7 @@ func (bitstamp *Bitstamp) GetDepth(size int, currency CurrencyPair) (*Depth, err
 	dep := new(Depth)
 	for _, v := range bids {
 		bid := v.([]interface{})
-		dep.BidList = append(dep.BidList, DepthRecord{ToFloat64(bid[0]), ToFloat64(bid[1])})
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(bid[0]), Amount: ToFloat64(bid[1])})
 		i++
 		if i == size {
 			break
@@ -378,7 +395,7 @@ func (bitstamp *Bitstamp) GetDepth(size int, currency CurrencyPair) (*Depth, err
 	i = 0
 	for _, v := range asks {
 		ask := v.([]interface{})
-		dep.AskList = append(dep.AskList, DepthRecord{ToFloat64(ask[0]), ToFloat64(ask[1])})
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(ask[0]), Amount: ToFloat64(ask[1])})
 		i++
 		if i == size {
 			break
@@ -390,14 +407,70 @@ func (bitstamp *Bitstamp) GetDepth(size int, currency CurrencyPair) (*Depth, err
 }
 
 func (bitstamp *Bitstamp) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 ////非个人，整个交易所的交易记录
 func (bitstamp *Bitstamp) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bitstamp *Bitstamp) GetExchangeName() string {
 	return BITSTAMP
 }
+
+func (bitstamp *Bitstamp) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true,
+		Ws:      true}
+}
+
+/**
+ * 错误是 {"error":"Order not found"} 或者 {"status":"error","reason":{"__all__":["You have only 1 USD available..."]}}
+ * reason也可能是字符串, 没有统一的错误码, 按内容判断
+ */
+func (bitstamp *Bitstamp) adaptError(resp []byte) error {
+	var respmap map[string]interface{}
+	if json.Unmarshal(resp, &respmap) != nil {
+		return errors.New(string(resp))
+	}
+
+	var msgs []string
+	for _, v := range []interface{}{respmap["error"], respmap["reason"]} {
+		switch reason := v.(type) {
+		case string:
+			msgs = append(msgs, reason)
+		case map[string]interface{}:
+			for _, field := range reason {
+				if list, ok := field.([]interface{}); ok {
+					for _, msg := range list {
+						msgs = append(msgs, fmt.Sprint(msg))
+					}
+				}
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return errors.New(string(resp))
+	}
+
+	msg := strings.Join(msgs, "; ")
+	apiErr := API_ERR
+	switch {
+	case strings.Contains(msg, "Check your account balance"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(msg, "Order not found"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.HasPrefix(msg, "Minimum order size is"):
+		apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+	case strings.Contains(msg, "Invalid signature"):
+		apiErr = EX_ERR_SIGN
+	case strings.Contains(msg, "API key not found"):
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	}
+	apiErr.OriginErrMsg = string(resp)
+	return apiErr.OriginErr(msg)
+}
@@ -49,14 +49,14 @@ func TestBitstamp_LimitSell(t *testing.T) {
 
 
 func TestBitstamp_MarketBuy(t *testing.T) {
-	ord, err := btmp.MarketBuy("1", goex.XRP_USD)
+	ord, err := btmp.MarketBuy("1", "", goex.XRP_USD)
 	assert.Nil(t, err)
 	t.Log(ord)
 }
 
 
 func TestBitstamp_MarketSell(t *testing.T) {
-	ord, err := btmp.MarketSell("2", goex.XRP_USD)
+	ord, err := btmp.MarketSell("2", "", goex.XRP_USD)
 	assert.Nil(t, err)
 	t.Log(ord)
 }
@@ -78,3 +78,15 @@ func TestBitstamp_GetOneOrder(t *testing.T) {
 	assert.Nil(t, err)
 	t.Log(ord)
 }
+
+func TestBitstamp_adaptError(t *testing.T) {
+	err := btmp.adaptError([]byte(`{"status":"error","reason":{"__all__":["You have only 1.5 USD available. Check your account balance for details."]}}`))
+	assert.True(t, goex.IsInsufficientBalance(err))
+	assert.Equal(t, "You have only 1.5 USD available. Check your account balance for details.", err.Error())
+
+	err = btmp.adaptError([]byte(`{"error":"Order not found"}`))
+	assert.True(t, goex.IsOrderNotFound(err))
+
+	err = btmp.adaptError([]byte(`{"status":"error","reason":"Invalid signature","code":"API0005"}`))
+	assert.Equal(t, goex.EX_ERR_SIGN.ErrCode, err.(goex.ApiError).ErrCode)
+}
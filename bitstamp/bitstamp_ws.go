@@ -16,20 +16,17 @@ type Event struct {
 	Data    interface{} `json:"data"`
 }
 
-func (bm *Bitstamp) createWsConn() {
-	if bm.ws != nil {
-		return
-	}
-
-	//connect wsx
+func (bm *Bitstamp) createWsConn() (*goex.WsConn, error) {
 	bm.createWsLock.Lock()
 	defer bm.createWsLock.Unlock()
 
+	//connect wsx
 	if bm.ws == nil {
-		bm.wsDepthHandleMap = make(map[string]func(*goex.Depth), 1)
-		bm.wsTickerHandleMap = make(map[string]func(*goex.Ticker), 1)
-
-		bm.ws = goex.NewWsConn("wss://ws.pusherapp.com/app/de504dc5763aeef9ff52?protocol=7&client=js&version=2.1.6&flash=false")
+		ws, err := goex.DialWsConn(bm.wsUrl, bm.wsDialOptions)
+		if err != nil {
+			return nil, err
+		}
+		bm.ws = ws
 		bm.ws.Heartbeat(func() interface{} { return Event{Event: "pusher:ping"} }, 10*time.Second)
 		bm.ws.ReConnect()
 		bm.ws.ReceiveMessage(func(msg []byte) {
@@ -41,33 +38,114 @@ func (bm *Bitstamp) createWsConn() {
 			}
 			switch e.Event {
 			case "pusher:pong":
-				bm.ws.UpdateActivedTime()
+				ws.UpdateActivedTime()
 			case "data":
+				if !strings.HasPrefix(e.Channel, "order_book") || !bm.wsHandlers.Has(e.Channel) {
+					return
+				}
 				pair := bm.getPairFromChannel(e.Channel)
 				dep := bm.parseDepth(e.Data.(string))
 				dep.Pair = pair
-				if strings.HasPrefix(e.Channel, "order_book") {
-					bm.wsDepthHandleMap[e.Channel](dep)
+				bm.wsHandlers.Dispatch(e.Channel, dep)
+			case "trade":
+				if !strings.HasPrefix(e.Channel, "live_trades") || !bm.wsHandlers.Has(e.Channel) {
+					return
 				}
+				trade, err := bm.parseTrade(e.Data.(string))
+				if err != nil {
+					log.Println(err)
+					return
+				}
+				trade.Pair = bm.getPairFromChannel(e.Channel)
+				bm.wsHandlers.Dispatch(e.Channel, trade)
 			default:
 				log.Printf("%+v", e)
 			}
 		})
 	}
+	return bm.ws, nil
 }
 
 func (bm *Bitstamp) GetDepthWithWs(pair goex.CurrencyPair, handle func(*goex.Depth)) error {
-	bm.createWsConn()
-	channel := fmt.Sprintf("order_book_%s", strings.ToLower(pair.ToSymbol("")))
+	_, err := bm.AddWsHandle(goex.UNSUB_ORDERBOOK, pair, 0, handle)
+	return err
+}
+
+func (bm *Bitstamp) GetTradeWithWs(pair goex.CurrencyPair, handle func(*goex.Trade)) error {
+	_, err := bm.AddWsHandle(goex.UNSUB_TRADE, pair, 0, handle)
+	return err
+}
+
+func (bm *Bitstamp) UnsubscribeDepth(pair goex.CurrencyPair) error {
+	return bm.wsHandlers.Unsubscribe(bm.depthChannel(pair))
+}
+
+func (bm *Bitstamp) UnsubscribeTrade(pair goex.CurrencyPair) error {
+	return bm.wsHandlers.Unsubscribe(bm.tradeChannel(pair))
+}
+
+//只支持深度和成交, pusher没有ticker和K线频道
+func (bm *Bitstamp) AddWsHandle(event int, pair goex.CurrencyPair, period int, handle interface{}) (int64, error) {
+	var channel string
+	switch event {
+	case goex.UNSUB_ORDERBOOK:
+		channel = bm.depthChannel(pair)
+	case goex.UNSUB_TRADE:
+		channel = bm.tradeChannel(pair)
+	default:
+		return 0, goex.EX_ERR_NOT_SUPPORTED
+	}
+
+	ws, err := bm.createWsConn()
+	if err != nil {
+		return 0, err
+	}
+	return bm.wsHandlers.Subscribe(channel, handle, func() error {
+		return ws.Subscribe(bm.channelEvent("pusher:subscribe", channel))
+	}, func() error {
+		return ws.Unsubscribe(bm.channelEvent("pusher:subscribe", channel), bm.channelEvent("pusher:unsubscribe", channel))
+	})
+}
+
+func (bm *Bitstamp) RemoveWsHandle(id int64) error {
+	return bm.wsHandlers.Remove(id)
+}
+
+func (bm *Bitstamp) depthChannel(pair goex.CurrencyPair) string {
 	if pair == goex.BTC_USD {
-		channel = "order_book"
+		return "order_book"
 	}
-	bm.wsDepthHandleMap[channel] = handle
-	e := &Event{
-		Event: "pusher:subscribe",
+	return fmt.Sprintf("order_book_%s", strings.ToLower(pair.ToSymbol("")))
+}
+
+func (bm *Bitstamp) tradeChannel(pair goex.CurrencyPair) string {
+	if pair == goex.BTC_USD {
+		return "live_trades"
+	}
+	return fmt.Sprintf("live_trades_%s", strings.ToLower(pair.ToSymbol("")))
+}
+
+func (bm *Bitstamp) channelEvent(event, channel string) *Event {
+	return &Event{
+		Event: event,
 		Data: map[string]interface{}{
 			"channel": channel}}
-	return bm.ws.Subscribe(e)
+}
+
+func (bm *Bitstamp) GetTickerWithWs(pair goex.CurrencyPair, handle func(*goex.Ticker)) error {
+	return goex.EX_ERR_NOT_SUPPORTED
+}
+
+func (bm *Bitstamp) GetKLineWithWs(pair goex.CurrencyPair, period int, handle func(*goex.Kline)) error {
+	return goex.EX_ERR_NOT_SUPPORTED
+}
+
+func (bm *Bitstamp) UnsubscribeTicker(pair goex.CurrencyPair) error {
+	return goex.EX_ERR_NOT_SUPPORTED
+}
+
+func (bm *Bitstamp) UnsubscribeKLine(pair goex.CurrencyPair, period int) error {
+	return goex.EX_ERR_NOT_SUPPORTED
 }
 
 func (bm *Bitstamp) parseDepth(dep string) *goex.Depth {
@@ -87,12 +165,12 @@ func (bm *Bitstamp) parseDepth(dep string) *goex.Depth {
 
 	for _, v := range bids {
 		bid := v.([]interface{})
-		depth.BidList = append(depth.BidList, goex.DepthRecord{goex.ToFloat64(bid[0]), goex.ToFloat64(bid[1])})
+		depth.BidList = append(depth.BidList, goex.DepthRecord{Price: goex.ToFloat64(bid[0]), Amount: goex.ToFloat64(bid[1])})
 	}
 
 	for _, v := range asks {
 		ask := v.([]interface{})
-		depth.AskList = append(depth.AskList, goex.DepthRecord{goex.ToFloat64(ask[0]), goex.ToFloat64(ask[1])})
+		depth.AskList = append(depth.AskList, goex.DepthRecord{Price: goex.ToFloat64(ask[0]), Amount: goex.ToFloat64(ask[1])})
 	}
 
 	sort.Sort(sort.Reverse(depth.AskList)) //reverse
@@ -100,11 +178,41 @@ func (bm *Bitstamp) parseDepth(dep string) *goex.Depth {
 	return &depth
 }
 
+//type 0是买, 1是卖; microtimestamp是微秒
+func (bm *Bitstamp) parseTrade(data string) (*goex.Trade, error) {
+	var t struct {
+		Id             int64  `json:"id"`
+		AmountStr      string `json:"amount_str"`
+		PriceStr       string `json:"price_str"`
+		Type           int    `json:"type"`
+		Timestamp      string `json:"timestamp"`
+		Microtimestamp string `json:"microtimestamp"`
+	}
+	err := json.Unmarshal([]byte(data), &t)
+	if err != nil {
+		return nil, err
+	}
+
+	trade := &goex.Trade{
+		Tid:    t.Id,
+		Type:   goex.BUY,
+		Amount: goex.ToFloat64(t.AmountStr),
+		Price:  goex.ToFloat64(t.PriceStr),
+		Date:   int64(goex.ToUint64(t.Timestamp)) * 1000}
+	if t.Type == 1 {
+		trade.Type = goex.SELL
+	}
+	if t.Microtimestamp != "" {
+		trade.Date = int64(goex.ToUint64(t.Microtimestamp)) / 1000
+	}
+	return trade, nil
+}
+
 func (bm *Bitstamp) getPairFromChannel(channel string) goex.CurrencyPair {
-	if channel == "order_book" {
+	if channel == "order_book" || channel == "live_trades" {
 		return goex.BTC_USD
 	}
 	metas := strings.Split(channel, "_")
-	pairstr := metas[2]
+	pairstr := metas[len(metas)-1]
 	return goex.NewCurrencyPair2(pairstr[0:3] + "_" + pairstr[3:])
 }
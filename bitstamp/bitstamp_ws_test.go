@@ -1,10 +1,14 @@
 package bitstamp
 
 import (
+	"github.com/gorilla/websocket"
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
 	"testing"
 	"time"
-	"log"
 )
 
 func TestBitstamp_GetDepthWithWs(t *testing.T) {
@@ -16,3 +20,36 @@ func TestBitstamp_GetDepthWithWs(t *testing.T) {
 	})
 	time.Sleep(1 * time.Minute)
 }
+
+func TestBitstamp_GetTradeWithWs_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	bm := NewBitstamp(http.DefaultClient, "", "", "")
+	bm.wsUrl = server.WsUrl()
+	trades := make(chan *goex.Trade, 1)
+	err := bm.GetTradeWithWs(goex.ETH_USD, func(trade *goex.Trade) {
+		trades <- trade
+	})
+	assert.Nil(t, err)
+	msg, err := server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"channel":"","event":"pusher:subscribe","data":{"channel":"live_trades_ethusd"}}`, string(msg))
+
+	//pusher的data是json字符串
+	assert.Nil(t, server.Send(websocket.TextMessage, []byte(`{"event":"trade","channel":"live_trades_ethusd","data":"{\"id\":12345,\"amount\":0.5,\"amount_str\":\"0.50000000\",\"price\":135.1,\"price_str\":\"135.10\",\"type\":1,\"timestamp\":\"1546300800\",\"microtimestamp\":\"1546300800123456\"}"}`)))
+	select {
+	case trade := <-trades:
+		assert.Equal(t, goex.Trade{Tid: 12345, Type: goex.SELL, Amount: 0.5, Price: 135.1, Date: 1546300800123, Pair: goex.ETH_USD}, *trade)
+	case <-time.After(time.Second):
+		t.Fatal("no trade received")
+	}
+
+	assert.Nil(t, bm.UnsubscribeTrade(goex.ETH_USD))
+	msg, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"channel":"","event":"pusher:unsubscribe","data":{"channel":"live_trades_ethusd"}}`, string(msg))
+
+	err = bm.GetTickerWithWs(goex.ETH_USD, func(ticker *goex.Ticker) {})
+	assert.True(t, goex.IsNotSupported(err))
+}
@@ -5,7 +5,6 @@ import (
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
 	"sort"
-	"errors"
 )
 
 type Bittrex struct {
@@ -15,49 +14,55 @@ type Bittrex struct {
 	secretkey string
 }
 
+func init() {
+	RegisterExchange(BITTREX, func(config *APIConfig) API {
+		bx := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		bx.baseUrl = OverrideUrl(bx.baseUrl, config.ApiUrl)
+		return bx
+	})
+}
+
 func New(client *http.Client, accesskey, secretkey string) *Bittrex {
 	return &Bittrex{client: client, accesskey: accesskey, secretkey: secretkey, baseUrl: "https://bittrex.com/api/v1.1"}
 }
 
 func (bx *Bittrex) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implement")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (bx *Bittrex) GetAccount() (*Account, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bx *Bittrex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	resp, err := HttpGet(bx.client, fmt.Sprintf("%s/public/getmarketsummary?market=%s", bx.baseUrl, currency.ToSymbol2("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, bx.adaptError(err, nil)
 	}
 
 	result, _ := resp["result"].([]interface{})
 	if len(result) <= 0 {
-		return nil, API_ERR
+		return nil, bx.adaptError(nil, resp)
 	}
 
 	tickermap := result[0].(map[string]interface{})
@@ -76,14 +81,12 @@ func (bx *Bittrex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 
 	resp, err := HttpGet(bx.client, fmt.Sprintf("%s/public/getorderbook?market=%s&type=both", bx.baseUrl, currency.ToSymbol2("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, bx.adaptError(err, nil)
 	}
 
 	result, err2 := resp["result"].(map[string]interface{})
 	if err2 != true {
-		return nil, errors.New(resp["message"].(string))
+		return nil, bx.adaptError(nil, resp)
 	}
 	bids, _ := result["buy"].([]interface{})
 	asks, _ := result["sell"].([]interface{})
@@ -106,14 +109,56 @@ func (bx *Bittrex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (bx *Bittrex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (bx *Bittrex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (bx *Bittrex) GetExchangeName() string {
 	return BITTREX
 }
+
+func (bx *Bittrex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
+
+//错误是 {"success":false,"message":"INVALID_MARKET","result":null}, http错误保留HttpError
+func (bx *Bittrex) adaptError(err error, resp map[string]interface{}) error {
+	if err != nil {
+		if _, ok := err.(HttpError); ok {
+			return err
+		}
+		errCode := HTTP_ERR_CODE
+		errCode.OriginErrMsg = err.Error()
+		return errCode
+	}
+
+	msg, _ := resp["message"].(string)
+	if msg == "" {
+		return API_ERR
+	}
+	apiErr := API_ERR
+	switch msg {
+	case "INVALID_MARKET":
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case "APIKEY_INVALID", "APIKEY_NOT_PROVIDED":
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case "INVALID_SIGNATURE":
+		apiErr = EX_ERR_SIGN
+	case "INSUFFICIENT_FUNDS":
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case "ORDER_NOT_OPEN", "UUID_INVALID", "INVALID_ORDER":
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case "MIN_TRADE_REQUIREMENT_NOT_MET":
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case "DUST_TRADE_DISALLOWED_MIN_VALUE_50K_SAT":
+		apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+	}
+	apiErr.OriginErrMsg = msg
+	return apiErr.OriginErr(msg)
+}
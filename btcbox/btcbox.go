@@ -3,6 +3,7 @@ package btcbox
 import (
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -13,50 +14,59 @@ var (
 type BtcBox struct {
 	client *http.Client
 	accessKey,
-	secretkey string
+	secretkey,
+	baseUrl string
+}
+
+func init() {
+	RegisterExchange("btcbox.co.jp", func(config *APIConfig) API {
+		btcbox := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		btcbox.baseUrl = OverrideUrl(btcbox.baseUrl, config.ApiUrl)
+		return btcbox
+	})
 }
 
 func New(client *http.Client, apikey, secretkey string) *BtcBox {
-	return &BtcBox{client: client, accessKey: apikey, secretkey: secretkey}
+	return &BtcBox{client: client, accessKey: apikey, secretkey: secretkey, baseUrl: baseurl}
 }
 
 func (btcbox *BtcBox) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (btcbox *BtcBox) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	respmap, err := HttpGet(btcbox.client, baseurl+"ticker?coin="+strings.ToLower(currency.CurrencyA.Symbol))
+	respmap, err := HttpGet(btcbox.client, btcbox.baseUrl+"ticker?coin="+strings.ToLower(currency.CurrencyA.Symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +81,7 @@ func (btcbox *BtcBox) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (btcbox *BtcBox) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	respmap, err := HttpGet(btcbox.client, baseurl+"depth?coin="+strings.ToLower(currency.CurrencyA.Symbol))
+	respmap, err := HttpGet(btcbox.client, btcbox.baseUrl+"depth?coin="+strings.ToLower(currency.CurrencyA.Symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -118,18 +128,27 @@ func (btcbox *BtcBox) GetDepth(size int, currency CurrencyPair) (*Depth, error)
 		}
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(dep.AskList))
+	sort.Sort(sort.Reverse(dep.BidList))
 	return dep, nil
 }
 
 func (btcbox *BtcBox) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (btcbox *BtcBox) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcbox *BtcBox) GetExchangeName() string {
 	return "btcbox.co.jp"
 }
+
+func (btcbox *BtcBox) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
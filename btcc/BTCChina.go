@@ -7,6 +7,7 @@ import (
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +17,8 @@ type BTCChina struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	marketUrl,
+	tradeUrl string
 }
 
 type ReqBody struct {
@@ -31,13 +34,22 @@ var (
 	_TRADE_API_V1_URL = "https://api.btcchina.com/api_trade_v1.php"
 )
 
+func init() {
+	RegisterExchange("btcchina.com", func(config *APIConfig) API {
+		btch := NewBTCChina(config.HttpClient, config.AccessKey, config.SecretKey)
+		btch.marketUrl = OverrideUrl(btch.marketUrl, config.ApiUrl)
+		btch.tradeUrl = OverrideUrl(btch.tradeUrl, config.ApiUrl)
+		return btch
+	})
+}
+
 func NewBTCChina(client *http.Client, accessKey, secretKey string) *BTCChina {
-	return &BTCChina{client, accessKey, secretKey}
+	return &BTCChina{client, accessKey, secretKey, _MARKET_API_URL, _TRADE_API_V1_URL}
 }
 
 func (btch *BTCChina) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	tickerResp, err := HttpGet(btch.httpClient, fmt.Sprintf("%s/ticker?market=%s",
-		_MARKET_API_URL, strings.ToLower(currency.ToSymbol(""))))
+		btch.marketUrl, strings.ToLower(currency.ToSymbol(""))))
 
 	if err != nil {
 		return nil, err
@@ -65,7 +77,7 @@ func (btch *BTCChina) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 func (btch *BTCChina) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	depthresp, err := HttpGet(btch.httpClient, fmt.Sprintf("%s/orderbook?market=%s&limit=%d",
-		_MARKET_API_URL, strings.ToLower(currency.ToSymbol("")), size))
+		btch.marketUrl, strings.ToLower(currency.ToSymbol("")), size))
 
 	if err != nil {
 		return nil, err
@@ -104,11 +116,14 @@ func (btch *BTCChina) GetDepth(size int, currency CurrencyPair) (*Depth, error)
 		depth.BidList = append(depth.BidList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return &depth, nil
 }
 
 func (btch *BTCChina) GetKlineRecords(currency CurrencyPair, period , size, since int) ([]Kline, error) {
-	panic("unimplement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btch *BTCChina) GetAccount() (*Account, error) {
@@ -182,11 +197,11 @@ func (btch *BTCChina) LimitSell(amount, price string, currency CurrencyPair) (*O
 }
 
 func (btch *BTCChina) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unimplement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btch *BTCChina) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unimplement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btch *BTCChina) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
@@ -261,18 +276,26 @@ func (btch *BTCChina) GetUnfinishOrders(currency CurrencyPair) ([]Order, error)
 }
 
 func (btch *BTCChina) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("unimplement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (btch *BTCChina) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("unimplement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btch *BTCChina) GetExchangeName() string {
 	return "btcchina.com"
 }
 
+func (btch *BTCChina) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (btch *BTCChina) GetBasicAuth(sign string) string {
 	authStr := btch.accessKey + ":" + sign
 	basicAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte(authStr))
@@ -285,7 +308,7 @@ func (btch *BTCChina) sendAuthorizationRequst(method string, params []interface{
 	println(string(reqJsonParams))
 
 	resp, err := HttpPostForm3(btch.httpClient,
-		_TRADE_API_V1_URL,
+		btch.tradeUrl,
 		string(reqJsonParams),
 		map[string]string{"Json-Rpc-Tonce": reqParams.Tonce,
 			"Authorization": btch.GetBasicAuth(reqParams.Sign)})
@@ -301,7 +324,7 @@ func (btch *BTCChina) sendAuthorizationRequst(method string, params []interface{
 	}
 
 	if respmap["error"] != nil {
-		return nil, errors.New(string(resp))
+		return nil, btch.adaptError(respmap["error"], resp)
 	}
 
 	return respmap, nil
@@ -350,3 +373,16 @@ func (btch *BTCChina) buildPostForm(method string, paramAr []interface{}) ReqBod
 
 	return reqbody
 }
+
+//json-rpc的错误 {"error":{"code":-32003,"message":"Insufficient CNY balance"},"id":"1"}
+func (btch *BTCChina) adaptError(errObj interface{}, resp []byte) error {
+	errmap, ok := errObj.(map[string]interface{})
+	if !ok {
+		return errors.New(string(resp))
+	}
+
+	//错误码没有确认过, 不映射到EX_ERR_*
+	apiErr := API_ERR
+	apiErr.OriginErrMsg = string(resp)
+	return apiErr.OriginErr(fmt.Sprint(errmap["message"]))
+}
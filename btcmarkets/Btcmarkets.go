@@ -1,7 +1,6 @@
 package btcmarkets
 
 import (
-	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
@@ -20,18 +19,32 @@ type Btcmarkets struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	baseUrl    string
+}
+
+func init() {
+	RegisterExchange(EXCHANGE_NAME, func(config *APIConfig) API {
+		btcm := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		btcm.baseUrl = OverrideUrl(btcm.baseUrl, config.ApiUrl)
+		return btcm
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey string) *Btcmarkets {
-	return &Btcmarkets{accessKey, secretKey, client}
+	return &Btcmarkets{accessKey, secretKey, client, API_BASE_URL}
 }
 
 func (btcm *Btcmarkets) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (btcm *Btcmarkets) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
 func (btcm *Btcmarkets) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	tickerUri := fmt.Sprintf(API_BASE_URL+TICKER_URI, currency.CurrencyA.String(), currency.CurrencyB.String())
+	tickerUri := fmt.Sprintf(btcm.baseUrl+TICKER_URI, currency.CurrencyA.String(), currency.CurrencyB.String())
 	//log.Println("tickerUrl:", tickerUri)
 	bodyDataMap, err := HttpGet(btcm.httpClient, tickerUri)
 	//log.Println("Btcmarkets bodyDataMap:", tickerUri, bodyDataMap)
@@ -44,7 +57,10 @@ func (btcm *Btcmarkets) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 	if result, isok := bodyDataMap["success"].(bool); isok == true && result != true {
 		//log.Println("bodyDataMap[\"success\"]", isok, result)
-		return nil, errors.New("err")
+		//{"success":false,"errorCode":3,"errorMessage":"Invalid argument."}
+		apiErr := API_ERR
+		apiErr.OriginErrMsg = fmt.Sprint(bodyDataMap["errorCode"])
+		return nil, apiErr.OriginErr(fmt.Sprint(bodyDataMap["errorMessage"]))
 	}
 
 	var tickerMap map[string]interface{} = bodyDataMap
@@ -69,49 +85,49 @@ func (btcm *Btcmarkets) GetTickerInBuf(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (btcm *Btcmarkets) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (btcm *Btcmarkets) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (btcm *Btcmarkets) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (btcm *Btcmarkets) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
@@ -0,0 +1,251 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/nntaoli-project/GoEx"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 配置文件中的一个账户
+ * 凭证可以直接写在Credentials中, 也可以引用环境变量(CredentialsEnv为变量前缀)或者凭证文件
+ */
+type AccountConfig struct {
+	Exchange        string           `json:"exchange" yaml:"exchange" toml:"exchange"`
+	Credentials     *Credentials     `json:"credentials" yaml:"credentials" toml:"credentials"`
+	CredentialsEnv  string           `json:"credentials_env" yaml:"credentials_env" toml:"credentials_env"`
+	CredentialsFile string           `json:"credentials_file" yaml:"credentials_file" toml:"credentials_file"`
+	CredentialsName string           `json:"credentials_name" yaml:"credentials_name" toml:"credentials_name"` //凭证文件中的名称, 为空时整个文件是一个凭证
+	Proxy           string           `json:"proxy" yaml:"proxy" toml:"proxy"`
+	Timeout         string           `json:"timeout" yaml:"timeout" toml:"timeout"` //比如10s, 见time.ParseDuration
+	BaseUrl         string           `json:"base_url" yaml:"base_url" toml:"base_url"`
+	RateLimit       *RateLimitConfig `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+}
+
+//没有配置时使用交易所默认的限频器
+type RateLimitConfig struct {
+	Disabled bool   `json:"disabled" yaml:"disabled" toml:"disabled"`
+	Limit    int    `json:"limit" yaml:"limit" toml:"limit"`
+	Interval string `json:"interval" yaml:"interval" toml:"interval"`
+	FailFast bool   `json:"fail_fast" yaml:"fail_fast" toml:"fail_fast"`
+}
+
+type AccountsConfig struct {
+	Accounts map[string]AccountConfig `json:"accounts" yaml:"accounts" toml:"accounts"`
+}
+
+var (
+	configDecodersLock sync.RWMutex
+	configDecoders     = map[string]func(data []byte, v interface{}) error{
+		".json": json.Unmarshal,
+		".yaml": yaml.Unmarshal,
+		".yml":  yaml.Unmarshal,
+		".toml": decodeToml,
+	}
+)
+
+/**
+ * 注册配置文件的解码器, 按扩展名选择, 内置json, yaml和toml
+ * 内置的toml解码器只支持账户配置用到的子集, 需要完整的toml时可以替换
+ * 比如 RegisterConfigDecoder(".toml", toml.Unmarshal) (github.com/BurntSushi/toml)
+ */
+func RegisterConfigDecoder(ext string, decode func(data []byte, v interface{}) error) {
+	configDecodersLock.Lock()
+	defer configDecodersLock.Unlock()
+	configDecoders[strings.ToLower(ext)] = decode
+}
+
+/**
+ * 按账户名称管理多个交易所账户, API在第一次使用时创建, 之后复用
+ * 按ip限频的交易所, 使用同一个代理的账户共用默认的限频器
+ */
+type AccountManager struct {
+	lock       sync.Mutex
+	accounts   map[string]AccountConfig
+	apis       map[string]API
+	futureApis map[string]FutureRestAPI
+	limiters   map[string]*RateLimiter //交易所和代理对应的默认限频器
+}
+
+//读取配置文件, 格式按扩展名选择
+func LoadAccountManager(path string) (*AccountManager, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configDecodersLock.RLock()
+	decode, ok := configDecoders[strings.ToLower(filepath.Ext(path))]
+	configDecodersLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("goex: no config decoder for %s, see RegisterConfigDecoder", path)
+	}
+
+	var config AccountsConfig
+	err = decode(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("goex: parse config %s: %w", path, err)
+	}
+	return NewAccountManager(config.Accounts)
+}
+
+func NewAccountManager(accounts map[string]AccountConfig) (*AccountManager, error) {
+	for name, account := range accounts {
+		if account.Exchange == "" {
+			return nil, fmt.Errorf("goex: account %s exchange is empty", name)
+		}
+		if _, err := parseDuration(account.Timeout); err != nil {
+			return nil, fmt.Errorf("goex: account %s timeout: %w", name, err)
+		}
+		if rl := account.RateLimit; rl != nil {
+			interval, err := parseDuration(rl.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("goex: account %s rate limit interval: %w", name, err)
+			}
+			//共用的限频器不能按账户设置fail_fast
+			if rl.FailFast && !rl.Disabled && !(rl.Limit > 0 && interval > 0) &&
+				!perKeyRateLimits[account.Exchange] && newDefaultRateLimiter(account.Exchange) != nil {
+				return nil, fmt.Errorf("goex: account %s fail_fast needs its own limit and interval, %s default rate limit is shared", name, account.Exchange)
+			}
+		}
+	}
+	return &AccountManager{
+		accounts:   accounts,
+		apis:       make(map[string]API),
+		futureApis: make(map[string]FutureRestAPI),
+		limiters:   make(map[string]*RateLimiter)}, nil
+}
+
+//已配置的账户名称, 按名称排序
+func (m *AccountManager) Accounts() []string {
+	names := make([]string, 0, len(m.accounts))
+	for name := range m.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *AccountManager) Account(name string) (AccountConfig, bool) {
+	account, ok := m.accounts[name]
+	return account, ok
+}
+
+func (m *AccountManager) API(name string) (API, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if api, ok := m.apis[name]; ok {
+		return api, nil
+	}
+
+	builder, account, err := m.builder(name)
+	if err != nil {
+		return nil, err
+	}
+	api, err := builder.Build(account.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	m.apis[name] = api
+	return api, nil
+}
+
+func (m *AccountManager) FutureAPI(name string) (FutureRestAPI, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if api, ok := m.futureApis[name]; ok {
+		return api, nil
+	}
+
+	builder, account, err := m.builder(name)
+	if err != nil {
+		return nil, err
+	}
+	api, err := builder.BuildFuture(account.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	m.futureApis[name] = api
+	return api, nil
+}
+
+func (m *AccountManager) builder(name string) (*APIBuilder, AccountConfig, error) {
+	account, ok := m.accounts[name]
+	if !ok {
+		return nil, account, fmt.Errorf("goex: unknown account %s", name)
+	}
+
+	//每个账户使用自己的builder, 代理设置互不影响
+	builder := NewAPIBuilder()
+
+	switch {
+	case account.Credentials != nil:
+		builder.Credentials(*account.Credentials)
+	case account.CredentialsEnv != "":
+		builder.CredentialsProvider(NewEnvCredentialsProvider(account.CredentialsEnv))
+	case account.CredentialsFile != "":
+		builder.CredentialsProvider(NewFileCredentialsProvider(account.CredentialsFile, account.CredentialsName))
+	}
+
+	if account.Proxy != "" {
+		builder.HttpProxy(account.Proxy)
+	}
+	if timeout, _ := parseDuration(account.Timeout); timeout > 0 {
+		builder.HttpTimeout(timeout)
+	}
+	if account.BaseUrl != "" {
+		builder.ApiUrl(account.BaseUrl)
+	}
+
+	rl := account.RateLimit
+	if rl == nil {
+		rl = &RateLimitConfig{}
+	}
+	interval, _ := parseDuration(rl.Interval)
+	switch {
+	case rl.Disabled:
+		builder.RateLimiter(account.Exchange, nil)
+	case rl.Limit > 0 && interval > 0:
+		limiter := NewRateLimiter(rl.Limit, interval)
+		if rl.FailFast {
+			limiter.Policy = RATE_LIMIT_FAIL_FAST
+		}
+		builder.RateLimiter(account.Exchange, limiter)
+	case perKeyRateLimits[account.Exchange]:
+		//按api key限频, 每个账户使用builder创建的默认限频器
+		if rl.FailFast {
+			builder.RateLimitPolicy(RATE_LIMIT_FAIL_FAST)
+		}
+	default:
+		if limiter := m.sharedLimiter(account.Exchange, account.Proxy); limiter != nil {
+			builder.RateLimiter(account.Exchange, limiter)
+		}
+	}
+
+	return builder, account, nil
+}
+
+//调用方持有m.lock
+func (m *AccountManager) sharedLimiter(exName, proxy string) *RateLimiter {
+	key := exName + " " + proxy
+	limiter, ok := m.limiters[key]
+	if !ok {
+		limiter = newDefaultRateLimiter(exName)
+		m.limiters[key] = limiter
+	}
+	return limiter
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,165 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nntaoli-project/GoEx"
+	"github.com/stretchr/testify/assert"
+)
+
+const accountsYaml = `
+accounts:
+  okex-main:
+    exchange: okex.com
+    credentials:
+      access_key: key
+      secret_key: secret
+    timeout: 10s
+    rate_limit:
+      limit: 20
+      interval: 2s
+  okex-future:
+    exchange: OKEX_FUTURE
+    credentials_env: GOEX_TEST_NONE
+`
+
+func TestLoadAccountManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "accounts.yaml")
+	ioutil.WriteFile(path, []byte(accountsYaml), 0600)
+	m, err := LoadAccountManager(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"okex-future", "okex-main"}, m.Accounts())
+	account, _ := m.Account("okex-main")
+	if assert.NotNil(t, account.Credentials) {
+		assert.Equal(t, goex.Credentials{AccessKey: "key", SecretKey: "secret"}, *account.Credentials)
+	}
+
+	api, err := m.API("okex-main")
+	assert.Nil(t, err)
+	assert.Equal(t, goex.OKEX, api.GetExchangeName())
+	api2, _ := m.API("okex-main")
+	assert.True(t, api == api2)
+
+	_, err = m.FutureAPI("okex-future")
+	assert.Error(t, err) //环境变量没有设置
+
+	_, err = m.API("unknown")
+	assert.Error(t, err)
+
+	ioutil.WriteFile(filepath.Join(dir, "accounts.ini"), []byte(""), 0600)
+	_, err = LoadAccountManager(filepath.Join(dir, "accounts.ini"))
+	assert.Error(t, err)
+}
+
+const accountsToml = `
+# 交易所账户
+[accounts.binance-main]
+exchange = "binance.com"
+proxy = 'socks5://127.0.0.1:1080' # 代理
+credentials.access_key = "key"
+credentials.secret_key = "sec\"ret"
+
+[accounts."huobi main".credentials]
+access_key = "key2"
+account_id = "123"
+
+[accounts."huobi main"]
+exchange = "huobi.pro"
+timeout = "10s"
+
+[accounts."huobi main".rate_limit]
+limit = 1_000
+interval = "1m"
+fail_fast = true
+`
+
+func TestLoadAccountManager_Toml(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "accounts.toml")
+	ioutil.WriteFile(path, []byte(accountsToml), 0600)
+	m, err := LoadAccountManager(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"binance-main", "huobi main"}, m.Accounts())
+
+	account, _ := m.Account("binance-main")
+	assert.Equal(t, "socks5://127.0.0.1:1080", account.Proxy)
+	if assert.NotNil(t, account.Credentials) {
+		assert.Equal(t, goex.Credentials{AccessKey: "key", SecretKey: `sec"ret`}, *account.Credentials)
+	}
+	account, _ = m.Account("huobi main")
+	assert.Equal(t, "10s", account.Timeout)
+	assert.Equal(t, &RateLimitConfig{Limit: 1000, Interval: "1m", FailFast: true}, account.RateLimit)
+	if assert.NotNil(t, account.Credentials) {
+		assert.Equal(t, goex.Credentials{AccessKey: "key2", AccountId: "123"}, *account.Credentials)
+	}
+
+	for _, bad := range []string{"a = [1, 2]", "a = 1\na = 2", "[a]\nb = 1\n[a.b]", "a = \"x", "a = x", "[[a]]"} {
+		var config AccountsConfig
+		assert.Error(t, decodeToml([]byte(bad), &config), bad)
+	}
+}
+
+func TestAccountManager_RateLimiter(t *testing.T) {
+	m, err := NewAccountManager(map[string]AccountConfig{
+		"binance-1":     {Exchange: goex.BINANCE},
+		"binance-2":     {Exchange: goex.BINANCE},
+		"binance-proxy": {Exchange: goex.BINANCE, Proxy: "socks5://127.0.0.1:1080"},
+		"huobi-1":       {Exchange: goex.HUOBI_PRO},
+		"huobi-2":       {Exchange: goex.HUOBI_PRO, RateLimit: &RateLimitConfig{FailFast: true}}})
+	assert.Nil(t, err)
+
+	limiter := func(name string) *goex.RateLimiter {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		builder, account, err := m.builder(name)
+		assert.Nil(t, err)
+		client, err := builder.httpClient(account.Exchange)
+		assert.Nil(t, err)
+		return client.Transport.(*goex.RateLimitTransport).Limiter
+	}
+
+	//按ip限频的交易所共用限频器, 不同代理的出口ip不同
+	assert.True(t, limiter("binance-1") == limiter("binance-2"))
+	assert.False(t, limiter("binance-1") == limiter("binance-proxy"))
+
+	//按api key限频的交易所每个账户单独限频
+	assert.False(t, limiter("huobi-1") == limiter("huobi-2"))
+	assert.Equal(t, goex.RATE_LIMIT_BLOCK, limiter("huobi-1").Policy)
+	assert.Equal(t, goex.RATE_LIMIT_FAIL_FAST, limiter("huobi-2").Policy)
+
+	_, err = NewAccountManager(map[string]AccountConfig{"a": {Exchange: goex.BINANCE, RateLimit: &RateLimitConfig{FailFast: true}}})
+	assert.Error(t, err)
+}
+
+func TestLoadAccountManager_Json(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "accounts.json")
+	ioutil.WriteFile(path, []byte(`{"accounts": {"bitstamp": {"exchange": "bitstamp.net", "credentials": {"access_key": "key", "secret_key": "secret", "client_id": "id"}}}}`), 0600)
+	m, err := LoadAccountManager(path)
+	assert.Nil(t, err)
+	account, _ := m.Account("bitstamp")
+	if assert.NotNil(t, account.Credentials) {
+		assert.Equal(t, goex.Credentials{AccessKey: "key", SecretKey: "secret", ClientId: "id"}, *account.Credentials)
+	}
+}
+
+func TestNewAccountManager(t *testing.T) {
+	_, err := NewAccountManager(map[string]AccountConfig{"a": {Exchange: goex.BINANCE, Timeout: "10"}})
+	assert.Error(t, err)
+
+	_, err = NewAccountManager(map[string]AccountConfig{"a": {}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"errors"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//连接池设置, 为0的项使用默认值
+type ConnPool struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+func newHttpTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     4 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+//socks5代理, 不需要认证时username为空
+func (builder *APIBuilder) Socks5Proxy(addr, username, password string) (_builder *APIBuilder) {
+	proxy := &url.URL{Scheme: "socks5", Host: addr}
+	if username != "" {
+		proxy.User = url.UserPassword(username, password)
+	}
+	return builder.HttpProxy(proxy.String())
+}
+
+/**
+ * 交易所的连接池大小, 设置后该交易所使用单独的transport
+ * exName为空时设置所有交易所默认的连接池, 只对之后Build的交易所生效
+ */
+func (builder *APIBuilder) ConnPool(exName string, pool ConnPool) (_builder *APIBuilder) {
+	if builder.httpTransport() == nil {
+		return builder
+	}
+	if exName == "" {
+		builder.updateTransports(pool.apply)
+		return builder
+	}
+	builder.lock.Lock()
+	defer builder.lock.Unlock()
+	if builder.connPools == nil {
+		builder.connPools = make(map[string]ConnPool)
+	}
+	builder.connPools[exName] = pool
+	//已经Build的交易所继续使用原来的transport, 之后Build时重新创建
+	delete(builder.exTransports, exName)
+	return builder
+}
+
+/**
+ * http2连接的保活, readIdleTimeout内没有收到数据时发送ping, pingTimeout内没有响应时关闭连接
+ * 避免长时间空闲的连接被中间设备断开后, 下一个请求一直等到超时
+ * 只对之后Build的交易所生效
+ */
+func (builder *APIBuilder) Http2KeepAlive(readIdleTimeout, pingTimeout time.Duration) (_builder *APIBuilder) {
+	if builder.httpTransport() == nil {
+		return builder
+	}
+	builder.h2ReadIdleTimeout = readIdleTimeout
+	builder.h2PingTimeout = pingTimeout
+	return builder
+}
+
+//调用方client的transport能否复制, 不能复制时不能设置代理和连接池
+func (builder *APIBuilder) transportCloneable() bool {
+	if builder.transport != nil {
+		return true
+	}
+	switch builder.client.Transport.(type) {
+	case nil, *http.Transport:
+		return true
+	}
+	return false
+}
+
+//builder自己的transport, 第一次修改时复制调用方client的transport
+func (builder *APIBuilder) httpTransport() *http.Transport {
+	if builder.transport != nil {
+		return builder.transport
+	}
+	if !builder.transportCloneable() {
+		builder.err = errors.New("goex: builder http client transport is not *http.Transport, can not set proxy or connection pool")
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t, ok := builder.client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+
+	client := *builder.client
+	client.Transport = transport
+	builder.client = &client
+	builder.transport = transport
+	return transport
+}
+
+/**
+ * 复制builder的transport修改后替换, 不修改已经Build的交易所正在使用的transport
+ * 交易所单独的transport在之后Build时从新的transport重新创建
+ */
+func (builder *APIBuilder) updateTransports(update func(transport *http.Transport)) {
+	if builder.httpTransport() == nil {
+		return
+	}
+	builder.lock.Lock()
+	defer builder.lock.Unlock()
+	transport := builder.transport.Clone()
+	update(transport)
+	client := *builder.client
+	client.Transport = transport
+	builder.client = &client
+	builder.transport = transport
+	builder.exTransports = nil
+}
+
+//交易所使用的transport, 没有单独设置时共用builder的transport
+func (builder *APIBuilder) exchangeTransport(exName string) (http.RoundTripper, error) {
+	if transport, ok := builder.exTransports[exName]; ok {
+		return transport, nil
+	}
+
+	pool, ok := builder.connPools[exName]
+	if !ok && builder.h2ReadIdleTimeout <= 0 {
+		return builder.client.Transport, nil
+	}
+
+	transport := builder.transport.Clone()
+	if ok {
+		pool.apply(transport)
+	}
+	if builder.h2ReadIdleTimeout > 0 {
+		h2, err := http2.ConfigureTransports(transport)
+		if err != nil {
+			return nil, err
+		}
+		h2.ReadIdleTimeout = builder.h2ReadIdleTimeout
+		h2.PingTimeout = builder.h2PingTimeout
+	}
+
+	if builder.exTransports == nil {
+		builder.exTransports = make(map[string]*http.Transport)
+	}
+	builder.exTransports[exName] = transport
+	return transport, nil
+}
+
+func (pool ConnPool) apply(transport *http.Transport) {
+	if pool.MaxIdleConns > 0 {
+		transport.MaxIdleConns = pool.MaxIdleConns
+	}
+	if pool.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	if pool.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	if pool.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = pool.IdleConnTimeout
+	}
+}
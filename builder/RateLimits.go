@@ -0,0 +1,31 @@
+package builder
+
+import (
+	. "github.com/nntaoli-project/GoEx"
+	"time"
+)
+
+//按api key限频的交易所, AccountManager中每个账户使用单独的默认限频器, 其它交易所按ip限频
+var perKeyRateLimits = map[string]bool{
+	HUOBI_PRO: true,
+}
+
+//交易所公布的限频规则, 没有的交易所返回nil
+func newDefaultRateLimiter(exName string) *RateLimiter {
+	switch exName {
+	case BINANCE:
+		//https://github.com/binance-exchange/binance-official-api-docs/blob/master/rest-api.md#limits
+		limiter := NewRateLimiter(1200, time.Minute).
+			Weight("GET", "/api/v3/account", 5).
+			Weight("GET", "/api/v3/myTrades", 5).
+			Weight("GET", "/api/v3/allOrders", 5).
+			AddLimit("POST", "/api/v3/order", 10, time.Second).
+			AddLimit("POST", "/api/v3/order", 100000, 24*time.Hour)
+		limiter.UsedWeightHeader = "X-MBX-USED-WEIGHT"
+		return limiter
+	case HUOBI_PRO:
+		//每个api key 10秒100次
+		return NewRateLimiter(100, 10*time.Second)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package builder
+
+import (
+	. "github.com/nntaoli-project/GoEx"
+)
+
+type Endpoint struct {
+	ApiUrl string
+	WsUrl  string
+}
+
+//交易所的测试环境, 见APIBuilder.Sandbox
+var SANDBOX_ENDPOINTS = map[string]Endpoint{
+	BITMEX:  {ApiUrl: "https://testnet.bitmex.com", WsUrl: "wss://testnet.bitmex.com"},
+	BINANCE: {ApiUrl: "https://testnet.binance.vision", WsUrl: "wss://testnet.binance.vision"}, //测试网只有/api/v3接口
+	GDAX:    {ApiUrl: "https://api-public.sandbox.pro.coinbase.com", WsUrl: "wss://ws-feed-public.sandbox.pro.coinbase.com"},
+}
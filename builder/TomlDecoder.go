@@ -0,0 +1,216 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+ * 账户配置用到的toml子集: 注释, [table]和[a."b c"]表头, 带点的key, 字符串, 整数, 浮点数, 布尔值
+ * 不支持数组, 内联表, 多行字符串和日期, 遇到时返回错误
+ * 先解析成map, 再按json tag解码到v, 配置结构体的json和toml tag相同
+ */
+func decodeToml(data []byte, v interface{}) error {
+	root := make(map[string]interface{})
+	table := root
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if strings.HasPrefix(line, "[[") || end < 0 || !isTomlComment(line[end+1:]) {
+				return fmt.Errorf("toml line %d: unsupported table header", lineNo)
+			}
+			keys, err := splitTomlKey(line[1:end])
+			if err != nil {
+				return fmt.Errorf("toml line %d: %w", lineNo, err)
+			}
+			table, err = tomlTable(root, keys)
+			if err != nil {
+				return fmt.Errorf("toml line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		eq := tomlKeyEnd(line)
+		if eq < 0 {
+			return fmt.Errorf("toml line %d: expected key = value", lineNo)
+		}
+		keys, err := splitTomlKey(line[:eq])
+		if err != nil {
+			return fmt.Errorf("toml line %d: %w", lineNo, err)
+		}
+		value, err := parseTomlValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return fmt.Errorf("toml line %d: %w", lineNo, err)
+		}
+		parent, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return fmt.Errorf("toml line %d: %w", lineNo, err)
+		}
+		key := keys[len(keys)-1]
+		if _, ok := parent[key]; ok {
+			return fmt.Errorf("toml line %d: duplicate key %s", lineNo, key)
+		}
+		parent[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+//按路径找到或者创建子表
+func tomlTable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch sub := table[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			table[key] = next
+			table = next
+		case map[string]interface{}:
+			table = sub
+		default:
+			return nil, fmt.Errorf("key %s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+//key和value之间的等号位置, 跳过引号中的等号
+func tomlKeyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+//a.b."c.d" 拆成 [a b c.d]
+func splitTomlKey(s string) ([]string, error) {
+	var keys []string
+	s = strings.TrimSpace(s)
+	for {
+		var key string
+		switch {
+		case strings.HasPrefix(s, `"`), strings.HasPrefix(s, "'"):
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated key %s", s)
+			}
+			key, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			key, s = strings.TrimSpace(s[:end]), s[end:]
+			if !isTomlBareKey(key) {
+				return nil, fmt.Errorf("invalid key %q", key)
+			}
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return keys, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key near %q", s)
+		}
+		s = strings.TrimSpace(s[1:])
+	}
+}
+
+func isTomlBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func isTomlComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+func parseTomlValue(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		return nil, fmt.Errorf("multi-line string is not supported")
+	case s[0] == '"':
+		//找到没有被转义的结束引号
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				if !isTomlComment(s[i+1:]) {
+					return nil, fmt.Errorf("unexpected %q after string", s[i+1:])
+				}
+				return strconv.Unquote(s[:i+1])
+			}
+		}
+		return nil, fmt.Errorf("unterminated string %s", s)
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		if !isTomlComment(s[end+2:]) {
+			return nil, fmt.Errorf("unexpected %q after string", s[end+2:])
+		}
+		return s[1 : end+1], nil
+	case s[0] == '[' || s[0] == '{':
+		return nil, fmt.Errorf("array and inline table are not supported")
+	}
+
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	num := strings.Replace(s, "_", "", -1)
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", s)
+}
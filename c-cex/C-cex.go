@@ -20,20 +20,34 @@ type C_cex struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	baseUrl    string
+}
+
+func init() {
+	RegisterExchange(EXCHANGE_NAME, func(config *APIConfig) API {
+		ccex := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		ccex.baseUrl = OverrideUrl(ccex.baseUrl, config.ApiUrl)
+		return ccex
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey string) *C_cex {
-	return &C_cex{accessKey, secretKey, client}
+	return &C_cex{accessKey, secretKey, client, API_BASE_URL}
 }
 
 func (ccex *C_cex) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (ccex *C_cex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
 func (ccex *C_cex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	currency = ccex.adaptCurrencyPair(currency)
 
-	tickerUri := API_BASE_URL + TICKER_URI + strings.ToLower(currency.ToSymbol("-")) + ".json"
+	tickerUri := ccex.baseUrl + TICKER_URI + strings.ToLower(currency.ToSymbol("-")) + ".json"
 	//log.Println("tickerUrl:", tickerUri)
 	bodyDataMap, err := HttpGet(ccex.httpClient, tickerUri)
 	//log.Println("C_cex bodyDataMap:", tickerUri, bodyDataMap)
@@ -65,7 +79,7 @@ func (ccex *C_cex) GetTickerInBuf(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (ccex *C_cex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (ccex *C_cex) adaptCurrencyPair(pair CurrencyPair) CurrencyPair {
 	var currencyA Currency
@@ -87,45 +101,45 @@ func (ccex *C_cex) adaptCurrencyPair(pair CurrencyPair) CurrencyPair {
 }
 
 func (ccex *C_cex) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (ccex *C_cex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ccex *C_cex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (ccex *C_cex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
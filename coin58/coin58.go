@@ -20,6 +20,14 @@ type Coin58 struct {
 }
 
 //58coin.com  closed the trade api
+func init() {
+	RegisterExchange(COIN58, func(config *APIConfig) API {
+		coin58 := New58Coin(config.HttpClient, config.AccessKey, config.SecretKey)
+		coin58.apiurl = OverrideUrl(coin58.apiurl, config.ApiUrl)
+		return coin58
+	})
+}
+
 func New58Coin(client *http.Client, apikey string, apisecretkey string) *Coin58 {
 	return &Coin58{client: client, apikey: apikey, apisecretkey: apisecretkey, apiurl: "https://api.58coin.com/v1/"}
 }
@@ -166,7 +174,7 @@ func (coin58 *Coin58) GetUnfinishOrders(currency CurrencyPair) ([]Order, error)
 }
 
 func (coin58 *Coin58) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (coin58 *Coin58) GetAccount() (*Account, error) {
@@ -259,18 +267,26 @@ func (coin58 *Coin58) GetDepth(size int, currency CurrencyPair) (*Depth, error)
 }
 
 func (coin58 *Coin58) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (coin58 *Coin58) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (coin58 *Coin58) GetExchangeName() string {
 	return COIN58
 }
 
+func (coin58 *Coin58) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (coin58 *Coin58) doAuthenticatedRequest(api string, params url.Values) (interface{}, error) {
 	url := coin58.apiurl + api
 
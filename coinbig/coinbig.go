@@ -25,16 +25,33 @@ type CoinBig struct {
 	accessKey,
 	secretKey string
 	timeoffset int64
+	baseUrl    string
+}
+
+func init() {
+	RegisterExchange("coinbig.com", func(config *APIConfig) API {
+		cb := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		cb.baseUrl = OverrideUrl(cb.baseUrl, config.ApiUrl)
+		return cb
+	})
 }
 
 func New(client *http.Client, api_key, secret_key string) *CoinBig {
-	return &CoinBig{accessKey: api_key, secretKey: secret_key, httpClient: client}
+	return &CoinBig{accessKey: api_key, secretKey: secret_key, httpClient: client, baseUrl: API_BASE_URL}
 }
 
 func (cb *CoinBig) GetExchangeName() string {
 	return "coinbig.com"
 }
 
+func (cb *CoinBig) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (cb *CoinBig) buildSigned(params *url.Values) string {
 	s, _ := GetParamMD5Sign(cb.secretKey, encodeWithoutEscape(*params)+fmt.Sprintf("&secret_key=%s", cb.secretKey))
 	params.Set("sign", strings.ToUpper(s))
@@ -43,7 +60,7 @@ func (cb *CoinBig) buildSigned(params *url.Values) string {
 }
 
 func (cb *CoinBig) GetAccount() (*Account, error) {
-	api_url := API_BASE_URL + "/api/publics/v1/userinfo"
+	api_url := cb.baseUrl + "/api/publics/v1/userinfo"
 
 	params := url.Values{}
 	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
@@ -61,9 +78,9 @@ func (cb *CoinBig) GetAccount() (*Account, error) {
 		// log.Println("respData", string(body))
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	balances, isok := bodyDataMap["data"].(map[string]interface{})
@@ -102,7 +119,7 @@ func (cb *CoinBig) GetAccount() (*Account, error) {
 }
 
 func (cb *CoinBig) placeOrder(amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
-	api_url := API_BASE_URL + "/api/publics/v1/trade"
+	api_url := cb.baseUrl + "/api/publics/v1/trade"
 
 	params := url.Values{}
 	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
@@ -137,9 +154,9 @@ func (cb *CoinBig) placeOrder(amount, price string, pair CurrencyPair, orderType
 		// log.Println("respData", string(body))
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	data, isok := bodyDataMap["data"].(map[string]interface{})
@@ -183,7 +200,7 @@ func (cb *CoinBig) MarketSell(amount, price string, currencyPair CurrencyPair) (
 }
 
 func (cb *CoinBig) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
-	path := API_BASE_URL + "/api/publics/v1/cancel_order"
+	path := cb.baseUrl + "/api/publics/v1/cancel_order"
 	params := url.Values{}
 
 	params.Set("apikey", cb.accessKey)
@@ -205,15 +222,15 @@ func (cb *CoinBig) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 		// log.Println("respData", string(resp))
 		return false, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, cb.adaptError(bodyDataMap)
 	}
 	return true, nil
 }
 
 func (cb *CoinBig) CancelOrders(orderId []string) (bool, error) {
-	path := API_BASE_URL + "/order/batchCancel"
+	path := cb.baseUrl + "/order/batchCancel"
 	params := url.Values{}
 
 	orders := strings.Join(orderId, ",")
@@ -236,14 +253,14 @@ func (cb *CoinBig) CancelOrders(orderId []string) (bool, error) {
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, cb.adaptError(bodyDataMap)
 	}
 
 	return true, nil
 }
 
 func (cb *CoinBig) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order, error) {
-	path := API_BASE_URL + "/api/publics/v1/order_info"
+	path := cb.baseUrl + "/api/publics/v1/order_info"
 	params := url.Values{}
 
 	params.Set("apikey", cb.accessKey)
@@ -268,9 +285,9 @@ func (cb *CoinBig) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 		// log.Println("respData", string(resp))
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	data, _ := bodyDataMap["data"].(map[string]interface{})
@@ -292,7 +309,7 @@ func (cb *CoinBig) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	}, nil
 }
 func (cb *CoinBig) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
-	path := API_BASE_URL + "/api/publics/v1/orders_info"
+	path := cb.baseUrl + "/api/publics/v1/orders_info"
 	params := url.Values{}
 
 	params.Set("apikey", cb.accessKey)
@@ -316,9 +333,9 @@ func (cb *CoinBig) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 		// log.Println("respData", string(resp))
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	data, _ := bodyDataMap["data"].(map[string]interface{})
@@ -345,10 +362,10 @@ func (cb *CoinBig) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	return orders, nil
 }
 func (cb *CoinBig) GetOrderHistorys(currencyPair CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (cb *CoinBig) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error) {
-	path := API_BASE_URL + "/api/publics/v1/depth"
+	path := cb.baseUrl + "/api/publics/v1/depth"
 	path += fmt.Sprintf("?size=%d&symbol=%s", size, currencyPair.String())
 	bodyDataMap, err := HttpGet(cb.httpClient, path)
 
@@ -356,9 +373,9 @@ func (cb *CoinBig) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	if err != nil {
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	data := bodyDataMap["data"].(map[string]interface{})
@@ -382,16 +399,18 @@ func (cb *CoinBig) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 		dr := DepthRecord{Amount: amount, Price: price}
 		depth.AskList = append(depth.AskList, dr)
 	}
-	//sort.Sort(depth.AskList)
-	//sort.Sort(sort.Reverse(depth.BidList))
+	//先按价格从低到高取最优的size档, 再倒序成AskList最后一个是卖一
+	sort.Sort(depth.AskList)
+	sort.Sort(sort.Reverse(depth.BidList))
 	depth.AskList = depth.AskList[0:size]
 	depth.BidList = depth.BidList[0:size]
+	sort.Sort(sort.Reverse(depth.AskList))
 
 	return depth, nil
 }
 
 func (cb *CoinBig) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
-	path := API_BASE_URL + "/api/publics/v1/ticker"
+	path := cb.baseUrl + "/api/publics/v1/ticker"
 	path += fmt.Sprintf("?symbol=%s", currencyPair.String())
 	bodyDataMap, err := HttpGet(cb.httpClient, path)
 
@@ -399,16 +418,16 @@ func (cb *CoinBig) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	if err != nil {
 		return nil, err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, cb.adaptError(bodyDataMap)
 	}
 
 	data := bodyDataMap["data"].(map[string]interface{})
 	tickerMap := data["ticker"].(map[string]interface{})
 	var ticker Ticker
 
-	ticker.Date = ToUint64(data["date"])
+	ticker.Date = ToUnixSecond(ToUint64(data["date"]))
 	ticker.Last = ToFloat64(tickerMap["last"])
 	ticker.Buy = ToFloat64(tickerMap["buy"])
 	ticker.Sell = ToFloat64(tickerMap["sell"])
@@ -420,16 +439,16 @@ func (cb *CoinBig) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 }
 
 func (cb *CoinBig) GetServerSync() error {
-	path := API_BASE_URL + "/api/publics/v1/getClientIpAndServerTime"
+	path := cb.baseUrl + "/api/publics/v1/getClientIpAndServerTime"
 	bodyDataMap, err := HttpGet(cb.httpClient, path)
 
 	log.Println("GetServerSync resp:", bodyDataMap, "err:", err)
 	if err != nil {
 		return err
 	}
-	if bodyDataMap["code"].(float64) != 0 {
+	if ToInt(bodyDataMap["code"]) != 0 {
 		// log.Println("respData", string(body))
-		return errors.New(bodyDataMap["msg"].(string))
+		return cb.adaptError(bodyDataMap)
 	}
 	data, _ := bodyDataMap["data"].(map[string]interface{})
 
@@ -445,12 +464,12 @@ func (cb *CoinBig) GetServerSync() error {
 }
 
 func (cb *CoinBig) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (cb *CoinBig) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func orderTypeAdapter(t1, t2 int) TradeSide {
@@ -514,3 +533,25 @@ func encodeWithoutEscape(v url.Values) string {
 	}
 	return buf.String()
 }
+
+//大部分接口用code表示错误, 撤单接口是status, 原因在msg中
+func (cb *CoinBig) adaptError(bodyDataMap map[string]interface{}) ApiError {
+	code := bodyDataMap["code"]
+	if code == nil {
+		code = bodyDataMap["status"]
+	}
+	msg := fmt.Sprint(bodyDataMap["msg"])
+	lower := strings.ToLower(msg)
+
+	apiErr := API_ERR
+	switch {
+	case strings.Contains(lower, "insufficient"), strings.Contains(msg, "余额不足"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(lower, "order does not exist"), strings.Contains(msg, "订单不存在"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(lower, "sign error"), strings.Contains(msg, "签名错误"):
+		apiErr = EX_ERR_SIGN
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(code)
+	return apiErr.OriginErr(msg)
+}
@@ -17,6 +17,14 @@ type Coincheck struct {
 	secretKey string
 }
 
+func init() {
+	RegisterExchange("coincheck.com", func(config *APIConfig) API {
+		cc := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		cc.baseUrl = OverrideUrl(cc.baseUrl, config.ApiUrl)
+		return cc
+	})
+}
+
 func New(httpClient *http.Client, accessKey, secretKey string) (coinCheck *Coincheck) {
 	cc := new(Coincheck)
 	cc.client = httpClient
@@ -30,6 +38,12 @@ func (cc *Coincheck) GetExchangeName() string {
 	return "coincheck.com"
 }
 
+func (cc *Coincheck) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
+
 func (cc *Coincheck) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	tickerUrl := fmt.Sprintf(cc.baseUrl + "api/ticker")
 
@@ -109,46 +123,46 @@ func (cc *Coincheck) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 
 
 func (cc *Coincheck) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	return false, nil
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) GetAccount() (*Account, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cc *Coincheck) GetKlineRecords(currency CurrencyPair, period , size, since int) ([]Kline, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (cc *Coincheck) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
\ No newline at end of file
@@ -18,21 +18,38 @@ import (
 type CoinEx struct {
 	httpClient *http.Client
 	accessKey,
-	secretKey string
+	secretKey,
+	baseUrl string
 }
 
 var (
 	baseurl = "https://api.coinex.com/v1/"
 )
 
+func init() {
+	RegisterExchange(COINEX, func(config *APIConfig) API {
+		coinex := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		coinex.baseUrl = OverrideUrl(coinex.baseUrl, config.ApiUrl)
+		return coinex
+	})
+}
+
 func New(client *http.Client, accessKey, secretKey string) *CoinEx {
-	return &CoinEx{client, accessKey, secretKey}
+	return &CoinEx{client, accessKey, secretKey, baseurl}
 }
 
 func (coinex *CoinEx) GetExchangeName() string {
 	return COINEX
 }
 
+func (coinex *CoinEx) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (coinex *CoinEx) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	params := url.Values{}
 	params.Set("market", currency.ToSymbol(""))
@@ -112,10 +129,10 @@ func (coinex *CoinEx) LimitSell(amount, price string, currency CurrencyPair) (*O
 }
 
 func (coinex *CoinEx) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (coinex *CoinEx) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (coinex *CoinEx) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
@@ -136,9 +153,6 @@ func (coinex *CoinEx) GetOneOrder(orderId string, currency CurrencyPair) (*Order
 	params.Set("market", currency.ToSymbol(""))
 	retmap, err := coinex.doRequest("GET", "order", &params)
 	if err != nil {
-		if "Order not found" == err.Error() {
-			return nil, EX_ERR_NOT_FIND_ORDER
-		}
 		return nil, err
 	}
 	order := coinex.adaptOrder(retmap, currency)
@@ -179,7 +193,7 @@ func (coinex *CoinEx) GetUnfinishOrders(currency CurrencyPair) ([]Order, error)
 }
 
 func (coinex *CoinEx) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 type coinexDifficulty struct {
@@ -239,16 +253,16 @@ func (coinex *CoinEx) GetAccount() (*Account, error) {
 }
 
 func (coinex *CoinEx) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (coinex *CoinEx) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (coinex *CoinEx) doRequestInner(method, uri string, params *url.Values) (buf []byte, err error) {
-	reqUrl := baseurl + uri
+	reqUrl := coinex.baseUrl + uri
 
 	headermap := map[string]string{
 		"Content-Type": "application/json; charset=utf-8"}
@@ -295,7 +309,7 @@ func (coinex *CoinEx) doRequest(method, uri string, params *url.Values) (map[str
 	}
 
 	if ToInt(retmap["code"]) != 0 {
-		return nil, errors.New(retmap["message"].(string))
+		return nil, coinex.adaptError(retmap)
 	}
 
 	//	log.Println(retmap)
@@ -343,3 +357,22 @@ func (coinex *CoinEx) adaptOrder(ordermap map[string]interface{}, pair CurrencyP
 		Fee:        ToFloat64(ordermap["deal_fee"]),
 		OrderTime:  ToInt(ordermap["create_time"])}
 }
+
+//错误是 {"code":107,"data":{},"message":"Insufficient balance"}
+func (coinex *CoinEx) adaptError(retmap map[string]interface{}) ApiError {
+	apiErr := API_ERR
+	switch ToInt(retmap["code"]) {
+	case 24, 34:
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case 25:
+		apiErr = EX_ERR_SIGN
+	case 107:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case 600:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case 602:
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(retmap["code"])
+	return apiErr.OriginErr(fmt.Sprint(retmap["message"]))
+}
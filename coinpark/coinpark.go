@@ -10,6 +10,7 @@ import (
 	"errors"
 	"log"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -38,11 +39,20 @@ const (
 type Cpk struct {
 	httpClient *http.Client
 	accessKey,
-	secretKey string
+	secretKey,
+	apiUrl string
+}
+
+func init() {
+	RegisterExchange("coinpark.cc", func(config *APIConfig) API {
+		c := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		c.apiUrl = OverrideUrl(c.apiUrl, config.ApiUrl)
+		return c
+	})
 }
 
 func New(client *http.Client, apikey, secretkey string) *Cpk {
-	return &Cpk{accessKey: apikey, secretKey: secretkey, httpClient: client}
+	return &Cpk{accessKey: apikey, secretKey: secretkey, httpClient: client, apiUrl: API_URL}
 }
 
 func (c *Cpk) buildSigned(cmd string) string {
@@ -54,12 +64,20 @@ func (c *Cpk) GetExchangeName() string {
 	return "coinpark.cc"
 }
 
+func (c *Cpk) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (c *Cpk) GetServerTime() error {
 	return nil
 }
 
 func (c *Cpk) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
-	url := API_URL + fmt.Sprintf(TICKER_API, currencyPair.String())
+	url := c.apiUrl + fmt.Sprintf(TICKER_API, currencyPair.String())
 	respmap, err := HttpGet(c.httpClient, url)
 	if err != nil {
 		return nil, err
@@ -67,7 +85,7 @@ func (c *Cpk) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	//log.Println("ticker respmap:", respmap)
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 
 	tickmap, ok := respmap["result"].(map[string]interface{})
@@ -76,7 +94,7 @@ func (c *Cpk) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 	ticker := new(Ticker)
 	ticker.Pair = currencyPair
-	ticker.Date = uint64(time.Now().Nanosecond() / 1000)
+	ticker.Date = uint64(time.Now().Unix())
 	ticker.Last = ToFloat64(tickmap["last"])
 	ticker.Vol = ToFloat64(tickmap["vol"])
 	ticker.Low = ToFloat64(tickmap["low"])
@@ -88,7 +106,7 @@ func (c *Cpk) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 }
 
 func (c *Cpk) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	url := API_URL + fmt.Sprintf(DEPTH_API, currency.String(), size)
+	url := c.apiUrl + fmt.Sprintf(DEPTH_API, currency.String(), size)
 	respmap, err := HttpGet(c.httpClient, url)
 	if err != nil {
 		return nil, err
@@ -96,7 +114,7 @@ func (c *Cpk) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 
 	bids, ok1 := respmap["bids"].([]interface{})
@@ -124,11 +142,14 @@ func (c *Cpk) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 		depth.BidList = append(depth.BidList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return depth, nil
 }
 
 func (c *Cpk) placeOrder(orderType, orderSide, amount, price string, pair CurrencyPair) (*Order, error) {
-	path := API_URL + TRADE_URL
+	path := c.apiUrl + TRADE_URL
 	params := make(map[string]interface{})
 	params["cmd"] = "orderpending/trade"
 	params["index"] = strconv.Itoa(rand.Intn(1000))
@@ -169,7 +190,7 @@ func (c *Cpk) placeOrder(orderType, orderSide, amount, price string, pair Curren
 
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 
 	orderId := ToInt(respmap["result"])
@@ -211,7 +232,7 @@ func (c *Cpk) MarketSell(amount, price string, currency CurrencyPair) (*Order, e
 }
 
 func (c *Cpk) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	path := API_URL + TRADE_URL
+	path := c.apiUrl + TRADE_URL
 	params := make(map[string]interface{})
 	params["cmd"] = "orderpending/cancelTrade"
 	params["index"] = strconv.Itoa(rand.Intn(1000))
@@ -238,7 +259,7 @@ func (c *Cpk) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
 
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return false, errors.New(errcode["msg"].(string))
+		return false, c.adaptError(errcode)
 	}
 
 	status := respmap["result"].(string)
@@ -249,7 +270,7 @@ func (c *Cpk) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
 }
 
 func (c *Cpk) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	path := API_URL + TRADE_URL
+	path := c.apiUrl + TRADE_URL
 	params := make(map[string]interface{})
 	params["cmd"] = "orderpending/order"
 
@@ -275,7 +296,7 @@ func (c *Cpk) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error)
 
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 
 	orderInfo := respmap["result"].(map[string]interface{})
@@ -313,20 +334,20 @@ func (c *Cpk) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error)
 
 }
 
-func (c *Cpk) GetOrdersList() {
-	panic("not implement")
+func (c *Cpk) GetOrdersList() ([]Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (c *Cpk) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (c *Cpk) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (c *Cpk) GetAccount() (*Account, error) {
-	path := API_URL + GET_ACCOUNT_API
+	path := c.apiUrl + GET_ACCOUNT_API
 	//cmds := "[{\"cmd\":\"transfer/assets\",\"body\":{\"select\":1}}]"
 
 	params := make(map[string]interface{})
@@ -368,7 +389,7 @@ func (c *Cpk) GetAccount() (*Account, error) {
 
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 	ba := respmap["result"].([]interface{})
 	ba1 := ba[0].(map[string]interface{})
@@ -398,16 +419,16 @@ func (c *Cpk) GetAccount() (*Account, error) {
 }
 
 func (c *Cpk) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (c *Cpk) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (c *Cpk) GetPairList() ([]CurrencyPair, error) {
-	url := API_URL + Pair_List
+	url := c.apiUrl + Pair_List
 	respmap, err := HttpGet(c.httpClient, url)
 	if err != nil {
 		return nil, err
@@ -415,7 +436,7 @@ func (c *Cpk) GetPairList() ([]CurrencyPair, error) {
 	log.Println("respmap:", respmap)
 	errcode, isok := respmap["error"].(map[string]interface{})
 	if isok == true {
-		return nil, errors.New(errcode["msg"].(string))
+		return nil, c.adaptError(errcode)
 	}
 
 	list, ok := respmap["result"].([]interface{})
@@ -431,3 +452,10 @@ func (c *Cpk) GetPairList() ([]CurrencyPair, error) {
 	return pairlist, nil
 
 }
+
+//错误是 {"error":{"code":"2027","msg":"..."}}, 错误码没有确认过, 不映射到EX_ERR_*
+func (c *Cpk) adaptError(errcode map[string]interface{}) ApiError {
+	apiErr := API_ERR
+	apiErr.OriginErrMsg = fmt.Sprint(errcode["code"])
+	return apiErr.OriginErr(fmt.Sprint(errcode["msg"]))
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -37,6 +38,7 @@ type Cryptopia struct {
 	secretKey string
 	httpClient *http.Client
 	debug      bool
+	baseUrl    string
 }
 type jsonResponse struct {
 	Success bool            `json:"success"`
@@ -55,15 +57,30 @@ type cancelOrderPayload struct {
 	Type        string
 }
 
+func init() {
+	RegisterExchange(CRYPTOPIA, func(config *APIConfig) API {
+		cta := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		cta.baseUrl = OverrideUrl(cta.baseUrl, config.ApiUrl)
+		return cta
+	})
+}
+
 func New(client *http.Client, accessKey, secretKey string) *Cryptopia {
 	debug := false
-	return &Cryptopia{accessKey, secretKey, client, debug}
+	return &Cryptopia{accessKey, secretKey, client, debug, API_BASE_URL}
 }
 
 func (cta *Cryptopia) GetExchangeName() string {
 	return CRYPTOPIA
 }
 
+func (cta *Cryptopia) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true,
+		Orders: true}
+}
+
 func (cta *Cryptopia) GetTickers(currency CurrencyPair) (*Ticker, error) {
 	return cta.GetTicker(currency)
 
@@ -90,7 +107,7 @@ func (cta *Cryptopia) GetTickers(currency CurrencyPair) (*Ticker, error) {
 func (cta *Cryptopia) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	currency = cta.adaptCurrencyPair(currency)
 
-	tickerUri := API_BASE_URL + TICKER_URI + currency.ToSymbol("_")
+	tickerUri := cta.baseUrl + TICKER_URI + currency.ToSymbol("_")
 	//log.Println("tickerUrl:", tickerUri)
 	bodyDataMap, err := HttpGet(cta.httpClient, tickerUri)
 	//log.Println("Cryptopia bodyDataMap:", tickerUri, bodyDataMap)
@@ -129,7 +146,7 @@ func (cta *Cryptopia) GetTickerInBuf(currency CurrencyPair) (*Ticker, error) {
 func (cta *Cryptopia) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	currency = cta.adaptCurrencyPair(currency)
 
-	depthURI := fmt.Sprintf("%s%s/%s/%d", API_BASE_URL, DEPTH_URI, currency.ToSymbol("_"), size)
+	depthURI := fmt.Sprintf("%s%s/%s/%d", cta.baseUrl, DEPTH_URI, currency.ToSymbol("_"), size)
 	bodyDataMap, err := HttpGet(cta.httpClient, depthURI)
 	if err != nil {
 		return nil, err
@@ -157,6 +174,9 @@ func (cta *Cryptopia) GetDepth(size int, currency CurrencyPair) (*Depth, error)
 		depth.AskList = append(depth.AskList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return depth, nil
 }
 
@@ -218,7 +238,7 @@ func (cta *Cryptopia) placeOrder(orderType, side, amount, price string, pair Cur
 		order.Amount = ToFloat64(amount)
 		order.Price = ToFloat64(price)
 	} else {
-		return nil, errors.New(jsonResp.Error)
+		return nil, cta.adaptError(jsonResp.Error)
 	}
 
 	switch side {
@@ -241,11 +261,11 @@ func (cta *Cryptopia) LimitSell(amount, price string, currency CurrencyPair) (*O
 }
 
 func (cta *Cryptopia) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cta *Cryptopia) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cta *Cryptopia) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
@@ -270,24 +290,24 @@ func (cta *Cryptopia) CancelOrder(orderId string, currency CurrencyPair) (bool,
 }
 
 func (cta *Cryptopia) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (cta *Cryptopia) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cta *Cryptopia) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cta *Cryptopia) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (cta *Cryptopia) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (cta *Cryptopia) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (cta *Cryptopia) do(method string, resource string, payload string, authNeeded bool) (response []byte, err error) {
@@ -297,7 +317,7 @@ func (cta *Cryptopia) do(method string, resource string, payload string, authNee
 	if strings.HasPrefix(resource, "http") {
 		rawurl = resource
 	} else {
-		rawurl = fmt.Sprintf("%s%s", API_BASE_URL, resource)
+		rawurl = fmt.Sprintf("%s%s", cta.baseUrl, resource)
 	}
 
 	req, err := http.NewRequest(method, rawurl, strings.NewReader(payload))
@@ -339,7 +359,7 @@ func (cta *Cryptopia) do(method string, resource string, payload string, authNee
 		return response, err
 	}
 	if resp.StatusCode != 200 {
-		err = errors.New(resp.Status)
+		err = HttpError{StatusCode: resp.StatusCode, Body: string(response), RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
 	return response, err
 }
@@ -400,3 +420,20 @@ func (cta *Cryptopia) doTimeoutRequest(timer *time.Timer, req *http.Request) (*h
 func (cta *Cryptopia) SetDebug(enable bool) {
 	cta.debug = enable
 }
+
+//Success为false时的Error只有描述, 比如 "Insufficient Funds."
+func (cta *Cryptopia) adaptError(msg string) ApiError {
+	apiErr := API_ERR
+	switch {
+	case strings.HasPrefix(msg, "Insufficient"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.HasPrefix(msg, "Minimum trade"):
+		apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+	case strings.Contains(msg, "does not exist"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(msg, "Market not found"):
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	}
+	apiErr.OriginErrMsg = msg
+	return apiErr.OriginErr(msg)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/internal/zbapi"
 	"log"
 	"net/http"
 	"net/url"
@@ -29,25 +30,47 @@ const (
 	PLACE_ORDER_API           = "order"
 	WITHDRAW_API              = "withdraw"
 	CANCELWITHDRAW_API        = "cancelWithdraw"
+	GET_USER_ADDRESS_API      = "getChargeAddress"
+	GET_CHARGE_RECORD_API     = "getChargeRecord"
+	GET_WITHDRAW_RECORD_API   = "getWithdrawRecord"
 )
 
 type Exx struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	marketUrl,
+	tradeUrl string
+}
+
+func init() {
+	RegisterExchange(EXX, func(config *APIConfig) API {
+		exx := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		exx.marketUrl = OverrideUrl(exx.marketUrl, config.ApiUrl)
+		exx.tradeUrl = OverrideUrl(exx.tradeUrl, config.ApiUrl)
+		return exx
+	})
 }
 
 func New(httpClient *http.Client, accessKey, secretKey string) *Exx {
-	return &Exx{httpClient, accessKey, secretKey}
+	return &Exx{httpClient, accessKey, secretKey, MARKET_URL, TRADE_URL}
 }
 
 func (exx *Exx) GetExchangeName() string {
 	return EXX
 }
 
+func (exx *Exx) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (exx *Exx) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	symbol := currency.AdaptBchToBcc().AdaptUsdToUsdt().ToLower().ToSymbol("_")
-	path := MARKET_URL + fmt.Sprintf(TICKER_API, symbol)
+	path := exx.marketUrl + fmt.Sprintf(TICKER_API, symbol)
 	resp, err := HttpGet(exx.httpClient, path)
 	if err != nil {
 		return nil, err
@@ -61,7 +84,7 @@ func (exx *Exx) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 	ticker := new(Ticker)
 	ticker.Pair = currency
-	ticker.Date, _ = strconv.ParseUint(resp["date"].(string), 10, 64)
+	ticker.Date = ToUnixSecond(ToUint64(resp["date"]))
 	ticker.Buy, _ = strconv.ParseFloat(tickermap["buy"].(string), 64)
 	ticker.Sell, _ = strconv.ParseFloat(tickermap["sell"].(string), 64)
 	ticker.Last, _ = strconv.ParseFloat(tickermap["last"].(string), 64)
@@ -74,7 +97,7 @@ func (exx *Exx) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 func (exx *Exx) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	symbol := currency.AdaptBchToBcc().AdaptUsdToUsdt().ToSymbol("_")
-	resp, err := HttpGet(exx.httpClient, MARKET_URL+fmt.Sprintf(DEPTH_API, symbol))
+	resp, err := HttpGet(exx.httpClient, exx.marketUrl+fmt.Sprintf(DEPTH_API, symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -112,9 +135,11 @@ func (exx *Exx) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 
 		depth.AskList = append(depth.AskList, r)
 	}
+	//先按价格从低到高取最优的size档, 再倒序成AskList最后一个是卖一
 	sort.Sort(depth.AskList)
 	depth.AskList = depth.AskList[0:size]
 	depth.BidList = depth.BidList[0:size]
+	sort.Sort(sort.Reverse(depth.AskList))
 
 	return depth, nil
 }
@@ -141,8 +166,8 @@ func (exx *Exx) GetAccount() (*Account, error) {
 	params := url.Values{}
 	exx.buildPostForm(&params)
 	log.Println(params.Encode())
-	log.Println(TRADE_URL + GET_ACCOUNT_API + "?" + params.Encode())
-	respmap, err := HttpGet(exx.httpClient, TRADE_URL+GET_ACCOUNT_API+"?"+params.Encode())
+	log.Println(exx.tradeUrl + GET_ACCOUNT_API + "?" + params.Encode())
+	respmap, err := HttpGet(exx.httpClient, exx.tradeUrl+GET_ACCOUNT_API+"?"+params.Encode())
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +211,7 @@ func (exx *Exx) placeOrder(amount, price string, currency CurrencyPair, tradeTyp
 	params.Set("tradeType", fmt.Sprintf("%d", tradeType))
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+PLACE_ORDER_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+PLACE_ORDER_API, params)
 	if err != nil {
 		//log.Println(err)
 		return nil, err
@@ -204,7 +229,7 @@ func (exx *Exx) placeOrder(amount, price string, currency CurrencyPair, tradeTyp
 	code := respmap["code"].(float64)
 	if code != 1000 {
 		//log.Println(string(resp))
-		return nil, errors.New(fmt.Sprintf("%.0f", code))
+		return nil, exx.adaptError(int(code), string(resp))
 	}
 
 	orid := respmap["id"].(string)
@@ -244,7 +269,7 @@ func (exx *Exx) CancelOrder(orderId string, currency CurrencyPair) (bool, error)
 	params.Set("currency", symbol)
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+CANCEL_ORDER_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+CANCEL_ORDER_API, params)
 	if err != nil {
 		//log.Println(err)
 		return false, err
@@ -264,7 +289,7 @@ func (exx *Exx) CancelOrder(orderId string, currency CurrencyPair) (bool, error)
 	}
 
 	//log.Println(respmap)
-	return false, errors.New(fmt.Sprintf("%.0f", code))
+	return false, exx.adaptError(int(code), string(resp))
 }
 
 func parseOrder(order *Order, ordermap map[string]interface{}) {
@@ -316,7 +341,7 @@ func (exx *Exx) GetOneOrder(orderId string, currency CurrencyPair) (*Order, erro
 	params.Set("currency", symbol)
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+GET_ORDER_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+GET_ORDER_API, params)
 	if err != nil {
 		//log.Println(err)
 		return nil, err
@@ -346,7 +371,7 @@ func (exx *Exx) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 	params.Set("pageSize", "100")
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+GET_UNFINISHED_ORDERS_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+GET_UNFINISHED_ORDERS_API, params)
 	if err != nil {
 		//log.Println(err)
 		return nil, err
@@ -380,24 +405,36 @@ func (exx *Exx) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (exx *Exx) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (exx *Exx) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
+// Deprecated: 使用SubmitWithdraw
 func (exx *Exx) Withdraw(amount string, currency Currency, fees, receiveAddr, safePwd string) (string, error) {
+	return exx.SubmitWithdraw(WithdrawRequest{Currency: currency, Amount: amount, Address: receiveAddr, Fee: fees, SafePwd: safePwd})
+}
+
+func (exx *Exx) SubmitWithdraw(req WithdrawRequest) (string, error) {
+	currency := req.Currency
+	receiveAddr := req.Address
+	//需要memo的币种,地址格式为 地址_memo
+	if req.Tag != "" {
+		receiveAddr += "_" + req.Tag
+	}
+
 	params := url.Values{}
 	params.Set("method", "withdraw")
 	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
-	params.Set("amount", amount)
-	params.Set("fees", fees)
+	params.Set("amount", req.Amount)
+	params.Set("fees", req.Fee)
 	params.Set("receiveAddr", receiveAddr)
-	params.Set("safePwd", safePwd)
+	params.Set("safePwd", req.SafePwd)
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+WITHDRAW_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+WITHDRAW_API, params)
 	if err != nil {
 		//log.Println("withdraw fail.", err)
 		return "", err
@@ -414,7 +451,7 @@ func (exx *Exx) Withdraw(amount string, currency Currency, fees, receiveAddr, sa
 		return respMap["id"].(string), nil
 	}
 
-	return "", errors.New(string(resp))
+	return "", exx.adaptError(ToInt(respMap["code"]), string(resp))
 }
 
 func (exx *Exx) CancelWithdraw(id string, currency Currency, safePwd string) (bool, error) {
@@ -425,7 +462,7 @@ func (exx *Exx) CancelWithdraw(id string, currency Currency, safePwd string) (bo
 	params.Set("safePwd", safePwd)
 	exx.buildPostForm(&params)
 
-	resp, err := HttpPostForm(exx.httpClient, TRADE_URL+CANCELWITHDRAW_API, params)
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+CANCELWITHDRAW_API, params)
 	if err != nil {
 		//log.Println("cancel withdraw fail.", err)
 		return false, err
@@ -442,17 +479,84 @@ func (exx *Exx) CancelWithdraw(id string, currency Currency, safePwd string) (bo
 		return true, nil
 	}
 
-	return false, errors.New(string(resp))
+	return false, exx.adaptError(ToInt(respMap["code"]), string(resp))
+}
+
+func (exx *Exx) GetDepositAddress(currency Currency) (*DepositAddress, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	datas, err := exx.doWalletRequest(GET_USER_ADDRESS_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseDepositAddress(currency, datas), nil
+}
+
+//最近的充值记录
+func (exx *Exx) GetDepositHistory(currency Currency) ([]Deposit, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	params.Set("pageIndex", "1")
+	params.Set("pageSize", "50")
+	datas, err := exx.doWalletRequest(GET_CHARGE_RECORD_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseDeposits(currency, datas), nil
+}
+
+//最近的提现记录
+func (exx *Exx) GetWithdrawHistory(currency Currency) ([]Withdrawal, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	params.Set("pageIndex", "1")
+	params.Set("pageSize", "50")
+	datas, err := exx.doWalletRequest(GET_WITHDRAW_RECORD_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseWithdrawals(currency, datas), nil
+}
+
+//充提相关接口,返回message.datas
+func (exx *Exx) doWalletRequest(method string, params url.Values) (map[string]interface{}, error) {
+	params.Set("method", method)
+	exx.buildPostForm(&params)
+
+	resp, err := HttpPostForm(exx.httpClient, exx.tradeUrl+method, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseWalletResponse(resp, exx.adaptError)
 }
 
 func (exx *Exx) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("unimplements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (exx *Exx) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (exx *Exx) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+//错误码 1001~1009:通用错误 2001~2009:余额不足 3001:订单不存在 4002:请求过于频繁
+func (exx *Exx) adaptError(code int, resp string) ApiError {
+	apiErr := API_ERR
+	switch {
+	case code == 1003:
+		apiErr = EX_ERR_SIGN
+	case code >= 2001 && code <= 2009:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case code == 3001:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case code == 4001:
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case code == 4002:
+		apiErr = EX_ERR_API_LIMIT
+	}
+	apiErr.OriginErrMsg = resp
+	return apiErr.OriginErr(resp)
 }
@@ -2,7 +2,9 @@ package exx
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 )
@@ -78,3 +80,35 @@ func TestExx_GetOneOrder(t *testing.T) {
 	t.Log(err)
 	t.Log(ord)
 }
+
+func TestExx_GetDepositHistory_Local(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "btc", r.Form.Get("currency"))
+		switch r.URL.Path {
+		case "/api/getChargeRecord":
+			w.Write([]byte(`{"code":1000,"message":{"des":"success","isSuc":true,"datas":{"list":[{"id":1001,"address":"1Addr","amount":"0.5","confirmTimes":3,"hash":"0xtx","status":2,"submit_time":"2018-01-01 08:00:00"}],"pageIndex":1,"pageSize":50,"total":1}}}`))
+		case "/api/getWithdrawRecord":
+			w.Write([]byte(`{"code":1000,"message":{"des":"success","isSuc":true,"datas":{"list":[{"id":2001,"toAddress":"1Addr","amount":0.2,"fees":0.001,"status":5,"submitTime":1514764800000}],"pageIndex":1,"pageSize":50,"total":1}}}`))
+		default:
+			w.Write([]byte(`{"code":4002,"message":"too frequent"}`))
+		}
+	}))
+	defer server.Close()
+
+	exx := New(http.DefaultClient, api_key, api_secretkey)
+	exx.tradeUrl = server.URL + "/api/"
+
+	deposits, err := exx.GetDepositHistory(goex.BTC)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Deposit{{Id: "1001", Currency: goex.BTC, Amount: 0.5, Address: "1Addr", TxId: "0xtx", Confirmations: 3,
+		Status: goex.DEPOSIT_SUCCESS, Time: 1514764800000}}, deposits)
+
+	withdrawals, err := exx.GetWithdrawHistory(goex.BTC)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Withdrawal{{Id: "2001", Currency: goex.BTC, Amount: 0.2, Fee: 0.001, Address: "1Addr",
+		Status: goex.WITHDRAW_PROCESSING, Time: 1514764800000}}, withdrawals)
+
+	_, err = exx.GetDepositAddress(goex.BTC)
+	assert.True(t, goex.IsRateLimited(err))
+}
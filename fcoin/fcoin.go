@@ -11,11 +11,13 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
 
 const (
+	API_BASE_URL    = "https://api.fcoin.com/v2/"
 	DEPTH_API       = "market/depth/%s/%s"
 	TRADE_URL       = "orders"
 	GET_ACCOUNT_API = "accounts/balance"
@@ -42,8 +44,18 @@ type FCoin struct {
 	timeoffset int64
 }
 
+func init() {
+	RegisterExchange(FCOIN, func(config *APIConfig) API {
+		return newFCoin(config.HttpClient, config.AccessKey, config.SecretKey, OverrideUrl(API_BASE_URL, config.ApiUrl))
+	})
+}
+
 func NewFCoin(client *http.Client, apikey, secretkey string) *FCoin {
-	fc := &FCoin{baseUrl: "https://api.fcoin.com/v2/", accessKey: apikey, secretKey: secretkey, httpClient: client}
+	return newFCoin(client, apikey, secretkey, API_BASE_URL)
+}
+
+func newFCoin(client *http.Client, apikey, secretkey, baseUrl string) *FCoin {
+	fc := &FCoin{baseUrl: baseUrl, accessKey: apikey, secretKey: secretkey, httpClient: client}
 	fc.setTimeOffset()
 	return fc
 }
@@ -52,6 +64,14 @@ func (ft *FCoin) GetExchangeName() string {
 	return FCOIN
 }
 
+func (ft *FCoin) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (ft *FCoin) setTimeOffset() error {
 	respmap, err := HttpGet(ft.httpClient, ft.baseUrl+"public/server-time")
 	if err != nil {
@@ -74,8 +94,8 @@ func (ft *FCoin) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 
 	////log.Println("ticker respmap:", respmap)
-	if respmap["status"].(float64) != 0 {
-		return nil, errors.New(respmap["err-msg"].(string))
+	if ToInt(respmap["status"]) != 0 {
+		return nil, ft.adaptError(nil, respmap)
 	}
 
 	//
@@ -91,7 +111,7 @@ func (ft *FCoin) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 
 	ticker := new(Ticker)
 	ticker.Pair = currencyPair
-	ticker.Date = uint64(time.Now().Nanosecond() / 1000)
+	ticker.Date = uint64(time.Now().Unix())
 	ticker.Last = ToFloat64(tickmap[0])
 	ticker.Vol = ToFloat64(tickmap[9])
 	ticker.Low = ToFloat64(tickmap[8])
@@ -111,8 +131,8 @@ func (ft *FCoin) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 		return nil, err
 	}
 
-	if respmap["status"].(float64) != 0 {
-		return nil, errors.New(respmap["err-msg"].(string))
+	if ToInt(respmap["status"]) != 0 {
+		return nil, ft.adaptError(nil, respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -147,7 +167,7 @@ func (ft *FCoin) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 		}
 	}
 
-	//sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.AskList))
 
 	return depth, nil
 }
@@ -170,7 +190,7 @@ func (ft *FCoin) doAuthenticatedRequest(method, uri string, params url.Values) (
 	case "GET":
 		respmap, err = HttpGet2(ft.httpClient, ft.baseUrl+uri+"?"+params.Encode(), header)
 		if err != nil {
-			return nil, err
+			return nil, ft.adaptError(err, nil)
 		}
 
 	case "POST":
@@ -181,14 +201,14 @@ func (ft *FCoin) doAuthenticatedRequest(method, uri string, params url.Values) (
 
 		respbody, err := HttpPostForm4(ft.httpClient, ft.baseUrl+uri, parammap, header)
 		if err != nil {
-			return nil, err
+			return nil, ft.adaptError(err, nil)
 		}
 
 		json.Unmarshal(respbody, &respmap)
 	}
 	log.Println(respmap)
 	if ToInt(respmap["status"]) != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, ft.adaptError(nil, respmap)
 	}
 
 	return respmap["data"], err
@@ -351,7 +371,7 @@ func (ft *FCoin) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (ft *FCoin) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (ft *FCoin) GetAccount() (*Account, error) {
@@ -381,10 +401,42 @@ func (ft *FCoin) GetAccount() (*Account, error) {
 }
 
 func (ft *FCoin) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (ft *FCoin) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+/**
+ * 错误是 {"status":1016,"msg":"account balance insufficient"}, 行情接口是err-msg
+ * 私有接口的错误通常带着非200的状态码返回, 从HttpError的body中解析, 限频时保留HttpError
+ */
+func (ft *FCoin) adaptError(err error, respmap map[string]interface{}) error {
+	if err != nil {
+		httpErr, ok := err.(HttpError)
+		if !ok || IsRateLimited(err) || json.Unmarshal([]byte(httpErr.Body), &respmap) != nil || ToInt(respmap["status"]) == 0 {
+			return err
+		}
+	}
+
+	msg, _ := respmap["msg"].(string)
+	if msg == "" {
+		msg = fmt.Sprint(respmap["err-msg"])
+	}
+
+	apiErr := API_ERR
+	switch ToInt(respmap["status"]) {
+	case 429:
+		apiErr = EX_ERR_API_LIMIT
+	case 1016:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	default:
+		if strings.Contains(msg, "balance insufficient") {
+			apiErr = EX_ERR_INSUFFICIENT_BALANCE
+		}
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(respmap["status"])
+	return apiErr.OriginErr(msg)
 }
@@ -15,50 +15,65 @@ var (
 type Gate struct {
 	client *http.Client
 	accesskey,
-	secretkey string
+	secretkey,
+	marketBaseUrl string
+}
+
+func init() {
+	RegisterExchange(GATEIO, func(config *APIConfig) API {
+		g := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		g.marketBaseUrl = OverrideUrl(g.marketBaseUrl, config.ApiUrl)
+		return g
+	})
 }
 
 func New(client *http.Client, accesskey, secretkey string) *Gate {
-	return &Gate{client: client, accesskey: accesskey, secretkey: secretkey}
+	return &Gate{client: client, accesskey: accesskey, secretkey: secretkey, marketBaseUrl: marketBaseUrl}
 }
 
 func (g *Gate) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implement")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gate) GetAccount() (*Account, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (g *Gate) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	uri := fmt.Sprintf("%s/ticker/%s", marketBaseUrl, strings.ToLower(currency.ToSymbol("_")))
+	uri := fmt.Sprintf("%s/ticker/%s", g.marketBaseUrl, strings.ToLower(currency.ToSymbol("_")))
 
 	resp, err := HttpGet(g.client, uri)
 	if err != nil {
+		if _, ok := err.(HttpError); ok {
+			return nil, err //保留状态码, 用于判断限频
+		}
 		errCode := HTTP_ERR_CODE
 		errCode.OriginErrMsg = err.Error()
 		return nil, errCode
 	}
+	if resp["result"] == "false" {
+		return nil, g.adaptError(resp)
+	}
 
 	return &Ticker{
 		Last: ToFloat64(resp["last"]),
@@ -71,12 +86,18 @@ func (g *Gate) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (g *Gate) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	resp, err := HttpGet(g.client, fmt.Sprintf("%s/orderBook/%s", marketBaseUrl, currency.ToSymbol("_")))
+	resp, err := HttpGet(g.client, fmt.Sprintf("%s/orderBook/%s", g.marketBaseUrl, currency.ToSymbol("_")))
 	if err != nil {
+		if _, ok := err.(HttpError); ok {
+			return nil, err //保留状态码, 用于判断限频
+		}
 		errCode := HTTP_ERR_CODE
 		errCode.OriginErrMsg = err.Error()
 		return nil, errCode
 	}
+	if resp["result"] == "false" {
+		return nil, g.adaptError(resp)
+	}
 
 	bids, _ := resp["bids"].([]interface{})
 	asks, _ := resp["asks"].([]interface{})
@@ -99,14 +120,41 @@ func (g *Gate) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (g *Gate) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (g *Gate) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (g *Gate) GetExchangeName() string {
 	return GATEIO
 }
+
+func (g *Gate) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
+
+//错误是 {"result":"false","code":21,"message":"Error: You don't have enough fund"}
+func (g *Gate) adaptError(resp map[string]interface{}) ApiError {
+	apiErr := API_ERR
+	switch ToInt(resp["code"]) {
+	case 4:
+		apiErr = EX_ERR_API_LIMIT
+	case 5, 6:
+		apiErr = EX_ERR_SIGN
+	case 7, 8, 9:
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case 16, 17:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case 20:
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case 21:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(resp["code"])
+	return apiErr.OriginErr(fmt.Sprint(resp["message"]))
+}
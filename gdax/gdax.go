@@ -1,6 +1,7 @@
 package gdax
 
 import (
+	"encoding/json"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
@@ -13,47 +14,59 @@ type Gdax struct {
 	httpClient *http.Client
 	baseUrl,
 	accessKey,
-	secretKey string
+	secretKey,
+	passphrase string
+}
+
+func init() {
+	RegisterExchange(GDAX, func(config *APIConfig) API {
+		g := NewWithPassphrase(config.HttpClient, config.AccessKey, config.SecretKey, config.Passphrase)
+		g.baseUrl = OverrideUrl(g.baseUrl, config.ApiUrl)
+		return g
+	})
 }
 
 func New(client *http.Client, accesskey, secretkey string) *Gdax {
-	return &Gdax{client, "https://api.gdax.com", accesskey, secretkey}
+	return NewWithPassphrase(client, accesskey, secretkey, "")
+}
+
+//私有接口需要创建api key时设置的passphrase
+func NewWithPassphrase(client *http.Client, accesskey, secretkey, passphrase string) *Gdax {
+	return &Gdax{client, "https://api.gdax.com", accesskey, secretkey, passphrase}
 }
 
 func (g *Gdax) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implement")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (g *Gdax) GetAccount() (*Account, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (g *Gdax) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	resp, err := HttpGet(g.httpClient, fmt.Sprintf("%s/products/%s/ticker", g.baseUrl, currency.ToSymbol("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, g.adaptError(err)
 	}
 
 	return &Ticker{
@@ -67,9 +80,7 @@ func (g *Gdax) GetTicker(currency CurrencyPair) (*Ticker, error) {
 func (g *Gdax) Get24HStats(pair CurrencyPair) (*Ticker, error) {
 	resp, err := HttpGet(g.httpClient, fmt.Sprintf("%s/products/%s/stats", g.baseUrl, pair.ToSymbol("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, g.adaptError(err)
 	}
 	return &Ticker{
 		High: ToFloat64(resp["high"]),
@@ -87,9 +98,7 @@ func (g *Gdax) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 
 	resp, err := HttpGet(g.httpClient, fmt.Sprintf("%s/products/%s/book?level=%d", g.baseUrl, currency.ToSymbol("-"), level))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, g.adaptError(err)
 	}
 
 	bids, _ := resp["bids"].([]interface{})
@@ -113,14 +122,41 @@ func (g *Gdax) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (g *Gdax) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (g *Gdax) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (g *Gdax) GetExchangeName() string {
 	return GDAX
 }
+
+func (g *Gdax) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
+
+//错误的body是 {"message":"NotFound"}, 交易对不存在时返回404; 其它http错误保留HttpError, 用于判断限频
+func (g *Gdax) adaptError(err error) error {
+	httpErr, ok := err.(HttpError)
+	if !ok {
+		errCode := HTTP_ERR_CODE
+		errCode.OriginErrMsg = err.Error()
+		return errCode
+	}
+
+	var resp struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal([]byte(httpErr.Body), &resp)
+	if httpErr.StatusCode == http.StatusNotFound && resp.Message == "NotFound" {
+		apiErr := EX_ERR_INVALID_CURRENCY_PAIR
+		apiErr.OriginErrMsg = httpErr.Body
+		return apiErr.OriginErr(resp.Message)
+	}
+	return httpErr
+}
@@ -0,0 +1,249 @@
+package goextest
+
+import (
+	. "github.com/nntaoli-project/GoEx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sort"
+	"testing"
+)
+
+/**
+ * 现货交易所接口的统一约定, RunSpotConformance按以下约定检查各个交易所的实现:
+ *  GetDepth: AskList和BidList都按价格从高到低排列, 即AskList最后一个是卖一, BidList第一个是买一
+ *  Ticker.Date, Kline.Timestamp: 单位秒, 交易所不返回时间时Ticker.Date为0
+ *  Trade.Date: 单位毫秒(json tag为date_ms), Trade.Type为BUY或SELL
+ *  Ticker.Pair, Depth.Pair, Kline.Pair, Trade.Pair, Order.Currency: 调用时传入的交易对, 不是交易所转换后的交易对
+ *  GetOrderHistorys: 不返回未成交的订单
+ *  GetUnfinishOrders: 没有订单时返回空slice, 不是nil
+ *  订单不存在: GetOneOrder返回IsOrderNotFound的错误
+ *  Capabilities声明不支持的接口: 返回EX_ERR_NOT_SUPPORTED
+ */
+
+//秒级时间戳的合理范围, 毫秒时间戳会超出
+const (
+	MIN_UNIX_SECOND = 1230768000 //2009-01-01
+	MAX_UNIX_SECOND = 4102444800 //2100-01-01
+)
+
+const (
+	MIN_UNIX_MILLISECOND = MIN_UNIX_SECOND * 1000
+	MAX_UNIX_MILLISECOND = MAX_UNIX_SECOND * 1000
+)
+
+//RunSpotConformance检查的数据, 为空的项不检查; 对应的请求需要录制在api使用的Recorder中
+type SpotFixtures struct {
+	Pair           CurrencyPair
+	Ticker         bool
+	DepthSize      int
+	Depth          *Depth //GetDepth(DepthSize, Pair)应该返回的AskList和BidList
+	KlinePeriod    int
+	KlineSize      int
+	KlineSince     int
+	Klines         []Kline //GetKlineRecords应该返回的前len(Klines)条K线
+	Trades         bool    //检查GetTrades(Pair, 0)
+	OrderHistory   bool    //检查GetOrderHistorys(Pair, 1, 10)
+	Order          *Order  //GetOneOrder(Order.OrderID2)应该返回的订单, 检查Status, Side, Amount, DealAmount, 以及不为0的OrderID, Price, AvgPrice, Fee, OrderTime
+	UnfinishOrders bool
+	MissingOrderId string //不存在的订单id
+}
+
+/**
+ * 用testdata/conformance.json回放newApi创建的api, 再执行RunSpotConformance
+ * golden文件目前是按交易所文档的响应格式手写的, 有网络时可以用GOEX_RECORD=1录制真实的响应,
+ * 录制后需要按新的响应更新fixtures中的订单id和期望值
+ * 目前只有binance, bitfinex, huobi, kraken, okcoin, zb有golden文件, 其它交易所需要联网录制, 暂不覆盖
+ */
+func ReplayConformance(t *testing.T, newApi func(client *http.Client) API, fixtures SpotFixtures, redactKeys ...string) {
+	RunSpotConformance(t, newApi(ReplayClient(t, "testdata/conformance.json", redactKeys...)), fixtures)
+}
+
+func RunSpotConformance(t *testing.T, api API, fixtures SpotFixtures) {
+	caps := GetCapabilities(api)
+
+	t.Run("Ticker", func(t *testing.T) {
+		if !fixtures.Ticker {
+			t.Skip("no ticker fixture")
+		}
+		ticker, err := api.GetTicker(fixtures.Pair)
+		if !assert.Nil(t, err) {
+			return
+		}
+		AssertTicker(t, fixtures.Pair, ticker)
+	})
+
+	t.Run("Depth", func(t *testing.T) {
+		if fixtures.DepthSize <= 0 {
+			t.Skip("no depth fixture")
+		}
+		dep, err := api.GetDepth(fixtures.DepthSize, fixtures.Pair)
+		if !assert.Nil(t, err) {
+			return
+		}
+		if fixtures.Depth != nil {
+			assert.Equal(t, fixtures.Depth.AskList, dep.AskList, "depth asks")
+			assert.Equal(t, fixtures.Depth.BidList, dep.BidList, "depth bids")
+		}
+		AssertDepth(t, fixtures.Pair, dep)
+		if caps.MaxDepthSize > 0 {
+			assert.True(t, len(dep.AskList) <= caps.MaxDepthSize && len(dep.BidList) <= caps.MaxDepthSize, "depth larger than MaxDepthSize")
+		}
+	})
+
+	t.Run("Kline", func(t *testing.T) {
+		if !caps.Kline {
+			_, err := api.GetKlineRecords(fixtures.Pair, KLINE_PERIOD_1MIN, 1, 0)
+			assert.True(t, IsNotSupported(err), "GetKlineRecords should return EX_ERR_NOT_SUPPORTED, got %v", err)
+			return
+		}
+		if fixtures.KlineSize <= 0 {
+			t.Skip("no kline fixture")
+		}
+		klines, err := api.GetKlineRecords(fixtures.Pair, fixtures.KlinePeriod, fixtures.KlineSize, fixtures.KlineSince)
+		if !assert.Nil(t, err) || !assert.NotEmpty(t, klines) {
+			return
+		}
+		if len(fixtures.Klines) > 0 && assert.True(t, len(klines) >= len(fixtures.Klines), "got %d klines", len(klines)) {
+			assert.Equal(t, fixtures.Klines, klines[:len(fixtures.Klines)], "klines")
+		}
+		for _, k := range klines {
+			assert.Equal(t, fixtures.Pair, k.Pair, "kline pair")
+			assertUnixSecond(t, k.Timestamp, "kline timestamp")
+			assert.True(t, k.Low <= k.Open && k.Low <= k.Close && k.High >= k.Open && k.High >= k.Close,
+				"kline open/close out of low/high: %+v", k)
+		}
+	})
+
+	t.Run("Trades", func(t *testing.T) {
+		if !caps.Trades {
+			_, err := api.GetTrades(fixtures.Pair, 0)
+			assert.True(t, IsNotSupported(err), "GetTrades should return EX_ERR_NOT_SUPPORTED, got %v", err)
+			return
+		}
+		if !fixtures.Trades {
+			t.Skip("no trades fixture")
+		}
+		trades, err := api.GetTrades(fixtures.Pair, 0)
+		if !assert.Nil(t, err) || !assert.NotEmpty(t, trades) {
+			return
+		}
+		for _, trade := range trades {
+			assert.Equal(t, fixtures.Pair, trade.Pair, "trade pair")
+			assertUnixMillisecond(t, trade.Date, "trade date")
+			assert.True(t, trade.Type == BUY || trade.Type == SELL, "trade type %s", trade.Type)
+			assert.True(t, trade.Price > 0 && trade.Amount > 0, "trade price/amount %+v", trade)
+		}
+	})
+
+	t.Run("OrderHistory", func(t *testing.T) {
+		if !caps.OrderHistory {
+			_, err := api.GetOrderHistorys(fixtures.Pair, 1, 10)
+			assert.True(t, IsNotSupported(err), "GetOrderHistorys should return EX_ERR_NOT_SUPPORTED, got %v", err)
+			return
+		}
+		if !fixtures.OrderHistory {
+			t.Skip("no order history fixture")
+		}
+		orders, err := api.GetOrderHistorys(fixtures.Pair, 1, 10)
+		if !assert.Nil(t, err) || !assert.NotEmpty(t, orders) {
+			return
+		}
+		for _, ord := range orders {
+			assert.Equal(t, fixtures.Pair, ord.Currency, "order currency")
+			assert.NotEqual(t, TradeStatus(ORDER_UNFINISH), ord.Status, "order history should not contain unfinish order %s", ord.OrderID2)
+			assert.True(t, ord.DealAmount <= ord.Amount, "order deal amount > amount")
+		}
+	})
+
+	t.Run("GetOneOrder", func(t *testing.T) {
+		if fixtures.Order == nil {
+			t.Skip("no order fixture")
+		}
+		expect := fixtures.Order
+		ord, err := api.GetOneOrder(expect.OrderID2, fixtures.Pair)
+		if !assert.Nil(t, err) || !assert.NotNil(t, ord) {
+			return
+		}
+		assert.Equal(t, expect.OrderID2, ord.OrderID2, "order id")
+		assert.Equal(t, fixtures.Pair, ord.Currency, "order currency")
+		assert.Equal(t, expect.Status, ord.Status, "order status")
+		assert.Equal(t, expect.Side, ord.Side, "order side")
+		assert.Equal(t, expect.Amount, ord.Amount, "order amount")
+		assert.Equal(t, expect.DealAmount, ord.DealAmount, "order deal amount")
+		if expect.OrderID != 0 {
+			assert.Equal(t, expect.OrderID, ord.OrderID, "order id")
+		}
+		if expect.Price != 0 {
+			assert.Equal(t, expect.Price, ord.Price, "order price")
+		}
+		if expect.AvgPrice != 0 {
+			assert.Equal(t, expect.AvgPrice, ord.AvgPrice, "order avg price")
+		}
+		if expect.Fee != 0 {
+			assert.Equal(t, expect.Fee, ord.Fee, "order fee")
+		}
+		if expect.OrderTime != 0 {
+			assert.Equal(t, expect.OrderTime, ord.OrderTime, "order time")
+		}
+		assert.True(t, ord.DealAmount <= ord.Amount, "order deal amount > amount")
+	})
+
+	t.Run("GetUnfinishOrders", func(t *testing.T) {
+		if !fixtures.UnfinishOrders {
+			t.Skip("no unfinish orders fixture")
+		}
+		orders, err := api.GetUnfinishOrders(fixtures.Pair)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.NotNil(t, orders, "GetUnfinishOrders should return an empty slice instead of nil")
+		for _, ord := range orders {
+			assert.Equal(t, fixtures.Pair, ord.Currency, "order currency")
+			assert.True(t, ord.Status == ORDER_UNFINISH || ord.Status == ORDER_PART_FINISH, "unfinish order status %s", ord.Status)
+		}
+	})
+
+	t.Run("OrderNotFound", func(t *testing.T) {
+		if fixtures.MissingOrderId == "" {
+			t.Skip("no missing order fixture")
+		}
+		_, err := api.GetOneOrder(fixtures.MissingOrderId, fixtures.Pair)
+		assert.True(t, IsOrderNotFound(err), "GetOneOrder should return EX_ERR_NOT_FIND_ORDER, got %v", err)
+	})
+}
+
+//按上面的约定检查rest或websocket返回的ticker
+func AssertTicker(t *testing.T, pair CurrencyPair, ticker *Ticker) {
+	assert.Equal(t, pair, ticker.Pair, "ticker pair")
+	if ticker.Date != 0 {
+		assertUnixSecond(t, int64(ticker.Date), "ticker date")
+	}
+	assert.True(t, ticker.Last > 0, "ticker last %f", ticker.Last)
+	if ticker.Buy > 0 && ticker.Sell > 0 {
+		assert.True(t, ticker.Buy <= ticker.Sell, "ticker buy %f > sell %f", ticker.Buy, ticker.Sell)
+	}
+}
+
+//按上面的约定检查rest或websocket返回的深度
+func AssertDepth(t *testing.T, pair CurrencyPair, dep *Depth) {
+	assert.Equal(t, pair, dep.Pair, "depth pair")
+	if !assert.NotEmpty(t, dep.AskList, "depth asks") || !assert.NotEmpty(t, dep.BidList, "depth bids") {
+		return
+	}
+	assert.True(t, sort.IsSorted(sort.Reverse(dep.AskList)), "depth asks should be sorted by price descending: %v", dep.AskList)
+	assert.True(t, sort.IsSorted(sort.Reverse(dep.BidList)), "depth bids should be sorted by price descending: %v", dep.BidList)
+
+	bestAsk, bestBid := dep.AskList[len(dep.AskList)-1], dep.BidList[0]
+	assert.True(t, bestBid.Price < bestAsk.Price, "depth best bid %f >= best ask %f", bestBid.Price, bestAsk.Price)
+	for _, r := range append(append(DepthRecords{}, dep.AskList...), dep.BidList...) {
+		assert.True(t, r.Price > 0 && r.Amount > 0, "depth record %v", r)
+	}
+}
+
+func assertUnixSecond(t *testing.T, ts int64, name string) {
+	assert.True(t, ts >= MIN_UNIX_SECOND && ts <= MAX_UNIX_SECOND, "%s %d is not a unix timestamp in seconds", name, ts)
+}
+
+func assertUnixMillisecond(t *testing.T, ts int64, name string) {
+	assert.True(t, ts >= MIN_UNIX_MILLISECOND && ts <= MAX_UNIX_MILLISECOND, "%s %d is not a unix timestamp in milliseconds", name, ts)
+}
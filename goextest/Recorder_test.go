@@ -0,0 +1,63 @@
+package goextest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	golden := filepath.Join(t.TempDir(), "testdata", "golden.json")
+
+	os.Setenv("GOEX_RECORD", "1")
+	rec, err := NewRecorder(golden, nil)
+	os.Unsetenv("GOEX_RECORD")
+	assert.Nil(t, err)
+	assert.Equal(t, MODE_RECORD, rec.Mode)
+
+	client := rec.Client()
+	resp, err := client.Get(server.URL + "/api/depth?symbol=btcusdt&api_key=secret-key&Signature=abc")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	resp, err = client.PostForm(server.URL+"/api/order", url.Values{"id": {"1"}, "sign": {"abc"}, "nonce": {"123"}})
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Nil(t, rec.Save())
+
+	data, err := ioutil.ReadFile(golden)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "secret-key"))
+	assert.False(t, strings.Contains(string(data), "abc"))
+
+	rec, err = NewRecorder(golden, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, MODE_REPLAY, rec.Mode)
+	server.Close()
+
+	//参数顺序和签名不同也能匹配
+	client = rec.Client()
+	resp, err = client.PostForm(server.URL+"/api/order", url.Values{"nonce": {"456"}, "id": {"1"}, "sign": {"xyz"}})
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"path":"/api/order"}`, strings.TrimSpace(string(body)))
+
+	resp, err = client.Get(server.URL + "/api/depth?Signature=def&api_key=other&symbol=btcusdt")
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	//每个录制的响应只回放一次
+	_, err = client.Get(server.URL + "/api/depth?Signature=def&api_key=other&symbol=btcusdt")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,92 @@
+package goextest
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 本地websocket服务, 用于离线测试各个交易所的websocket订阅和解析逻辑
+ * 客户端发来的消息按顺序放进Received, Send把消息推送给所有已连接的客户端
+ */
+type WsServer struct {
+	*httptest.Server
+	Received chan []byte
+
+	lock  sync.Mutex
+	conns []*websocket.Conn
+}
+
+func NewWsServer() *WsServer {
+	s := &WsServer{Received: make(chan []byte, 100)}
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.lock.Lock()
+		s.conns = append(s.conns, conn)
+		s.lock.Unlock()
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.Received <- msg
+		}
+	}))
+	return s
+}
+
+//ws://开头的地址
+func (s *WsServer) WsUrl() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+//messageType是websocket.TextMessage或websocket.BinaryMessage
+func (s *WsServer) Send(messageType int, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.conns) == 0 {
+		return errors.New("goextest: no websocket client connected")
+	}
+	for _, conn := range s.conns {
+		err := conn.WriteMessage(messageType, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//等待客户端发来的下一条消息
+func (s *WsServer) NextMessage(timeout time.Duration) ([]byte, error) {
+	select {
+	case msg := <-s.Received:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, errors.New("goextest: no websocket message received")
+	}
+}
+
+//断开所有客户端连接, 不关闭服务
+func (s *WsServer) CloseClients() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *WsServer) Close() {
+	s.CloseClients()
+	s.Server.Close()
+}
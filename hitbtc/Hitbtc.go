@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -40,16 +41,35 @@ type Hitbtc struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	baseUrl    string
+}
+
+func init() {
+	goex.RegisterExchange(EXCHANGE_NAME, func(config *goex.APIConfig) goex.API {
+		hitbtc := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		hitbtc.baseUrl = goex.OverrideUrl(hitbtc.baseUrl, config.ApiUrl)
+		return hitbtc
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey string) *Hitbtc {
-	return &Hitbtc{accessKey, secretKey, client}
+	return &Hitbtc{accessKey, secretKey, client, API_BASE_URL}
 }
 
 func (hitbtc *Hitbtc) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (hitbtc *Hitbtc) Capabilities() goex.Capabilities {
+	return goex.Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Trades:       true,
+		Orders:       true,
+		OrderHistory: true,
+		Account:      true}
+}
+
 // https://api.hitbtc.com/#symbols
 /*
 curl "https://api.hitbtc.com/api/2/public/symbol"
@@ -84,6 +104,30 @@ func (hitbtc *Hitbtc) GetSymbols() ([]goex.CurrencyPair, error) {
 	return pairs, nil
 }
 
+func (hitbtc *Hitbtc) GetMarkets() ([]goex.Market, error) {
+	resp := []map[string]interface{}{}
+	err := hitbtc.doRequest("GET", SYMBOLS_URI, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	markets := []goex.Market{}
+	for _, e := range resp {
+		m := goex.Market{
+			Pair: goex.NewCurrencyPair(goex.NewCurrency(e["baseCurrency"].(string), ""),
+				goex.NewCurrency(e["quoteCurrency"].(string), "")),
+			Symbol:   e["id"].(string),
+			TickSize: goex.ToFloat64(e["tickSize"]),
+			LotSize:  goex.ToFloat64(e["quantityIncrement"]),
+			Status:   goex.MARKET_STATUS_TRADING}
+		m.PricePrecision = goex.StepToPrecision(m.TickSize)
+		m.AmountPrecision = goex.StepToPrecision(m.LotSize)
+		m.MinAmount = m.LotSize
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
 // https://api.hitbtc.com/#tickers
 
 /*
@@ -107,7 +151,7 @@ curl "https://api.hitbtc.com/api/2/public/ticker"
 func (hitbtc *Hitbtc) GetTicker(currency goex.CurrencyPair) (*goex.Ticker, error) {
 	currency = hitbtc.adaptCurrencyPair(currency)
 	curr := currency.ToSymbol("")
-	tickerUri := API_BASE_URL + API_V2 + TICKER_URI + curr
+	tickerUri := hitbtc.baseUrl + API_V2 + TICKER_URI + curr
 	bodyDataMap, err := goex.HttpGet(hitbtc.httpClient, tickerUri)
 	if err != nil {
 		return nil, err
@@ -181,14 +225,65 @@ func (hitbtc *Hitbtc) placeOrder(ty goex.TradeSide, amount, price string, curren
 		postData.Set("price", price)
 	}
 
-	reqUrl := API_BASE_URL + API_V2 + ORDER_URI
+	return hitbtc.doPlaceOrder(postData)
+}
+
+func (hitbtc *Hitbtc) PlaceOrder(req goex.OrderRequest) (*goex.Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+
+	postData := url.Values{}
+	postData.Set("symbol", req.Currency.ToSymbol(""))
+	postData.Set("side", "buy")
+	if req.Side == goex.SELL {
+		postData.Set("side", "sell")
+	}
+	postData.Set("quantity", req.Amount)
+
+	switch req.Type {
+	case goex.ORDER_TYPE_LIMIT:
+		postData.Set("type", "limit")
+		postData.Set("price", req.Price)
+	case goex.ORDER_TYPE_MARKET:
+		postData.Set("type", "market")
+	case goex.ORDER_TYPE_STOP_LIMIT:
+		postData.Set("type", "stopLimit")
+		postData.Set("price", req.Price)
+		postData.Set("stopPrice", req.StopPrice)
+	case goex.ORDER_TYPE_STOP_MARKET:
+		postData.Set("type", "stopMarket")
+		postData.Set("stopPrice", req.StopPrice)
+	}
+
+	switch req.TimeInForce {
+	case goex.TIME_IN_FORCE_GTC:
+		postData.Set("timeInForce", "GTC")
+	case goex.TIME_IN_FORCE_IOC:
+		postData.Set("timeInForce", "IOC")
+	case goex.TIME_IN_FORCE_FOK:
+		postData.Set("timeInForce", "FOK")
+	case goex.TIME_IN_FORCE_POST_ONLY:
+		postData.Set("timeInForce", "GTC")
+		postData.Set("postOnly", "true")
+	}
+
+	if req.ClientOrderID != "" {
+		postData.Set("clientOrderId", req.ClientOrderID)
+	}
+
+	return hitbtc.doPlaceOrder(postData)
+}
+
+func (hitbtc *Hitbtc) doPlaceOrder(postData url.Values) (*goex.Order, error) {
+	reqUrl := hitbtc.baseUrl + API_V2 + ORDER_URI
 	headers := make(map[string]string)
 	headers["Content-type"] = "application/x-www-form-urlencoded"
 	headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(hitbtc.accessKey+":"+hitbtc.secretKey))
 
 	bytes, err := goex.HttpPostForm3(hitbtc.httpClient, reqUrl, postData.Encode(), headers)
 	if err != nil {
-		return nil, err
+		return nil, hitbtc.adaptError(err)
 	}
 
 	/*
@@ -208,7 +303,7 @@ func (hitbtc *Hitbtc) placeOrder(ty goex.TradeSide, amount, price string, curren
 
 	if errObj, ok := resp["error"]; ok {
 		log.Println(errObj)
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, hitbtc.adaptErrorObj(errObj)
 	}
 
 	return toOrder(resp), nil
@@ -232,12 +327,12 @@ func (hitbtc *Hitbtc) MarketSell(amount, price string, currency goex.CurrencyPai
 
 func (hitbtc *Hitbtc) CancelOrder(orderId string, currency goex.CurrencyPair) (bool, error) {
 	postData := url.Values{}
-	reqUrl := API_BASE_URL + API_V2 + ORDER_URI + "/" + orderId
+	reqUrl := hitbtc.baseUrl + API_V2 + ORDER_URI + "/" + orderId
 	headers := make(map[string]string)
 	headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(hitbtc.accessKey+":"+hitbtc.secretKey))
 	bytes, err := goex.HttpDeleteForm(hitbtc.httpClient, reqUrl, postData, headers)
 	if err != nil {
-		return false, err
+		return false, hitbtc.adaptError(err)
 	}
 
 	var resp map[string]interface{}
@@ -248,7 +343,7 @@ func (hitbtc *Hitbtc) CancelOrder(orderId string, currency goex.CurrencyPair) (b
 
 	if errObj, ok := resp["error"]; ok {
 		log.Println(errObj)
-		return false, errors.New(errObj.(map[string]string)["message"])
+		return false, hitbtc.adaptErrorObj(errObj)
 	}
 
 	return true, nil
@@ -262,7 +357,7 @@ func (hitbtc *Hitbtc) GetOneOrder(orderId string, currency goex.CurrencyPair) (*
 	}
 
 	if errObj, ok := resp["error"]; ok {
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, hitbtc.adaptErrorObj(errObj)
 	}
 
 	return toOrder(resp), nil
@@ -368,10 +463,10 @@ func (hitbtc *Hitbtc) GetDepth(size int, currency goex.CurrencyPair) (*goex.Dept
 	}
 
 	if errObj, ok := resp["error"]; ok {
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, hitbtc.adaptErrorObj(errObj)
 	}
 
-	askList := []goex.DepthRecord{}
+	askList := goex.DepthRecords{}
 
 	for _, ee := range resp["ask"].([]interface{}) {
 		e := ee.(map[string]interface{})
@@ -382,7 +477,7 @@ func (hitbtc *Hitbtc) GetDepth(size int, currency goex.CurrencyPair) (*goex.Dept
 		askList = append(askList, one)
 	}
 
-	bidList := []goex.DepthRecord{}
+	bidList := goex.DepthRecords{}
 	for _, ee := range resp["bid"].([]interface{}) {
 		e := ee.(map[string]interface{})
 		one := goex.DepthRecord{
@@ -392,11 +487,14 @@ func (hitbtc *Hitbtc) GetDepth(size int, currency goex.CurrencyPair) (*goex.Dept
 		bidList = append(bidList, one)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(askList))
+	sort.Sort(sort.Reverse(bidList))
 	return &goex.Depth{AskList: askList, BidList: bidList}, nil
 }
 
 func (hitbtc *Hitbtc) GetKlineRecords(currency goex.CurrencyPair, period, size, since int) ([]goex.Kline, error) {
-	panic("not implement")
+	return nil, goex.EX_ERR_NOT_SUPPORTED
 }
 
 // https://api.hitbtc.com/#candles
@@ -495,11 +593,14 @@ func (hitbtc *Hitbtc) GetTrades(currencyPair goex.CurrencyPair, since int64) ([]
 	for _, e := range resp {
 		one := goex.Trade{
 			Tid:    int64(goex.ToUint64(e["id"])),
-			Type:   e["side"].(string),
+			Type:   goex.SELL,
 			Amount: goex.ToFloat64(e["quantity"]),
 			Price:  goex.ToFloat64(e["price"]),
 			Date:   parseTime(e["timestamp"].(string)),
 		}
+		if e["side"] == "buy" {
+			one.Type = goex.BUY
+		}
 		trades = append(trades, one)
 	}
 	return trades, nil
@@ -525,7 +626,7 @@ func (hitbtc *Hitbtc) adaptCurrencyPair(pair goex.CurrencyPair) goex.CurrencyPai
 }
 
 func (hitbtc *Hitbtc) doRequest(reqMethod, uri string, ret interface{}) error {
-	url := API_BASE_URL + API_V2 + uri
+	url := hitbtc.baseUrl + API_V2 + uri
 	req, _ := http.NewRequest(reqMethod, url, strings.NewReader(""))
 	req.SetBasicAuth(hitbtc.accessKey, hitbtc.secretKey)
 	resp, err := hitbtc.httpClient.Do(req)
@@ -541,7 +642,10 @@ func (hitbtc *Hitbtc) doRequest(reqMethod, uri string, ret interface{}) error {
 	}
 
 	if resp.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(bodyData)))
+		return hitbtc.adaptError(goex.HttpError{
+			StatusCode: resp.StatusCode,
+			Body:       string(bodyData),
+			RetryAfter: goex.ParseRetryAfter(resp.Header.Get("Retry-After"))})
 	}
 
 	err = json.Unmarshal(bodyData, ret)
@@ -573,15 +677,45 @@ func (hitbtc *Hitbtc) doRequest(reqMethod, uri string, ret interface{}) error {
 */
 func toOrder(resp map[string]interface{}) *goex.Order {
 	return &goex.Order{
-		Price:      goex.ToFloat64(resp["price"]),
-		Amount:     goex.ToFloat64(resp["quantity"]),
-		DealAmount: goex.ToFloat64(resp["cumQuantity"]),
-		OrderID2:   resp["clientOrderId"].(string),
-		OrderID:    goex.ToInt(resp["id"]),
-		OrderTime:  int(parseTime(resp["createdAt"].(string))),
-		Status:     parseStatus(resp["status"].(string)),
-		Currency:   parseSymbol(resp["symbol"].(string)),
-		Side:       parseSide(resp["side"].(string), resp["type"].(string)),
+		Price:         goex.ToFloat64(resp["price"]),
+		Amount:        goex.ToFloat64(resp["quantity"]),
+		DealAmount:    goex.ToFloat64(resp["cumQuantity"]),
+		OrderID2:      resp["clientOrderId"].(string),
+		OrderID:       goex.ToInt(resp["id"]),
+		OrderTime:     int(parseTime(resp["createdAt"].(string))),
+		Status:        parseStatus(resp["status"].(string)),
+		Currency:      parseSymbol(resp["symbol"].(string)),
+		Side:          parseSide(resp["side"].(string), resp["type"].(string)),
+		Type:          parseOrderType(resp["type"].(string)),
+		TimeInForce:   parseTimeInForce(resp),
+		ClientOrderID: resp["clientOrderId"].(string),
+	}
+}
+
+func parseOrderType(oType string) goex.OrderType {
+	switch oType {
+	case "market":
+		return goex.ORDER_TYPE_MARKET
+	case "stopLimit":
+		return goex.ORDER_TYPE_STOP_LIMIT
+	case "stopMarket":
+		return goex.ORDER_TYPE_STOP_MARKET
+	default:
+		return goex.ORDER_TYPE_LIMIT
+	}
+}
+
+func parseTimeInForce(resp map[string]interface{}) goex.TimeInForce {
+	if postOnly, _ := resp["postOnly"].(bool); postOnly {
+		return goex.TIME_IN_FORCE_POST_ONLY
+	}
+	switch resp["timeInForce"] {
+	case "IOC":
+		return goex.TIME_IN_FORCE_IOC
+	case "FOK":
+		return goex.TIME_IN_FORCE_FOK
+	default:
+		return goex.TIME_IN_FORCE_GTC
 	}
 }
 
@@ -621,15 +755,56 @@ func parseSymbol(str string) goex.CurrencyPair {
 }
 
 func parseSide(side, oType string) goex.TradeSide {
-	if side == "buy" && oType == "limit" {
+	if side == "buy" && (oType == "limit" || oType == "stopLimit") {
 		return goex.BUY
-	} else if side == "sell" && oType == "limit" {
+	} else if side == "sell" && (oType == "limit" || oType == "stopLimit") {
 		return goex.SELL
-	} else if side == "buy" && oType == "market" {
+	} else if side == "buy" && (oType == "market" || oType == "stopMarket") {
 		return goex.BUY_MARKET
-	} else if side == "sell" && oType == "market" {
+	} else if side == "sell" && (oType == "market" || oType == "stopMarket") {
 		return goex.SELL_MARKET
 	} else {
 		panic("Invalid TradeSide:" + side + "&" + oType)
 	}
 }
+
+//非200响应的body是 {"error":{"code":20001,"message":"...","description":"..."}}, 限频时保留HttpError
+func (hitbtc *Hitbtc) adaptError(err error) error {
+	httpErr, ok := err.(goex.HttpError)
+	if !ok || goex.IsRateLimited(err) {
+		return err
+	}
+
+	var resp map[string]interface{}
+	if json.Unmarshal([]byte(httpErr.Body), &resp) != nil || resp["error"] == nil {
+		return err
+	}
+	return hitbtc.adaptErrorObj(resp["error"])
+}
+
+//https://api.hitbtc.com/#error-response
+func (hitbtc *Hitbtc) adaptErrorObj(errObj interface{}) goex.ApiError {
+	errMap, _ := errObj.(map[string]interface{})
+	msg := fmt.Sprint(errMap["message"])
+	if desc, _ := errMap["description"].(string); desc != "" {
+		msg += ", " + desc
+	}
+
+	apiErr := goex.API_ERR
+	switch goex.ToInt(errMap["code"]) {
+	case 429:
+		apiErr = goex.EX_ERR_API_LIMIT
+	case 1001, 1002:
+		apiErr = goex.EX_ERR_NOT_FIND_APIKEY
+	case 2001:
+		apiErr = goex.EX_ERR_INVALID_CURRENCY_PAIR
+	case 2011:
+		apiErr = goex.EX_ERR_AMOUNT_TOO_SMALL
+	case 20001:
+		apiErr = goex.EX_ERR_INSUFFICIENT_BALANCE
+	case 20002:
+		apiErr = goex.EX_ERR_NOT_FIND_ORDER
+	}
+	apiErr.OriginErrMsg = fmt.Sprint(errMap["code"])
+	return apiErr.OriginErr(msg)
+}
@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"context"
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
@@ -20,7 +21,6 @@ import (
 )
 
 var HBPOINT = NewCurrency("HBPOINT", "")
-var onceWsConn sync.Once
 
 var _INERNAL_KLINE_PERIOD_CONVERTER = map[int]string{
 	KLINE_PERIOD_1MIN:   "1min",
@@ -48,16 +48,17 @@ type AccountInfo struct {
 type HuoBiPro struct {
 	httpClient        *http.Client
 	baseUrl           string
+	wsUrl             string
 	accountId         string
+	accountType       string //没有accountId时第一次使用前按账户类型查询
+	accountLock       sync.Mutex
 	accessKey         string
 	secretKey         string
 	ECDSAPrivateKey   string
 	ws                *WsConn
+	wsDialOptions     WsDialOptions
 	createWsLock      sync.Mutex
-	wsTickerHandleMap map[string]func(*Ticker)
-	wsDepthHandleMap  map[string]func(*Depth)
-	wsTradeHandleMap  map[string]func(*Trade)
-	wsKLineHandleMap  map[string]func(*Kline)
+	wsHandlers        *WsRegistry
 }
 
 type HuoBiProSymbol struct {
@@ -69,17 +70,30 @@ type HuoBiProSymbol struct {
 	Symbol string
 }
 
+func init() {
+	RegisterExchange(HUOBI_PRO, func(config *APIConfig) API {
+		hb := NewHuoBiPro(config.HttpClient, config.AccessKey, config.SecretKey, config.AccountId)
+		hb.baseUrl = OverrideUrl(hb.baseUrl, config.ApiUrl)
+		hb.wsUrl = OverrideUrl(hb.wsUrl, config.WsUrl)
+		hb.wsDialOptions = config.WsDialOptions
+		//没有指定账户id时按账户类型查询, 默认现货账户, Build时不访问交易所
+		hb.accountType = config.AccountType
+		if hb.accountType == "" && hb.accessKey != "" {
+			hb.accountType = HB_SPOT_ACCOUNT
+		}
+		return hb
+	})
+}
+
 func NewHuoBiPro(client *http.Client, apikey, secretkey, accountId string) *HuoBiPro {
 	hbpro := new(HuoBiPro)
 	hbpro.baseUrl = "https://api.huobi.br.com"
+	hbpro.wsUrl = "wss://api.huobi.br.com/ws"
 	hbpro.httpClient = client
 	hbpro.accessKey = apikey
 	hbpro.secretKey = secretkey
 	hbpro.accountId = accountId
-	hbpro.wsDepthHandleMap = make(map[string]func(*Depth))
-	hbpro.wsTickerHandleMap = make(map[string]func(*Ticker))
-	hbpro.wsTradeHandleMap = make(map[string]func(*Trade))
-	hbpro.wsKLineHandleMap = make(map[string]func(*Kline))
+	hbpro.wsHandlers = NewWsRegistry()
 	return hbpro
 }
 
@@ -126,7 +140,7 @@ func (hbpro *HuoBiPro) GetAccountInfo(acc string) (AccountInfo, error) {
 	}
 	//log.Println(respmap)
 	if respmap["status"].(string) != "ok" {
-		return AccountInfo{}, errors.New(respmap["err-code"].(string))
+		return AccountInfo{}, hbpro.adaptError(respmap)
 	}
 
 	var info AccountInfo
@@ -145,15 +159,42 @@ func (hbpro *HuoBiPro) GetAccountInfo(acc string) (AccountInfo, error) {
 	return info, nil
 }
 
+//账户id, 没有指定时按accountType查询, 查询成功后不再查询
+func (hbpro *HuoBiPro) getAccountId() (string, error) {
+	hbpro.accountLock.Lock()
+	defer hbpro.accountLock.Unlock()
+
+	if hbpro.accountId != "" || hbpro.accountType == "" {
+		return hbpro.accountId, nil
+	}
+	accinfo, err := hbpro.GetAccountInfo(hbpro.accountType)
+	if err != nil {
+		return "", err
+	}
+	if accinfo.Id == "" {
+		return "", API_ERR.OriginErr("not find " + hbpro.accountType + " account")
+	}
+	hbpro.accountId = accinfo.Id
+	return hbpro.accountId, nil
+}
+
 func (hbpro *HuoBiPro) GetAccount() (*Account, error) {
-	path := fmt.Sprintf("/v1/account/accounts/%s/balance", hbpro.accountId)
+	return hbpro.GetAccountCtx(context.Background())
+}
+
+func (hbpro *HuoBiPro) GetAccountCtx(ctx context.Context) (*Account, error) {
+	accountId, err := hbpro.getAccountId()
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/v1/account/accounts/%s/balance", accountId)
 	params := &url.Values{}
-	params.Set("accountId-id", hbpro.accountId)
+	params.Set("accountId-id", accountId)
 	hbpro.buildPostForm("GET", path, params)
 
 	urlStr := hbpro.baseUrl + path + "?" + params.Encode()
 	//println(urlStr)
-	respmap, err := HttpGet(hbpro.httpClient, urlStr)
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, urlStr)
 
 	if err != nil {
 		return nil, err
@@ -162,7 +203,7 @@ func (hbpro *HuoBiPro) GetAccount() (*Account, error) {
 	//log.Println(respmap)
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, hbpro.adaptError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -202,10 +243,8 @@ func (hbpro *HuoBiPro) GetAccount() (*Account, error) {
 	return acc, nil
 }
 
-func (hbpro *HuoBiPro) placeOrder(amount, price string, pair CurrencyPair, orderType string) (string, error) {
-	path := "/v1/order/orders/place"
+func (hbpro *HuoBiPro) placeOrder(ctx context.Context, amount, price string, pair CurrencyPair, orderType string) (string, error) {
 	params := url.Values{}
-	params.Set("account-id", hbpro.accountId)
 	params.Set("amount", amount)
 	params.Set("symbol", strings.ToLower(pair.ToSymbol("")))
 	params.Set("type", orderType)
@@ -215,9 +254,19 @@ func (hbpro *HuoBiPro) placeOrder(amount, price string, pair CurrencyPair, order
 		params.Set("price", price)
 	}
 
+	return hbpro.doPlaceOrder(ctx, params)
+}
+
+func (hbpro *HuoBiPro) doPlaceOrder(ctx context.Context, params url.Values) (string, error) {
+	accountId, err := hbpro.getAccountId()
+	if err != nil {
+		return "", err
+	}
+	path := "/v1/order/orders/place"
+	params.Set("account-id", accountId)
 	hbpro.buildPostForm("POST", path, &params)
 
-	resp, err := HttpPostForm3(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), hbpro.toJson(params),
+	resp, err := HttpPostForm3Ctx(ctx, hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), hbpro.toJson(params),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
 	if err != nil {
 		return "", err
@@ -230,14 +279,95 @@ func (hbpro *HuoBiPro) placeOrder(amount, price string, pair CurrencyPair, order
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return "", errors.New(respmap["err-code"].(string))
+		return "", hbpro.adaptError(respmap)
 	}
 
 	return respmap["data"].(string), nil
 }
 
+func (hbpro *HuoBiPro) PlaceOrder(req OrderRequest) (*Order, error) {
+	return hbpro.PlaceOrderCtx(context.Background(), req)
+}
+
+func (hbpro *HuoBiPro) PlaceOrderCtx(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+
+	side := "buy"
+	operator := "gte" //买入止损单价格上涨到触发价时下单
+	if req.Side == SELL {
+		side = "sell"
+		operator = "lte"
+	}
+
+	params := url.Values{}
+	params.Set("amount", req.Amount)
+	params.Set("symbol", strings.ToLower(req.Currency.ToSymbol("")))
+
+	orderType := ""
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		params.Set("price", req.Price)
+		switch req.TimeInForce {
+		case TIME_IN_FORCE_GTC:
+			orderType = side + "-limit"
+		case TIME_IN_FORCE_IOC:
+			orderType = side + "-ioc"
+		case TIME_IN_FORCE_FOK:
+			orderType = side + "-limit-fok"
+		case TIME_IN_FORCE_POST_ONLY:
+			orderType = side + "-limit-maker"
+		}
+	case ORDER_TYPE_MARKET:
+		if req.TimeInForce != TIME_IN_FORCE_GTC {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		orderType = side + "-market"
+	case ORDER_TYPE_STOP_LIMIT:
+		switch req.TimeInForce {
+		case TIME_IN_FORCE_GTC:
+			orderType = side + "-stop-limit"
+		case TIME_IN_FORCE_FOK:
+			orderType = side + "-stop-limit-fok"
+		default:
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		params.Set("price", req.Price)
+		params.Set("stop-price", req.StopPrice)
+		params.Set("operator", operator)
+	default:
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+	params.Set("type", orderType)
+
+	if req.ClientOrderID != "" {
+		params.Set("client-order-id", req.ClientOrderID)
+	}
+
+	orderId, err := hbpro.doPlaceOrder(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		Currency:      req.Currency,
+		OrderID:       ToInt(orderId),
+		OrderID2:      orderId,
+		Amount:        ToFloat64(req.Amount),
+		Price:         ToFloat64(req.Price),
+		Side:          req.Side,
+		Type:          req.Type,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: req.ClientOrderID}, nil
+}
+
 func (hbpro *HuoBiPro) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := hbpro.placeOrder(amount, price, currency, "buy-limit")
+	return hbpro.LimitBuyCtx(context.Background(), amount, price, currency)
+}
+
+func (hbpro *HuoBiPro) LimitBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := hbpro.placeOrder(ctx, amount, price, currency, "buy-limit")
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +381,11 @@ func (hbpro *HuoBiPro) LimitBuy(amount, price string, currency CurrencyPair) (*O
 }
 
 func (hbpro *HuoBiPro) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := hbpro.placeOrder(amount, price, currency, "sell-limit")
+	return hbpro.LimitSellCtx(context.Background(), amount, price, currency)
+}
+
+func (hbpro *HuoBiPro) LimitSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := hbpro.placeOrder(ctx, amount, price, currency, "sell-limit")
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +399,11 @@ func (hbpro *HuoBiPro) LimitSell(amount, price string, currency CurrencyPair) (*
 }
 
 func (hbpro *HuoBiPro) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := hbpro.placeOrder(amount, price, currency, "buy-market")
+	return hbpro.MarketBuyCtx(context.Background(), amount, price, currency)
+}
+
+func (hbpro *HuoBiPro) MarketBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := hbpro.placeOrder(ctx, amount, price, currency, "buy-market")
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +417,11 @@ func (hbpro *HuoBiPro) MarketBuy(amount, price string, currency CurrencyPair) (*
 }
 
 func (hbpro *HuoBiPro) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := hbpro.placeOrder(amount, price, currency, "sell-market")
+	return hbpro.MarketSellCtx(context.Background(), amount, price, currency)
+}
+
+func (hbpro *HuoBiPro) MarketSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := hbpro.placeOrder(ctx, amount, price, currency, "sell-market")
 	if err != nil {
 		return nil, err
 	}
@@ -336,16 +478,20 @@ func (hbpro *HuoBiPro) parseOrder(ordmap map[string]interface{}) Order {
 }
 
 func (hbpro *HuoBiPro) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	return hbpro.GetOneOrderCtx(context.Background(), orderId, currency)
+}
+
+func (hbpro *HuoBiPro) GetOneOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
 	path := "/v1/order/orders/" + orderId
 	params := url.Values{}
 	hbpro.buildPostForm("GET", path, &params)
-	respmap, err := HttpGet(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode())
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode())
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, hbpro.adaptError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -356,7 +502,11 @@ func (hbpro *HuoBiPro) GetOneOrder(orderId string, currency CurrencyPair) (*Orde
 }
 
 func (hbpro *HuoBiPro) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return hbpro.getOrders(queryOrdersParams{
+	return hbpro.GetUnfinishOrdersCtx(context.Background(), currency)
+}
+
+func (hbpro *HuoBiPro) GetUnfinishOrdersCtx(ctx context.Context, currency CurrencyPair) ([]Order, error) {
+	return hbpro.getOrders(ctx, queryOrdersParams{
 		pair:   currency,
 		states: "pre-submitted,submitted,partial-filled",
 		size:   100,
@@ -365,10 +515,14 @@ func (hbpro *HuoBiPro) GetUnfinishOrders(currency CurrencyPair) ([]Order, error)
 }
 
 func (hbpro *HuoBiPro) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	return hbpro.CancelOrderCtx(context.Background(), orderId, currency)
+}
+
+func (hbpro *HuoBiPro) CancelOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
 	path := fmt.Sprintf("/v1/order/orders/%s/submitcancel", orderId)
 	params := url.Values{}
 	hbpro.buildPostForm("POST", path, &params)
-	resp, err := HttpPostForm3(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), hbpro.toJson(params),
+	resp, err := HttpPostForm3Ctx(ctx, hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), hbpro.toJson(params),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
 	if err != nil {
 		return false, err
@@ -381,14 +535,69 @@ func (hbpro *HuoBiPro) CancelOrder(orderId string, currency CurrencyPair) (bool,
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return false, errors.New(string(resp))
+		return false, hbpro.adaptError(respmap)
 	}
 
 	return true, nil
 }
 
+//每次最多撤销50个订单
+func (hbpro *HuoBiPro) BatchCancelOrders(currency CurrencyPair, orderIds []string) ([]BatchCancelResult, error) {
+	results := make([]BatchCancelResult, len(orderIds))
+	for i := 0; i < len(orderIds); i += 50 {
+		end := i + 50
+		if end > len(orderIds) {
+			end = len(orderIds)
+		}
+		hbpro.batchCancel(orderIds[i:end], results[i:end])
+	}
+	return results, nil
+}
+
+func (hbpro *HuoBiPro) batchCancel(orderIds []string, results []BatchCancelResult) {
+	path := "/v1/order/orders/batchcancel"
+	params := url.Values{}
+	hbpro.buildPostForm("POST", path, &params)
+	body, _ := json.Marshal(map[string]interface{}{"order-ids": orderIds})
+
+	var respmap map[string]interface{}
+	resp, err := HttpPostForm3(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), string(body),
+		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
+	if err == nil {
+		err = json.Unmarshal(resp, &respmap)
+	}
+	if err == nil && respmap["status"] != "ok" {
+		err = hbpro.adaptError(respmap)
+	}
+
+	failed := make(map[string]error)
+	if err == nil {
+		data, _ := respmap["data"].(map[string]interface{})
+		failedList, _ := data["failed"].([]interface{})
+		for _, f := range failedList {
+			fmap := f.(map[string]interface{})
+			errMsg, _ := fmap["err-msg"].(string)
+			errCode, _ := fmap["err-code"].(string)
+			failed[fmt.Sprint(fmap["order-id"])] = hbpro.adaptErrorCode(errCode, errMsg, EX_ERR_CANCEL_ORDER_FAIL)
+		}
+	}
+
+	for i, id := range orderIds {
+		results[i].OrderID = id
+		if err != nil {
+			results[i].Err = err
+		} else {
+			results[i].Err = failed[id]
+		}
+	}
+}
+
 func (hbpro *HuoBiPro) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return hbpro.getOrders(queryOrdersParams{
+	return hbpro.GetOrderHistorysCtx(context.Background(), currency, currentPage, pageSize)
+}
+
+func (hbpro *HuoBiPro) GetOrderHistorysCtx(ctx context.Context, currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	return hbpro.getOrders(ctx, queryOrdersParams{
 		pair:   currency,
 		size:   pageSize,
 		states: "partial-canceled,filled",
@@ -407,7 +616,7 @@ type queryOrdersParams struct {
 	pair CurrencyPair
 }
 
-func (hbpro *HuoBiPro) getOrders(queryparams queryOrdersParams) ([]Order, error) {
+func (hbpro *HuoBiPro) getOrders(ctx context.Context, queryparams queryOrdersParams) ([]Order, error) {
 	path := "/v1/order/orders"
 	params := url.Values{}
 	params.Set("symbol", strings.ToLower(queryparams.pair.ToSymbol("")))
@@ -422,17 +631,17 @@ func (hbpro *HuoBiPro) getOrders(queryparams queryOrdersParams) ([]Order, error)
 	}
 
 	hbpro.buildPostForm("GET", path, &params)
-	respmap, err := HttpGet(hbpro.httpClient, fmt.Sprintf("%s%s?%s", hbpro.baseUrl, path, params.Encode()))
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, fmt.Sprintf("%s%s?%s", hbpro.baseUrl, path, params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, hbpro.adaptError(respmap)
 	}
 
 	datamap := respmap["data"].([]interface{})
-	var orders []Order
+	orders := make([]Order, 0, len(datamap))
 	for _, v := range datamap {
 		ordmap := v.(map[string]interface{})
 		ord := hbpro.parseOrder(ordmap)
@@ -443,15 +652,71 @@ func (hbpro *HuoBiPro) getOrders(queryparams queryOrdersParams) ([]Order, error)
 	return orders, nil
 }
 
+//since按天查询,结果可能包含since当天更早的成交
+func (hbpro *HuoBiPro) GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	return hbpro.GetMyTradesCtx(context.Background(), currency, since, limit)
+}
+
+func (hbpro *HuoBiPro) GetMyTradesCtx(ctx context.Context, currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	path := "/v1/order/matchresults"
+	params := url.Values{}
+	params.Set("symbol", strings.ToLower(currency.ToSymbol("")))
+	if since > 0 {
+		params.Set("start-date", time.Unix(since/1000, 0).Format("2006-01-02"))
+	}
+	if limit > 0 {
+		params.Set("size", fmt.Sprint(limit))
+	}
+
+	hbpro.buildPostForm("GET", path, &params)
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, fmt.Sprintf("%s%s?%s", hbpro.baseUrl, path, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	if respmap["status"].(string) != "ok" {
+		return nil, hbpro.adaptError(respmap)
+	}
+
+	datamap := respmap["data"].([]interface{})
+	var fills []Fill
+	for _, v := range datamap {
+		t := v.(map[string]interface{})
+		fill := Fill{
+			OrderID:     fmt.Sprint(ToUint64(t["order-id"])),
+			TradeID:     fmt.Sprint(ToUint64(t["match-id"])),
+			Pair:        currency,
+			Side:        SELL,
+			Price:       ToFloat64(t["price"]),
+			Amount:      ToFloat64(t["filled-amount"]),
+			Fee:         ToFloat64(t["filled-fees"]),
+			FeeCurrency: currency.CurrencyB,
+			IsMaker:     t["role"] == "maker",
+			Time:        int64(ToUint64(t["created-at"]))}
+		//买入手续费扣base币,卖出扣quote币
+		if strings.HasPrefix(t["type"].(string), "buy") {
+			fill.Side = BUY
+			fill.FeeCurrency = currency.CurrencyA
+		}
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
+}
+
 func (hbpro *HuoBiPro) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
+	return hbpro.GetTickerCtx(context.Background(), currencyPair)
+}
+
+func (hbpro *HuoBiPro) GetTickerCtx(ctx context.Context, currencyPair CurrencyPair) (*Ticker, error) {
 	url := hbpro.baseUrl + "/market/detail/merged?symbol=" + strings.ToLower(currencyPair.ToSymbol(""))
-	respmap, err := HttpGet(hbpro.httpClient, url)
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, url)
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) == "error" {
-		return nil, errors.New(respmap["err-msg"].(string))
+		return nil, hbpro.adaptError(respmap)
 	}
 
 	tickmap, ok := respmap["tick"].(map[string]interface{})
@@ -460,6 +725,7 @@ func (hbpro *HuoBiPro) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 
 	ticker := new(Ticker)
+	ticker.Pair = currencyPair
 	ticker.Vol = ToFloat64(tickmap["amount"])
 	ticker.Low = ToFloat64(tickmap["low"])
 	ticker.High = ToFloat64(tickmap["high"])
@@ -474,29 +740,39 @@ func (hbpro *HuoBiPro) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	ticker.Buy = ToFloat64(bid[0])
 	ticker.Sell = ToFloat64(ask[0])
 	ticker.Last = ToFloat64(tickmap["close"])
-	ticker.Date = ToUint64(respmap["ts"])
+	ticker.Date = ToUint64(respmap["ts"]) / 1000
 
 	return ticker, nil
 }
 
 func (hbpro *HuoBiPro) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	return hbpro.GetDepthCtx(context.Background(), size, currency)
+}
+
+func (hbpro *HuoBiPro) GetDepthCtx(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
 	url := hbpro.baseUrl + "/market/depth?symbol=%s&type=step0"
-	respmap, err := HttpGet(hbpro.httpClient, fmt.Sprintf(url, strings.ToLower(currency.ToSymbol(""))))
+	respmap, err := HttpGetCtx(ctx, hbpro.httpClient, fmt.Sprintf(url, strings.ToLower(currency.ToSymbol(""))))
 	if err != nil {
 		return nil, err
 	}
 
 	if "ok" != respmap["status"].(string) {
-		return nil, errors.New(respmap["err-msg"].(string))
+		return nil, hbpro.adaptError(respmap)
 	}
 
 	tick, _ := respmap["tick"].(map[string]interface{})
 
-	return hbpro.parseDepthData(tick), nil
+	dep := hbpro.parseDepthData(tick)
+	dep.Pair = currency
+	return dep, nil
 }
 
 //倒序
 func (hbpro *HuoBiPro) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
+	return hbpro.GetKlineRecordsCtx(context.Background(), currency, period, size, since)
+}
+
+func (hbpro *HuoBiPro) GetKlineRecordsCtx(ctx context.Context, currency CurrencyPair, period, size, since int) ([]Kline, error) {
 	url := hbpro.baseUrl + "/market/history/kline?period=%s&size=%d&symbol=%s"
 	symbol := strings.ToLower(currency.AdaptUsdToUsdt().ToSymbol(""))
 	periodS, isOk := _INERNAL_KLINE_PERIOD_CONVERTER[period]
@@ -504,7 +780,7 @@ func (hbpro *HuoBiPro) GetKlineRecords(currency CurrencyPair, period, size, sinc
 		periodS = "1min"
 	}
 
-	ret, err := HttpGet(hbpro.httpClient, fmt.Sprintf(url, periodS, size, symbol))
+	ret, err := HttpGetCtx(ctx, hbpro.httpClient, fmt.Sprintf(url, periodS, size, symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -532,7 +808,11 @@ func (hbpro *HuoBiPro) GetKlineRecords(currency CurrencyPair, period, size, sinc
 
 //非个人，整个交易所的交易记录
 func (hbpro *HuoBiPro) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return hbpro.GetTradesCtx(context.Background(), currencyPair, since)
+}
+
+func (hbpro *HuoBiPro) GetTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 type ecdsaSignature struct {
@@ -544,7 +824,10 @@ func (hbpro *HuoBiPro) buildPostForm(reqMethod, path string, postForm *url.Value
 	postForm.Set("SignatureMethod", "HmacSHA256")
 	postForm.Set("SignatureVersion", "2")
 	postForm.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05"))
-	domain := strings.Replace(hbpro.baseUrl, "https://", "", len(hbpro.baseUrl))
+	var domain string
+	if u, err := url.Parse(hbpro.baseUrl); err == nil {
+		domain = u.Host
+	}
 	payload := fmt.Sprintf("%s\n%s\n%s\n%s", reqMethod, domain, path, postForm.Encode())
 	sign, _ := GetParamHmacSHA256Base64Sign(hbpro.secretKey, payload)
 	postForm.Set("Signature", sign)
@@ -570,10 +853,16 @@ func (hbpro *HuoBiPro) toJson(params url.Values) string {
 	return string(jsonData)
 }
 
-func (hbpro *HuoBiPro) createWsConn() {
+func (hbpro *HuoBiPro) createWsConn() (*WsConn, error) {
+	hbpro.createWsLock.Lock()
+	defer hbpro.createWsLock.Unlock()
 
-	onceWsConn.Do(func() {
-		hbpro.ws = NewWsConn("wss://api.huobi.br.com/ws")
+	if hbpro.ws == nil {
+		ws, err := DialWsConn(hbpro.wsUrl, hbpro.wsDialOptions)
+		if err != nil {
+			return nil, err
+		}
+		hbpro.ws = ws
 		hbpro.ws.Heartbeat(func() interface{} {
 			return map[string]interface{}{
 				"ping": time.Now().Unix()}
@@ -596,14 +885,14 @@ func (hbpro *HuoBiPro) createWsConn() {
 
 			if datamap["ping"] != nil {
 				//log.Println(datamap)
-				hbpro.ws.UpdateActivedTime()
-				hbpro.ws.SendWriteJSON(map[string]interface{}{
+				ws.UpdateActivedTime()
+				ws.SendWriteJSON(map[string]interface{}{
 					"pong": datamap["ping"]}) // 回应心跳
 				return
 			}
 
 			if datamap["pong"] != nil { //
-				hbpro.ws.UpdateActivedTime()
+				ws.UpdateActivedTime()
 				return
 			}
 
@@ -620,46 +909,38 @@ func (hbpro *HuoBiPro) createWsConn() {
 
 			tick := datamap["tick"].(map[string]interface{})
 
-			pair := hbpro.getPairFromChannel(ch)
-			if strings.Contains(ch, ".detail") {
-				if hbpro.wsTickerHandleMap[ch] != nil {
-					tick := hbpro.parseTickerData(tick)
-					tick.Pair = pair
-					tick.Date = ToUint64(datamap["ts"])
-					(hbpro.wsTickerHandleMap[ch])(tick)
-					return
-				}
-			}
-
-			if strings.Contains(ch, ".depth.step") {
-				if hbpro.wsDepthHandleMap[ch] != nil {
-					depth := hbpro.parseDepthData(tick)
-					depth.Pair = pair
-					(hbpro.wsDepthHandleMap[ch])(depth)
-					return
-				}
+			if !hbpro.wsHandlers.Has(ch) {
+				return
 			}
 
-			if strings.Contains(ch, ".trade.detail") {
+			pair := hbpro.getPairFromChannel(ch)
+			switch {
+			case strings.Contains(ch, ".trade.detail"):
 				trades := hbpro.parseTradeData(tick)
 				//反向是为了和app服务端顺序一致
 				for i := len(trades) - 1; i >= 0; i-- {
 					trades[i].Pair = pair
-					(hbpro.wsTradeHandleMap[ch])(trades[i])
+					hbpro.wsHandlers.Dispatch(ch, trades[i])
 				}
-			}
-
-			if hbpro.wsKLineHandleMap[ch] != nil {
+			case strings.HasSuffix(ch, ".detail"):
+				tick := hbpro.parseTickerData(tick)
+				tick.Pair = pair
+				tick.Date = ToUint64(datamap["ts"]) / 1000
+				hbpro.wsHandlers.Dispatch(ch, tick)
+			case strings.Contains(ch, ".depth.step"):
+				depth := hbpro.parseDepthData(tick)
+				depth.Pair = pair
+				hbpro.wsHandlers.Dispatch(ch, depth)
+			case strings.Contains(ch, ".kline."):
 				kline := hbpro.parseWsKLineData(tick)
 				kline.Pair = pair
-				(hbpro.wsKLineHandleMap[ch])(kline)
-				return
+				hbpro.wsHandlers.Dispatch(ch, kline)
 			}
 
 			//log.Println(string(data))
 		})
-	})
-
+	}
+	return hbpro.ws, nil
 }
 
 func Between(str, starting, ending string) string {
@@ -687,7 +968,7 @@ func (hbpro *HuoBiPro) getPairFromChannel(ch string) CurrencyPair {
 		symbol = s[1]
 	} else if s := regexp.MustCompile(`market.(.*).trade.detail`).FindStringSubmatch(ch); len(s) > 1 {
 		symbol = s[1]
-	} else if s := regexp.MustCompile(`market.(.*).detail	`).FindStringSubmatch(ch); len(s) > 1 {
+	} else if s := regexp.MustCompile(`market.(.*).detail`).FindStringSubmatch(ch); len(s) > 1 {
 		symbol = s[1]
 	} else if s := regexp.MustCompile(`orders.(.*)`).FindStringSubmatch(ch); len(s) > 1 {
 		symbol = s[1]
@@ -795,6 +1076,19 @@ func (hbpro *HuoBiPro) GetExchangeName() string {
 	return HUOBI_PRO
 }
 
+func (hbpro *HuoBiPro) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Kline:        true,
+		Orders:       true,
+		OrderHistory: true,
+		Account:      true,
+		Ws:           true,
+		KlinePeriods: KlinePeriods(_INERNAL_KLINE_PERIOD_CONVERTER),
+		MaxDepthSize: 150}
+}
+
 func (hbpro *HuoBiPro) GetCurrenciesList() ([]string, error)  {
 	url := hbpro.baseUrl + "/v1/common/currencys"
 
@@ -839,43 +1133,169 @@ func (hbpro *HuoBiPro) GetCurrenciesPrecision() ([]HuoBiProSymbol, error)  {
 	return Symbols, nil
 }
 
+func (hbpro *HuoBiPro) GetMarkets() ([]Market, error) {
+	ret, err := HttpGet(hbpro.httpClient, hbpro.baseUrl+"/v1/common/symbols")
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := ret["data"].([]interface{})
+	if !ok {
+		return nil, errors.New("response format error")
+	}
+
+	var markets []Market
+	for _, v := range data {
+		_sym := v.(map[string]interface{})
+		m := Market{
+			Pair: NewCurrencyPair(NewCurrency(_sym["base-currency"].(string), ""),
+				NewCurrency(_sym["quote-currency"].(string), "")),
+			Symbol:          _sym["symbol"].(string),
+			PricePrecision:  ToInt(_sym["price-precision"]),
+			AmountPrecision: ToInt(_sym["amount-precision"]),
+			MinAmount:       ToFloat64(_sym["min-order-amt"]),
+			MinNotional:     ToFloat64(_sym["min-order-value"])}
+		m.TickSize = PrecisionToStep(m.PricePrecision)
+		m.LotSize = PrecisionToStep(m.AmountPrecision)
+
+		switch _sym["state"] {
+		case nil, "online":
+			m.Status = MARKET_STATUS_TRADING
+		case "suspend":
+			m.Status = MARKET_STATUS_HALT
+		case "offline":
+			m.Status = MARKET_STATUS_OFFLINE
+		}
+		markets = append(markets, m)
+	}
+
+	return markets, nil
+}
+
 func (hbpro *HuoBiPro) GetTickerWithWs(pair CurrencyPair, handle func(ticker *Ticker)) error {
-	hbpro.createWsConn()
-	sub := fmt.Sprintf("market.%s.detail", strings.ToLower(pair.ToSymbol("")))
-	hbpro.wsTickerHandleMap[sub] = handle
-	return hbpro.ws.Subscribe(map[string]interface{}{
-		"id":  1,
-		"sub": sub})
+	_, err := hbpro.AddWsHandle(UNSUB_TICKER, pair, 0, handle)
+	return err
 }
 
 func (hbpro *HuoBiPro) GetDepthWithWs(pair CurrencyPair, handle func(dep *Depth)) error {
-	hbpro.createWsConn()
-	sub := fmt.Sprintf("market.%s.depth.step0", strings.ToLower(pair.ToSymbol("")))
-	hbpro.wsDepthHandleMap[sub] = handle
-	return hbpro.ws.Subscribe(map[string]interface{}{
-		"id":  2,
-		"sub": sub})
+	_, err := hbpro.AddWsHandle(UNSUB_ORDERBOOK, pair, 0, handle)
+	return err
 }
 
 func (hbpro *HuoBiPro) GetTradeWithWs(pair CurrencyPair, handle func(dep *Trade)) error {
-	hbpro.createWsConn()
-	sub := fmt.Sprintf("market.%s.trade.detail", strings.ToLower(pair.ToSymbol("")))
-	hbpro.wsTradeHandleMap[sub] = handle
-  return hbpro.ws.Subscribe(map[string]interface{}{
-		"id":  3,
-		"sub": sub})
+	_, err := hbpro.AddWsHandle(UNSUB_TRADE, pair, 0, handle)
+	return err
 }
 
 func (hbpro *HuoBiPro) GetKLineWithWs(pair CurrencyPair, period int, handle func(kline *Kline)) error {
-	hbpro.createWsConn()
+	_, err := hbpro.AddWsHandle(UNSUB_KLINE, pair, period, handle)
+	return err
+}
+
+func (hbpro *HuoBiPro) UnsubscribeTicker(pair CurrencyPair) error {
+	return hbpro.wsHandlers.Unsubscribe(hbpro.tickerChannel(pair))
+}
+
+func (hbpro *HuoBiPro) UnsubscribeDepth(pair CurrencyPair) error {
+	return hbpro.wsHandlers.Unsubscribe(hbpro.depthChannel(pair))
+}
+
+func (hbpro *HuoBiPro) UnsubscribeTrade(pair CurrencyPair) error {
+	return hbpro.wsHandlers.Unsubscribe(hbpro.tradeChannel(pair))
+}
+
+func (hbpro *HuoBiPro) UnsubscribeKLine(pair CurrencyPair, period int) error {
+	return hbpro.wsHandlers.Unsubscribe(hbpro.klineChannel(pair, period))
+}
+
+func (hbpro *HuoBiPro) AddWsHandle(event int, pair CurrencyPair, period int, handle interface{}) (int64, error) {
+	var sub string
+	switch event {
+	case UNSUB_TICKER:
+		sub = hbpro.tickerChannel(pair)
+	case UNSUB_ORDERBOOK:
+		sub = hbpro.depthChannel(pair)
+	case UNSUB_TRADE:
+		sub = hbpro.tradeChannel(pair)
+	case UNSUB_KLINE:
+		sub = hbpro.klineChannel(pair, period)
+	default:
+		return 0, EX_ERR_NOT_SUPPORTED.OriginErr(fmt.Sprintf("ws event %d", event))
+	}
+
+	ws, err := hbpro.createWsConn()
+	if err != nil {
+		return 0, err
+	}
+	return hbpro.wsHandlers.Subscribe(sub, handle, func() error {
+		return ws.Subscribe(hbpro.subEvent(sub))
+	}, func() error {
+		return ws.Unsubscribe(hbpro.subEvent(sub), map[string]interface{}{
+			"id":    sub,
+			"unsub": sub})
+	})
+}
+
+func (hbpro *HuoBiPro) RemoveWsHandle(id int64) error {
+	return hbpro.wsHandlers.Remove(id)
+}
+
+func (hbpro *HuoBiPro) subEvent(sub string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":  sub,
+		"sub": sub}
+}
+
+func (hbpro *HuoBiPro) tickerChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("market.%s.detail", strings.ToLower(pair.ToSymbol("")))
+}
+
+func (hbpro *HuoBiPro) depthChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("market.%s.depth.step0", strings.ToLower(pair.ToSymbol("")))
+}
+
+func (hbpro *HuoBiPro) tradeChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("market.%s.trade.detail", strings.ToLower(pair.ToSymbol("")))
+}
+
+func (hbpro *HuoBiPro) klineChannel(pair CurrencyPair, period int) string {
 	periodS, isOk := _INERNAL_KLINE_PERIOD_CONVERTER[period]
 	if isOk != true {
 		periodS = "1min"
 	}
+	return fmt.Sprintf("market.%s.kline.%s", strings.ToLower(pair.ToSymbol("")), periodS)
+}
+
+//返回的错误是 {"status":"error","err-code":"...","err-msg":"..."}
+func (hbpro *HuoBiPro) adaptError(respmap map[string]interface{}) ApiError {
+	errCode, _ := respmap["err-code"].(string)
+	errMsg, _ := respmap["err-msg"].(string)
+	return hbpro.adaptErrorCode(errCode, errMsg, API_ERR)
+}
 
-	sub := fmt.Sprintf("market.%s.kline.%s", strings.ToLower(pair.ToSymbol("")), periodS)
-	hbpro.wsKLineHandleMap[sub] = handle
-	return hbpro.ws.Subscribe(map[string]interface{}{
-		"id":  3,
-		"sub": sub})
+func (hbpro *HuoBiPro) adaptErrorCode(errCode, errMsg string, defErr ApiError) ApiError {
+	apiErr := defErr
+	switch errCode {
+	case "api-signature-not-valid", "api-signature-check-failed":
+		apiErr = EX_ERR_SIGN
+	case "login-required":
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case "account-frozen-balance-insufficient-error", "order-accountbalance-error", "insufficient-balance":
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case "base-record-invalid", "order-queryorder-invalid":
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case "base-symbol-error", "invalid-symbol":
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case "order-limitorder-amount-min-error", "order-marketorder-amount-min-error":
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case "order-value-min-error":
+		apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+	case "order-orderstate-error":
+		apiErr = EX_ERR_CANCEL_ORDER_FAIL
+	}
+	apiErr.OriginErrMsg = errCode
+	if errMsg == "" {
+		errMsg = errCode
+	}
+	return apiErr.OriginErr(errMsg)
 }
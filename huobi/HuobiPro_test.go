@@ -1,38 +1,27 @@
 package huobi
 
 import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gorilla/websocket"
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"github.com/stretchr/testify/assert"
-	"net"
 	"net/http"
-	"net/url"
+	"net/http/httptest"
 	"testing"
 	"time"
 	"log"
 	"fmt"
 )
 
-var httpProxyClient = &http.Client{
-	Transport: &http.Transport{
-		Proxy: func(req *http.Request) (*url.URL, error) {
-			return &url.URL{
-				Scheme: "socks5",
-				Host:   "127.0.0.1:8016"}, nil
-		},
-		Dial: (&net.Dialer{
-			Timeout: 10 * time.Second,
-		}).Dial,
-	},
-	Timeout: 10 * time.Second,
-}
-
 var (
 	apikey    = ""
 	secretkey = ""
 )
 
-//
-var hbpro = NewHuoBiProSpot(httpProxyClient, apikey, secretkey)
+//初始化时不访问网络, 需要账户id的测试自己设置
+var hbpro = NewHuoBiPro(http.DefaultClient, apikey, secretkey, "")
 
 func TestHuobiPro_GetTicker(t *testing.T) {
 	return
@@ -59,7 +48,7 @@ func TestHuobiPro_GetAccountInfo(t *testing.T) {
 //获取点卡剩余
 func TestHuoBiPro_GetPoint(t *testing.T) {
 	return
-	point := NewHuoBiProPoint(httpProxyClient, apikey, secretkey)
+	point := NewHuoBiProPoint(http.DefaultClient, apikey, secretkey)
 	acc, _ := point.GetAccount()
 	t.Log(acc.SubAccounts[HBPOINT])
 }
@@ -139,12 +128,124 @@ func TestHuobiPro_GetDepthWithWs(t *testing.T) {
 
 
 func TestHuoBiPro_GetTradeWithWs(t *testing.T) {
+	t.Skip("需要访问交易所")
 	hbpro.GetTradeWithWs(goex.EOS_USDT, func(trade *goex.Trade) {
 		//log.Printf("%+v", trade)
 	})
 	time.Sleep(time.Minute*100)
 }
 
+func TestHuoBiPro_GetTradeWithWs_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	hb := NewHuoBiPro(http.DefaultClient, "", "", "")
+	hb.wsUrl = server.WsUrl()
+	trades := make(chan *goex.Trade, 2)
+	err := hb.GetTradeWithWs(goex.BTC_USDT, func(trade *goex.Trade) {
+		trades <- trade
+	})
+	assert.Nil(t, err)
+	//同一个频道的第二个handle不会再次订阅
+	err = hb.GetTradeWithWs(goex.BTC_USDT, func(trade *goex.Trade) {
+		trades <- trade
+	})
+	assert.Nil(t, err)
+
+	msg, err := server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"market.btcusdt.trade.detail","sub":"market.btcusdt.trade.detail"}`, string(msg))
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"ch":"market.btcusdt.trade.detail","ts":1546300800123,"tick":{"id":1,"ts":1546300800123,"data":[{"id":10001,"amount":0.5,"price":3800.1,"direction":"sell","ts":1546300800123}]}}`))
+	w.Close()
+	assert.Nil(t, server.Send(websocket.BinaryMessage, buf.Bytes()))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case trade := <-trades:
+			assert.Equal(t, goex.Trade{BigId: "10001", Type: goex.SELL, Amount: 0.5, Price: 3800.1, Date: 1546300800123, Pair: goex.BTC_USDT}, *trade)
+		case <-time.After(time.Second):
+			t.Fatal("no trade received")
+		}
+	}
+
+	assert.Nil(t, hb.UnsubscribeTrade(goex.BTC_USDT))
+	msg, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"market.btcusdt.trade.detail","unsub":"market.btcusdt.trade.detail"}`, string(msg))
+}
+
+func TestHuoBiPro_GetTickerWithWs_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	hb := NewHuoBiPro(http.DefaultClient, "", "", "")
+	hb.wsUrl = server.WsUrl()
+	tickers := make(chan *goex.Ticker, 1)
+	err := hb.GetTickerWithWs(goex.BTC_USDT, func(ticker *goex.Ticker) {
+		tickers <- ticker
+	})
+	assert.Nil(t, err)
+	_, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"ch":"market.btcusdt.detail","ts":1546300800123,"tick":{"close":3800.1,"low":3700,"high":3900,"vol":12.5}}`))
+	w.Close()
+	assert.Nil(t, server.Send(websocket.BinaryMessage, buf.Bytes()))
+
+	select {
+	case ticker := <-tickers:
+		//和rest接口一样, 单位秒
+		assert.Equal(t, goex.Ticker{Pair: goex.BTC_USDT, Last: 3800.1, Low: 3700, High: 3900, Vol: 12.5, Date: 1546300800}, *ticker)
+	case <-time.After(time.Second):
+		t.Fatal("no ticker received")
+	}
+}
+
+func TestHuoBiPro_RemoveWsHandle_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	hb := NewHuoBiPro(http.DefaultClient, "", "", "")
+	hb.wsUrl = server.WsUrl()
+	tickers1 := make(chan *goex.Ticker, 1)
+	tickers2 := make(chan *goex.Ticker, 1)
+	id1, err := hb.AddWsHandle(goex.UNSUB_TICKER, goex.BTC_USDT, 0, func(ticker *goex.Ticker) { tickers1 <- ticker })
+	assert.Nil(t, err)
+	id2, err := hb.AddWsHandle(goex.UNSUB_TICKER, goex.BTC_USDT, 0, func(ticker *goex.Ticker) { tickers2 <- ticker })
+	assert.Nil(t, err)
+	_, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+
+	//删除一个handle不影响其他handle, 也不取消交易所的订阅
+	assert.Nil(t, hb.RemoveWsHandle(id1))
+	_, err = server.NextMessage(100 * time.Millisecond)
+	assert.NotNil(t, err)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"ch":"market.btcusdt.detail","ts":1546300800123,"tick":{"close":3800.1}}`))
+	w.Close()
+	assert.Nil(t, server.Send(websocket.BinaryMessage, buf.Bytes()))
+
+	select {
+	case ticker := <-tickers2:
+		assert.Equal(t, 3800.1, ticker.Last)
+	case <-time.After(time.Second):
+		t.Fatal("no ticker received")
+	}
+	assert.Len(t, tickers1, 0)
+
+	assert.Nil(t, hb.RemoveWsHandle(id2))
+	msg, err := server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"market.btcusdt.detail","unsub":"market.btcusdt.detail"}`, string(msg))
+}
+
 func TestHuoBiPro_getPairFromChannel(t *testing.T) {
 
 	var ch string
@@ -159,7 +260,7 @@ func TestHuoBiPro_getPairFromChannel(t *testing.T) {
 func TestHuobiPro_GetTickerWithWs(t *testing.T) {
 	return
 	hbpro.GetTickerWithWs(goex.BTC_USDT, func(ticker *goex.Ticker) {
-		log.Println("%+v", *ticker)
+		log.Printf("%+v", *ticker)
 	})
 	time.Sleep(time.Minute)
 }
@@ -167,7 +268,7 @@ func TestHuobiPro_GetTickerWithWs(t *testing.T) {
 func TestHuobiPro_GetKLineWithWs(t *testing.T) {
 	return
 	hbpro.GetKLineWithWs(goex.BTC_USDT, goex.KLINE_PERIOD_60MIN, func(kline *goex.Kline) {
-		log.Println("%+v", *kline)
+		log.Printf("%+v", *kline)
 	})
 	time.Sleep(time.Minute)
 }
@@ -178,7 +279,59 @@ func TestHuobiPro_GetCurrenciesList(t *testing.T) {
 }
 
 func TestHuobiPro_GetCurrenciesPrecision(t *testing.T) {
-	//return
+	t.Skip("需要访问交易所")
 	t.Log(hbpro.GetCurrenciesPrecision())
 
 }
+
+func TestHuobiPro_AccountId_Local(t *testing.T) {
+	accountsCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/account/accounts":
+			accountsCalls++
+			if accountsCalls == 1 {
+				w.Write([]byte(`{"status":"error","err-code":"base-system-error","err-msg":"system busy"}`))
+				return
+			}
+			w.Write([]byte(`{"status":"ok","data":[{"id":100001,"type":"otc","state":"working"},{"id":100009,"type":"spot","state":"working"}]}`))
+		case "/v1/account/accounts/100009/balance":
+			w.Write([]byte(`{"status":"ok","data":{"id":100009,"type":"spot","state":"working","list":[{"currency":"btc","type":"trade","balance":"1.5"}]}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	//Build时不查询账户id
+	api, err := goex.NewExchangeAPI(goex.HUOBI_PRO, &goex.APIConfig{HttpClient: http.DefaultClient, ApiUrl: server.URL,
+		Credentials: goex.Credentials{AccessKey: "key", SecretKey: "secret"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, accountsCalls)
+
+	_, err = api.GetAccount()
+	assert.Error(t, err)
+	acc, err := api.GetAccount()
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, acc.SubAccounts[goex.BTC].Amount)
+	_, err = api.GetAccount()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, accountsCalls)
+}
+
+func TestHuobiPro_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return NewHuoBiPro(client, apikey, secretkey, "100009")
+	}, goextest.SpotFixtures{
+		Pair:        goex.BTC_USDT,
+		Ticker:      true,
+		DepthSize:   2,
+		Depth:       &goex.Depth{AskList: goex.DepthRecords{{Price: 3802.0, Amount: 2.5}, {Price: 3801.2, Amount: 0.3}}, BidList: goex.DepthRecords{{Price: 3800.5, Amount: 0.5}, {Price: 3800.1, Amount: 1.2}}},
+		KlinePeriod: goex.KLINE_PERIOD_1MIN,
+		KlineSize:   2,
+		Klines:      []goex.Kline{{Pair: goex.BTC_USDT, Timestamp: 1546300860, Open: 3801, Close: 3805.5, High: 3806, Low: 3800.2, Vol: 47560.3}},
+		Order: &goex.Order{OrderID2: "59378", Status: goex.ORDER_PART_FINISH, Side: goex.BUY, Amount: 2, DealAmount: 1.5,
+			OrderID: 59378, AvgPrice: 3798, Fee: 0.003},
+		UnfinishOrders: true,
+		MissingOrderId: "1"})
+}
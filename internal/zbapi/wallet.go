@@ -0,0 +1,126 @@
+package zbapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/nntaoli-project/GoEx"
+	"log"
+	"strings"
+	"time"
+)
+
+/**
+ * ZB和EXX共用的充提接口解析, EXX的交易接口沿用ZB的参数, 返回格式和状态码
+ * 签名和错误码由各自的交易所实现
+ */
+
+//充提接口code不是1000时返回adaptError的错误, 成功时返回message.datas
+func ParseWalletResponse(resp []byte, adaptError func(code int, resp string) ApiError) (map[string]interface{}, error) {
+	respMap := make(map[string]interface{})
+	err := json.Unmarshal(resp, &respMap)
+	if err != nil {
+		log.Println(err, string(resp))
+		return nil, err
+	}
+
+	if ToInt(respMap["code"]) != 1000 {
+		return nil, adaptError(ToInt(respMap["code"]), string(resp))
+	}
+
+	message, _ := respMap["message"].(map[string]interface{})
+	datas, ok := message["datas"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New(string(resp))
+	}
+	return datas, nil
+}
+
+//需要memo的币种,返回的地址格式为 地址_memo
+func ParseDepositAddress(currency Currency, datas map[string]interface{}) *DepositAddress {
+	addr := &DepositAddress{Currency: currency}
+	key, _ := datas["key"].(string)
+	if i := strings.LastIndex(key, "_"); i > 0 {
+		addr.Address, addr.Tag = key[:i], key[i+1:]
+	} else {
+		addr.Address = key
+	}
+	return addr
+}
+
+func ParseDeposits(currency Currency, datas map[string]interface{}) []Deposit {
+	list, _ := datas["list"].([]interface{})
+	deposits := make([]Deposit, 0, len(list))
+	for _, v := range list {
+		record := v.(map[string]interface{})
+		address, _ := record["address"].(string)
+		txid, _ := record["hash"].(string)
+		deposits = append(deposits, Deposit{
+			Id:            fmt.Sprint(ToUint64(record["id"])),
+			Currency:      currency,
+			Amount:        ToFloat64(record["amount"]),
+			Address:       address,
+			TxId:          txid,
+			Confirmations: ToInt(record["confirmTimes"]),
+			Status:        ParseDepositStatus(ToInt(record["status"])),
+			Time:          ParseRecordTime(record["submit_time"])})
+	}
+	return deposits
+}
+
+func ParseWithdrawals(currency Currency, datas map[string]interface{}) []Withdrawal {
+	list, _ := datas["list"].([]interface{})
+	withdrawals := make([]Withdrawal, 0, len(list))
+	for _, v := range list {
+		record := v.(map[string]interface{})
+		address, _ := record["toAddress"].(string)
+		withdrawals = append(withdrawals, Withdrawal{
+			Id:       fmt.Sprint(ToUint64(record["id"])),
+			Currency: currency,
+			Amount:   ToFloat64(record["amount"]),
+			Fee:      ToFloat64(record["fees"]),
+			Address:  address,
+			Status:   ParseWithdrawStatus(ToInt(record["status"])),
+			Time:     ParseRecordTime(record["submitTime"])})
+	}
+	return withdrawals
+}
+
+//充值状态 0:确认中 1:失败 2:成功
+func ParseDepositStatus(status int) DepositStatus {
+	switch status {
+	case 1:
+		return DEPOSIT_FAILED
+	case 2:
+		return DEPOSIT_SUCCESS
+	default:
+		return DEPOSIT_PENDING
+	}
+}
+
+//提现状态 0:提交 1:失败 2:成功 3:取消 5:转账中
+func ParseWithdrawStatus(status int) WithdrawStatus {
+	switch status {
+	case 1:
+		return WITHDRAW_FAILED
+	case 2:
+		return WITHDRAW_SUCCESS
+	case 3:
+		return WITHDRAW_CANCELED
+	case 5:
+		return WITHDRAW_PROCESSING
+	default:
+		return WITHDRAW_PENDING
+	}
+}
+
+//时间可能是毫秒时间戳或北京时间 2006-01-02 15:04:05
+func ParseRecordTime(v interface{}) int64 {
+	if s, ok := v.(string); ok {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.FixedZone("CST", 8*3600))
+		if err == nil {
+			return t.UnixNano() / int64(time.Millisecond)
+		}
+	}
+	return int64(ToUint64(v))
+}
@@ -0,0 +1,29 @@
+package zbapi
+
+import (
+	"github.com/nntaoli-project/GoEx"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseWalletRecord(t *testing.T) {
+	if ParseDepositStatus(2) != goex.DEPOSIT_SUCCESS || ParseWithdrawStatus(5) != goex.WITHDRAW_PROCESSING {
+		t.Fatal("wrong status")
+	}
+	if ParseRecordTime("2016-12-07 18:51:57") != 1481107917000 || ParseRecordTime(1461579288000.0) != 1461579288000 {
+		t.Fatal("wrong time")
+	}
+}
+
+func TestParseWalletResponse(t *testing.T) {
+	adaptError := func(code int, resp string) goex.ApiError {
+		return goex.EX_ERR_API_LIMIT.OriginErr(resp)
+	}
+
+	datas, err := ParseWalletResponse([]byte(`{"code":1000,"message":{"des":"success","isSuc":true,"datas":{"key":"rXXXX_12345"}}}`), adaptError)
+	assert.Nil(t, err)
+	assert.Equal(t, &goex.DepositAddress{Currency: goex.XRP, Address: "rXXXX", Tag: "12345"}, ParseDepositAddress(goex.XRP, datas))
+
+	_, err = ParseWalletResponse([]byte(`{"code":4002,"message":"too frequent"}`), adaptError)
+	assert.True(t, goex.IsRateLimited(err))
+}
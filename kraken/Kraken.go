@@ -6,12 +6,12 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -29,7 +29,8 @@ type NewOrderResponse struct {
 type Kraken struct {
 	httpClient *http.Client
 	accessKey,
-	secretKey string
+	secretKey,
+	apiDomain string
 }
 
 var (
@@ -40,8 +41,16 @@ var (
 	PRIVATE    = "private/"
 )
 
+func init() {
+	RegisterExchange(KRAKEN, func(config *APIConfig) API {
+		k := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		k.apiDomain = OverrideUrl(k.apiDomain, config.ApiUrl)
+		return k
+	})
+}
+
 func New(client *http.Client, accesskey, secretkey string) *Kraken {
-	return &Kraken{client, accesskey, secretkey}
+	return &Kraken{client, accesskey, secretkey, API_DOMAIN}
 }
 
 func (k *Kraken) placeOrder(orderType, side, amount, price string, pair CurrencyPair) (*Order, error) {
@@ -60,6 +69,9 @@ func (k *Kraken) placeOrder(orderType, side, amount, price string, pair Currency
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.TxIds) == 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr(fmt.Sprintf("no txid in response: %v", resp.Description))
+	}
 
 	var tradeSide TradeSide = SELL
 	if "buy" == side {
@@ -75,6 +87,75 @@ func (k *Kraken) placeOrder(orderType, side, amount, price string, pair Currency
 		Status:   ORDER_UNFINISH}, nil
 }
 
+//ClientOrderID对应userref,只能是32位整数; 不支持FOK
+func (k *Kraken) PlaceOrder(req OrderRequest) (*Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("pair", k.convertPair(req.Currency).ToSymbol(""))
+	params.Set("type", "buy")
+	if req.Side == SELL {
+		params.Set("type", "sell")
+	}
+	params.Set("volume", req.Amount)
+
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+		params.Set("ordertype", "limit")
+		params.Set("price", req.Price)
+	case ORDER_TYPE_MARKET:
+		params.Set("ordertype", "market")
+	case ORDER_TYPE_STOP_LIMIT:
+		params.Set("ordertype", "stop-loss-limit")
+		params.Set("price", req.StopPrice)
+		params.Set("price2", req.Price)
+	case ORDER_TYPE_STOP_MARKET:
+		params.Set("ordertype", "stop-loss")
+		params.Set("price", req.StopPrice)
+	}
+
+	switch req.TimeInForce {
+	case TIME_IN_FORCE_IOC:
+		params.Set("timeinforce", "IOC")
+	case TIME_IN_FORCE_POST_ONLY:
+		if req.Type != ORDER_TYPE_LIMIT {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+		params.Set("oflags", "post")
+	case TIME_IN_FORCE_FOK:
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	if req.ClientOrderID != "" {
+		if _, err := strconv.ParseInt(req.ClientOrderID, 10, 32); err != nil {
+			return nil, EX_ERR_NOT_SUPPORTED.OriginErr("kraken client order id must be int32")
+		}
+		params.Set("userref", req.ClientOrderID)
+	}
+
+	var resp NewOrderResponse
+	err := k.doAuthenticatedRequest("POST", "private/AddOrder", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.TxIds) == 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr(fmt.Sprintf("no txid in response: %v", resp.Description))
+	}
+
+	return &Order{
+		Currency:      req.Currency,
+		OrderID2:      resp.TxIds[0],
+		Amount:        ToFloat64(req.Amount),
+		Price:         ToFloat64(req.Price),
+		Side:          req.Side,
+		Status:        ORDER_UNFINISH,
+		Type:          req.Type,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: req.ClientOrderID}, nil
+}
+
 func (k *Kraken) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
 	return k.placeOrder("limit", "buy", amount, price, currency)
 }
@@ -148,7 +229,7 @@ func (k *Kraken) GetOneOrder(orderId string, currency CurrencyPair) (*Order, err
 	}
 
 	if len(orders) == 0 {
-		return nil, errors.New("not fund the order " + orderId)
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("not fund the order " + orderId)
 	}
 
 	ord := &orders[0]
@@ -166,7 +247,7 @@ func (k *Kraken) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 		return nil, err
 	}
 
-	var orders []Order
+	orders := make([]Order, 0, len(result.Open))
 
 	for txid, v := range result.Open {
 		ord := k.toOrder(v)
@@ -179,7 +260,7 @@ func (k *Kraken) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (k *Kraken) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (k *Kraken) GetAccount() (*Account, error) {
@@ -225,6 +306,7 @@ func (k *Kraken) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	}
 
 	ticker := new(Ticker)
+	ticker.Pair = currency
 	for _, t := range resultmap {
 		tickermap := t.(map[string]interface{})
 		ticker.Last = ToFloat64(tickermap["c"].([]interface{})[0])
@@ -247,7 +329,7 @@ func (k *Kraken) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	}
 
 	//log.Println(respmap)
-	dep := Depth{}
+	dep := Depth{Pair: currency}
 	for _, d := range resultmap {
 		depmap := d.(map[string]interface{})
 		asksmap := depmap["asks"].([]interface{})
@@ -269,18 +351,100 @@ func (k *Kraken) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (k *Kraken) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (k *Kraken) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+func (k *Kraken) GetMarkets() ([]Market, error) {
+	var resultmap map[string]interface{}
+	err := k.doAuthenticatedRequest("GET", "public/AssetPairs", url.Values{}, &resultmap)
+	if err != nil {
+		return nil, err
+	}
+
+	var markets []Market
+	for _, v := range resultmap {
+		pairmap := v.(map[string]interface{})
+		altname := pairmap["altname"].(string)
+		if strings.HasSuffix(altname, ".d") {
+			continue //暗池
+		}
+
+		m := Market{
+			Pair: NewCurrencyPair(k.convertMarketCurrency(pairmap["base"].(string)),
+				k.convertMarketCurrency(pairmap["quote"].(string))),
+			Symbol:          altname,
+			PricePrecision:  ToInt(pairmap["pair_decimals"]),
+			AmountPrecision: ToInt(pairmap["lot_decimals"]),
+			MinAmount:       ToFloat64(pairmap["ordermin"]),
+			Status:          MARKET_STATUS_TRADING}
+		m.TickSize = PrecisionToStep(m.PricePrecision)
+		m.LotSize = PrecisionToStep(m.AmountPrecision)
+		markets = append(markets, m)
+	}
+
+	return markets, nil
+}
+
+//TradesHistory不能按交易对查询,返回结果中过滤; 手续费以quote币计
+func (k *Kraken) GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	params := url.Values{}
+	if since > 0 {
+		params.Set("start", fmt.Sprint(since/1000))
+	}
+
+	var resultmap map[string]interface{}
+	err := k.doAuthenticatedRequest("POST", "private/TradesHistory", params, &resultmap)
+	if err != nil {
+		return nil, err
+	}
+
+	trades, _ := resultmap["trades"].(map[string]interface{})
+	var fills []Fill
+	for txid, v := range trades {
+		t := v.(map[string]interface{})
+		if k.toAltname(fmt.Sprint(t["pair"])) != k.convertPair(currency).ToSymbol("") {
+			continue
+		}
+		fill := Fill{
+			OrderID:     fmt.Sprint(t["ordertxid"]),
+			TradeID:     txid,
+			Pair:        currency,
+			Side:        k.convertSide(fmt.Sprint(t["type"])),
+			Price:       ToFloat64(t["price"]),
+			Amount:      ToFloat64(t["vol"]),
+			Fee:         ToFloat64(t["fee"]),
+			FeeCurrency: currency.CurrencyB,
+			Time:        int64(ToFloat64(t["time"]) * 1000)}
+		fill.IsMaker, _ = t["maker"].(bool)
+		fills = append(fills, fill)
+	}
+
+	sort.Slice(fills, func(i, j int) bool {
+		return fills[i].Time > fills[j].Time
+	})
+	if limit > 0 && len(fills) > limit {
+		fills = fills[:limit]
+	}
+	return fills, nil
 }
 
 func (k *Kraken) GetExchangeName() string {
 	return KRAKEN
 }
 
+func (k *Kraken) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (k *Kraken) buildParamsSigned(apiuri string, postForm *url.Values) string {
 	postForm.Set("nonce", fmt.Sprintf("%d", time.Now().UnixNano()))
 	urlPath := API_V0 + apiuri
@@ -314,7 +478,7 @@ func (k *Kraken) doAuthenticatedRequest(method, apiuri string, params url.Values
 		}
 	}
 
-	resp, err := NewHttpRequest(k.httpClient, method, API_DOMAIN+apiuri, params.Encode(), headers)
+	resp, err := NewHttpRequest(k.httpClient, method, k.apiDomain+apiuri, params.Encode(), headers)
 	if err != nil {
 		return err
 	}
@@ -330,12 +494,35 @@ func (k *Kraken) doAuthenticatedRequest(method, apiuri string, params url.Values
 	//println(string(resp))
 
 	if len(base.Error) > 0 {
-		return errors.New(base.Error[0])
+		return k.adaptError(base.Error[0])
 	}
 
 	return nil
 }
 
+//错误格式是 <类别>:<描述>, 比如 EOrder:Insufficient funds
+func (k *Kraken) adaptError(errMsg string) ApiError {
+	apiErr := API_ERR
+	switch errMsg {
+	case "EAPI:Invalid key":
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case "EAPI:Invalid signature":
+		apiErr = EX_ERR_SIGN
+	case "EAPI:Rate limit exceeded", "EOrder:Rate limit exceeded":
+		apiErr = EX_ERR_API_LIMIT
+	case "EOrder:Insufficient funds":
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case "EOrder:Unknown order":
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case "EOrder:Order minimum not met":
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case "EQuery:Unknown asset pair":
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	}
+	apiErr.OriginErrMsg = errMsg
+	return apiErr.OriginErr(errMsg)
+}
+
 func (k *Kraken) convertCurrency(currencySymbol string) Currency {
 	if len(currencySymbol) >= 4 {
 		currencySymbol = strings.Replace(currencySymbol, "X", "", 1)
@@ -344,6 +531,14 @@ func (k *Kraken) convertCurrency(currencySymbol string) Currency {
 	return NewCurrency(currencySymbol, "")
 }
 
+func (k *Kraken) convertMarketCurrency(currencySymbol string) Currency {
+	currency := k.convertCurrency(currencySymbol)
+	if currency == XBT {
+		return BTC
+	}
+	return currency
+}
+
 func (k *Kraken) convertPair(pair CurrencyPair) CurrencyPair {
 	if "BTC" == pair.CurrencyA.Symbol {
 		return NewCurrencyPair(XBT, pair.CurrencyB)
@@ -356,6 +551,14 @@ func (k *Kraken) convertPair(pair CurrencyPair) CurrencyPair {
 	return pair
 }
 
+// XXBTZUSD --> XBTUSD
+func (k *Kraken) toAltname(pairName string) string {
+	if len(pairName) == 8 && strings.ContainsAny(pairName[0:1], "XZ") && strings.ContainsAny(pairName[4:5], "XZ") {
+		return pairName[1:4] + pairName[5:8]
+	}
+	return pairName
+}
+
 func (k *Kraken) convertSide(typeS string) TradeSide {
 	switch typeS {
 	case "sell":
@@ -2,8 +2,10 @@ package kraken
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -61,3 +63,38 @@ func TestKraken_GetOneOrder(t *testing.T) {
 	assert.Nil(t, err)
 	t.Log(ord)
 }
+
+func TestKraken_toAltname(t *testing.T) {
+	assert.Equal(t, "XBTUSD", k.toAltname("XXBTZUSD"))
+	assert.Equal(t, "ETHXBT", k.toAltname("XETHXXBT"))
+	assert.Equal(t, "DOTUSD", k.toAltname("DOTUSD"))
+}
+
+func TestKraken_PlaceOrder_EmptyTxId_Local(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/0/private/AddOrder", r.URL.Path)
+		w.Write([]byte(`{"error":[],"result":{"descr":{"order":"buy 0.5 XBTUSD @ limit 3800"},"txid":[]}}`))
+	}))
+	defer server.Close()
+
+	k := New(http.DefaultClient, "", "")
+	k.apiDomain = server.URL + API_V0
+	_, err := k.LimitBuy("0.5", "3800", goex.BTC_USD)
+	assert.Equal(t, goex.EX_ERR_PLACE_ORDER_FAIL.ErrCode, err.(goex.ApiError).ErrCode)
+	_, err = k.PlaceOrder(goex.OrderRequest{Currency: goex.BTC_USD, Side: goex.SELL, Type: goex.ORDER_TYPE_LIMIT, Amount: "0.5", Price: "3800"})
+	assert.Equal(t, goex.EX_ERR_PLACE_ORDER_FAIL.ErrCode, err.(goex.ApiError).ErrCode)
+}
+
+func TestKraken_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return New(client, "", "")
+	}, goextest.SpotFixtures{
+		Pair:      goex.BTC_USD,
+		Ticker:    true,
+		DepthSize: 2,
+		Depth:     &goex.Depth{AskList: goex.DepthRecords{{Price: 3802.5, Amount: 0.8}, {Price: 3801.9, Amount: 1.1}}, BidList: goex.DepthRecords{{Price: 3800, Amount: 0.4}, {Price: 3799.5, Amount: 2}}},
+		Order: &goex.Order{OrderID2: "OBCMZD-JIEE7-77TH3F", Status: goex.ORDER_FINISH, Side: goex.BUY, Amount: 0.5, DealAmount: 0.5,
+			Price: 3800, AvgPrice: 3798, Fee: 3.0384},
+		UnfinishOrders: true,
+		MissingOrderId: "OXXXXX-XXXXX-XXXXXX"})
+}
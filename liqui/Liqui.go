@@ -22,23 +22,37 @@ type Liqui struct {
 	accessKey,
 	secretKey string
 	httpClient *http.Client
+	apiV1      string
+}
+
+func init() {
+	RegisterExchange(EXCHANGE_NAME, func(config *APIConfig) API {
+		liqui := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		liqui.apiV1 = OverrideUrl(liqui.apiV1, config.ApiUrl)
+		return liqui
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey string) *Liqui {
-	return &Liqui{accessKey, secretKey, client}
+	return &Liqui{accessKey, secretKey, client, API_V1}
 }
 
 func (liqui *Liqui) GetExchangeName() string {
 	return EXCHANGE_NAME
 }
 
+func (liqui *Liqui) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
 func (liqui *Liqui) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	cur := strings.ToLower(currency.ToSymbol("_"))
 	if cur == "nil" {
 		log.Println("Unsupport The CurrencyPair")
 		return nil, errors.New("Unsupport The CurrencyPair")
 	}
-	tickerUri := API_V1 + fmt.Sprintf(TICKER_URI, cur)
+	tickerUri := liqui.apiV1 + fmt.Sprintf(TICKER_URI, cur)
 	bodyDataMap, err := HttpGet(liqui.httpClient, tickerUri)
 	//fmt.Println("tickerUri:", tickerUri)
 	//fmt.Println("Liqui bodyDataMap:", bodyDataMap)
@@ -54,6 +68,15 @@ func (liqui *Liqui) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	case map[string]interface{}:
 		tickerMap = bodyDataMap[cur].(map[string]interface{})
 	default:
+		if errmsg, isok := bodyDataMap["error"].(string); isok {
+			//和wex一样是 {"success":0,"error":"Invalid pair name: xxx"}
+			apiErr := API_ERR
+			if strings.HasPrefix(errmsg, "Invalid pair name") {
+				apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+			}
+			apiErr.OriginErrMsg = errmsg
+			return nil, apiErr.OriginErr(errmsg)
+		}
 		return nil, errors.New(fmt.Sprintf("Type Convert Error ? \n %s", bodyDataMap))
 	}
 
@@ -70,43 +93,43 @@ func (liqui *Liqui) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	return &ticker, nil
 }
 
-func (liqui *Liqui) GetDepth() string {
-	panic("not implements")
+func (liqui *Liqui) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) GetAccount() string {
-	panic("not implements")
+func (liqui *Liqui) GetAccount() (*Account, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) LimitBuy() string {
-	panic("not implements")
+func (liqui *Liqui) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) LimitSell() string {
-	panic("not implements")
+func (liqui *Liqui) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) MarketBuy() string {
-	panic("not implements")
+func (liqui *Liqui) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) MarketSell() string {
-	panic("not implements")
+func (liqui *Liqui) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) CancelOrder() string {
-	panic("not implements")
+func (liqui *Liqui) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	return false, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) GetOneOrder() string {
-	panic("not implements")
+func (liqui *Liqui) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
-func (liqui *Liqui) GetUnfinishOrders() string {
-	panic("not implements")
+func (liqui *Liqui) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (liqui *Liqui) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (liqui *Liqui) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (liqui *Liqui) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
@@ -10,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -40,19 +41,36 @@ const (
 type Ocx struct {
 	httpClient *http.Client
 	accessKey,
-	secretKey string
+	secretKey,
+	baseUrl string
+}
+
+func init() {
+	RegisterExchange("ocx.com", func(config *APIConfig) API {
+		o := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		o.baseUrl = OverrideUrl(o.baseUrl, config.ApiUrl)
+		return o
+	})
 }
 
 func New(client *http.Client, apikey, secretkey string) *Ocx {
-	return &Ocx{accessKey: apikey, secretKey: secretkey, httpClient: client}
+	return &Ocx{accessKey: apikey, secretKey: secretkey, httpClient: client, baseUrl: API_BASE_URL}
 }
 
 func (o *Ocx) GetExchangeName() string {
 	return "ocx.com"
 }
 
+func (o *Ocx) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (o *Ocx) GetServerTime() int64 {
-	url := API_BASE_URL + V2 + SERVER_TIME
+	url := o.baseUrl + V2 + SERVER_TIME
 	respmap, err := HttpGet(o.httpClient, url)
 	if err != nil {
 		return 0
@@ -67,7 +85,7 @@ func (o *Ocx) GetServerTime() int64 {
 }
 
 func (o *Ocx) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
-	url := API_BASE_URL + V2 + fmt.Sprintf(TICKER_API, strings.ToLower(currencyPair.ToSymbol("")))
+	url := o.baseUrl + V2 + fmt.Sprintf(TICKER_API, strings.ToLower(currencyPair.ToSymbol("")))
 	respmap, err := HttpGet(o.httpClient, url)
 	if err != nil {
 		return nil, err
@@ -80,7 +98,7 @@ func (o *Ocx) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 	ticker := new(Ticker)
 	ticker.Pair = currencyPair
-	ticker.Date = ToUint64(tickmap["timestamp"])
+	ticker.Date = ToUnixSecond(ToUint64(tickmap["timestamp"]))
 	ticker.Last = ToFloat64(tickmap["last"])
 	ticker.Vol = ToFloat64(tickmap["volume"])
 	ticker.Low = ToFloat64(tickmap["low"])
@@ -93,7 +111,7 @@ func (o *Ocx) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 }
 
 func (o *Ocx) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	url := API_BASE_URL + V2 + fmt.Sprintf(DEPTH_API, strings.ToLower(currency.ToSymbol("")))
+	url := o.baseUrl + V2 + fmt.Sprintf(DEPTH_API, strings.ToLower(currency.ToSymbol("")))
 	resp, err := HttpGet(o.httpClient, url)
 	if err != nil {
 		return nil, err
@@ -124,6 +142,9 @@ func (o *Ocx) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 		depth.BidList = append(depth.BidList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return depth, nil
 }
 
@@ -154,7 +175,7 @@ func (o *Ocx) buildSigned(method, apiurl string, para *url.Values) string {
 }
 
 func (o *Ocx) placeOrder(orderType, orderSide, amount, price string, pair CurrencyPair) (*Order, error) {
-	uri := API_BASE_URL + V2 + TRADE_URL
+	uri := o.baseUrl + V2 + TRADE_URL
 	method := "POST"
 	path := V2 + TRADE_URL
 	params := url.Values{}
@@ -171,7 +192,7 @@ func (o *Ocx) placeOrder(orderType, orderSide, amount, price string, pair Curren
 	//resp, err := HttpPostForm3(o.httpClient, uri, form.Encode(), nil)
 	log.Println("resp:", string(resp), "err:", err)
 	if err != nil {
-		return nil, err
+		return nil, o.adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -183,7 +204,7 @@ func (o *Ocx) placeOrder(orderType, orderSide, amount, price string, pair Curren
 
 	orderId := ToInt(respmap["orderId"])
 	if orderId <= 0 {
-		return nil, errors.New(string(resp))
+		return nil, o.adaptErrorResp(resp)
 	}
 
 	side := BUY
@@ -215,14 +236,14 @@ func (o *Ocx) LimitSell(amount, price string, currency CurrencyPair) (*Order, er
 }
 
 func (o *Ocx) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (o *Ocx) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (o *Ocx) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	path := API_BASE_URL + V2 + fmt.Sprintf(CANCEL_ORDER_API, strings.ToLower(currency.ToSymbol("")))
+	path := o.baseUrl + V2 + fmt.Sprintf(CANCEL_ORDER_API, strings.ToLower(currency.ToSymbol("")))
 	params := url.Values{}
 
 	params.Set("order_id", orderId)
@@ -232,11 +253,14 @@ func (o *Ocx) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
 
 	resp, err := HttpPostForm2(o.httpClient, path, params, nil)
 	log.Println("resp:", string(resp), "err:", err)
+	if err != nil {
+		return false, o.adaptError(err)
+	}
 	return true, nil
 }
 
 func (o *Ocx) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	path := API_BASE_URL + V2 + fmt.Sprintf(GET_ORDER_API, orderId)
+	path := o.baseUrl + V2 + fmt.Sprintf(GET_ORDER_API, orderId)
 	para := url.Values{}
 	para.Set("order_id", orderId)
 
@@ -254,21 +278,20 @@ func (o *Ocx) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error)
 
 }
 
-func (o *Ocx) GetOrdersList() {
-	panic("not implement")
-
+func (o *Ocx) GetOrdersList() ([]Order, error) {
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (o *Ocx) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (o *Ocx) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (o *Ocx) GetAccount() (*Account, error) {
-	url := API_BASE_URL + V2 + GET_ACCOUNT_API
+	url := o.baseUrl + V2 + GET_ACCOUNT_API
 	//timestamp := strconv.FormatInt((time.Now().UnixNano() / 1000000), 10)
 
 	//sign := o.buildSigned("GET", url, nil)
@@ -281,8 +304,8 @@ func (o *Ocx) GetAccount() (*Account, error) {
 
 	log.Println(respmap)
 
-	if respmap["status"].(float64) != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+	if ToInt(respmap["status"]) != 0 {
+		return nil, o.adaptErrorMap(respmap)
 	}
 
 	acc := new(Account)
@@ -306,10 +329,42 @@ func (o *Ocx) GetAccount() (*Account, error) {
 }
 
 func (o *Ocx) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (o *Ocx) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+//非200响应的body中有错误信息, 限频时保留HttpError
+func (o *Ocx) adaptError(err error) error {
+	httpErr, ok := err.(HttpError)
+	if !ok || IsRateLimited(err) {
+		return err
+	}
+	return o.adaptErrorResp([]byte(httpErr.Body))
+}
+
+func (o *Ocx) adaptErrorResp(resp []byte) error {
+	var respmap map[string]interface{}
+	if json.Unmarshal(resp, &respmap) != nil || (respmap["error"] == nil && ToInt(respmap["status"]) == 0) {
+		return errors.New(string(resp))
+	}
+	return o.adaptErrorMap(respmap)
+}
+
+/**
+ * 错误是peatio的格式 {"error":{"code":2004,"message":"..."}}, 部分接口是 {"status":1,"msg":"..."}
+ * 错误码没有确认过, 不映射到EX_ERR_*
+ */
+func (o *Ocx) adaptErrorMap(respmap map[string]interface{}) ApiError {
+	code, msg := respmap["status"], respmap["msg"]
+	if errmap, ok := respmap["error"].(map[string]interface{}); ok {
+		code, msg = errmap["code"], errmap["message"]
+	}
+
+	apiErr := API_ERR
+	apiErr.OriginErrMsg = fmt.Sprint(code)
+	return apiErr.OriginErr(fmt.Sprint(msg))
 }
@@ -1,6 +1,7 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +20,7 @@ const (
 
 	url_userinfo      = "userinfo.do"
 	url_trade         = "trade.do"
+	url_batch_trade   = "batch_trade.do"
 	url_cancel_order  = "cancel_order.do"
 	url_order_info    = "order_info.do"
 	url_orders_info   = "orders_info.do"
@@ -59,18 +61,26 @@ var _INERNAL_KLINE_PERIOD_CONVERTER = map[int]string{
 //	}
 //}
 
+func init() {
+	RegisterExchange(OKCOIN_CN, func(config *APIConfig) API {
+		ok := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		ok.api_base_url = OverrideUrl(ok.api_base_url, config.ApiUrl)
+		return ok
+	})
+}
+
 func New(client *http.Client, api_key, secret_key string) *OKCoinCN_API {
 	return &OKCoinCN_API{client, api_key, secret_key, "https://www.okex.com/api/v1/"}
 }
 
-func (ctx *OKCoinCN_API) buildPostForm(postForm *url.Values) error {
-	postForm.Set("api_key", ctx.api_key)
-	//postForm.Set("secret_key", ctx.secret_key);
+func (ok *OKCoinCN_API) buildPostForm(postForm *url.Values) error {
+	postForm.Set("api_key", ok.api_key)
+	//postForm.Set("secret_key", ok.secret_key);
 
 	payload := postForm.Encode()
-	payload = payload + "&secret_key=" + ctx.secret_key
+	payload = payload + "&secret_key=" + ok.secret_key
 
-	sign, err := GetParamMD5Sign(ctx.secret_key, payload)
+	sign, err := GetParamMD5Sign(ok.secret_key, payload)
 	if err != nil {
 		return err
 	}
@@ -80,7 +90,7 @@ func (ctx *OKCoinCN_API) buildPostForm(postForm *url.Values) error {
 	return nil
 }
 
-func (ctx *OKCoinCN_API) placeOrder(side, amount, price string, currency CurrencyPair) (*Order, error) {
+func (ok *OKCoinCN_API) placeOrder(ctx context.Context, side, amount, price string, currency CurrencyPair) (*Order, error) {
 	postData := url.Values{}
 	postData.Set("type", side)
 
@@ -92,12 +102,12 @@ func (ctx *OKCoinCN_API) placeOrder(side, amount, price string, currency Currenc
 	}
 	postData.Set("symbol", strings.ToLower(currency.ToSymbol("_")))
 
-	err := ctx.buildPostForm(&postData)
+	err := ok.buildPostForm(&postData)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_trade, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.api_base_url+url_trade, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +122,7 @@ func (ctx *OKCoinCN_API) placeOrder(side, amount, price string, currency Currenc
 	}
 
 	if err, isok := respMap["error_code"].(float64); isok {
-		return nil, errors.New(fmt.Sprint(err))
+		return nil, ok.errorWrapper(int(err))
 	}
 
 	order := new(Order)
@@ -133,30 +143,85 @@ func (ctx *OKCoinCN_API) placeOrder(side, amount, price string, currency Currenc
 	return order, nil
 }
 
-func (ctx *OKCoinCN_API) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ctx.placeOrder("buy", amount, price, currency)
+//v1接口只支持普通限价单和市价单,不支持自定义订单id; 市价单参数同MarketBuy,MarketSell
+func (ok *OKCoinCN_API) PlaceOrder(req OrderRequest) (*Order, error) {
+	return ok.PlaceOrderCtx(context.Background(), req)
+}
+
+func (ok *OKCoinCN_API) PlaceOrderCtx(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	if req.TimeInForce != TIME_IN_FORCE_GTC || req.ClientOrderID != "" {
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	side := "buy"
+	if req.Side == SELL {
+		side = "sell"
+	}
+
+	switch req.Type {
+	case ORDER_TYPE_LIMIT:
+	case ORDER_TYPE_MARKET:
+		side += "_market"
+	default:
+		return nil, EX_ERR_NOT_SUPPORTED
+	}
+
+	order, err := ok.placeOrder(ctx, side, req.Amount, req.Price, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	order.Side = req.Side
+	order.Type = req.Type
+	return order, nil
+}
+
+func (ok *OKCoinCN_API) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.LimitBuyCtx(context.Background(), amount, price, currency)
+}
+
+func (ok *OKCoinCN_API) LimitBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.placeOrder(ctx, "buy", amount, price, currency)
+}
+
+func (ok *OKCoinCN_API) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.LimitSellCtx(context.Background(), amount, price, currency)
+}
+
+func (ok *OKCoinCN_API) LimitSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.placeOrder(ctx, "sell", amount, price, currency)
+}
+
+func (ok *OKCoinCN_API) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.MarketBuyCtx(context.Background(), amount, price, currency)
+}
+
+func (ok *OKCoinCN_API) MarketBuyCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.placeOrder(ctx, "buy_market", amount, price, currency)
 }
 
-func (ctx *OKCoinCN_API) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ctx.placeOrder("sell", amount, price, currency)
+func (ok *OKCoinCN_API) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.MarketSellCtx(context.Background(), amount, price, currency)
 }
 
-func (ctx *OKCoinCN_API) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ctx.placeOrder("buy_market", amount, price, currency)
+func (ok *OKCoinCN_API) MarketSellCtx(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.placeOrder(ctx, "sell_market", amount, price, currency)
 }
 
-func (ctx *OKCoinCN_API) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ctx.placeOrder("sell_market", amount, price, currency)
+func (ok *OKCoinCN_API) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	return ok.CancelOrderCtx(context.Background(), orderId, currency)
 }
 
-func (ctx *OKCoinCN_API) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+func (ok *OKCoinCN_API) CancelOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
 	postData := url.Values{}
 	postData.Set("order_id", orderId)
 	postData.Set("symbol", strings.ToLower(currency.ToSymbol("_")))
 
-	ctx.buildPostForm(&postData)
+	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_cancel_order, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.api_base_url+url_cancel_order, postData)
 
 	if err != nil {
 		return false, err
@@ -170,20 +235,161 @@ func (ctx *OKCoinCN_API) CancelOrder(orderId string, currency CurrencyPair) (boo
 	}
 
 	if err, isok := respMap["error_code"].(float64); isok {
-		return false, errors.New(fmt.Sprint(err))
+		return false, ok.errorWrapper(int(err))
 	}
 
 	return true, nil
 }
 
-func (ctx *OKCoinCN_API) getOrders(orderId string, currency CurrencyPair) ([]Order, error) {
+/**
+ * batch_trade.do每次最多5个订单,只支持同一交易对的普通限价单,
+ * 其他情况返回EX_ERR_NOT_SUPPORTED,由goex.BatchPlaceOrders逐个下单
+ */
+func (ok *OKCoinCN_API) BatchPlaceOrders(reqs []OrderRequest) ([]BatchOrderResult, error) {
+	for _, req := range reqs {
+		if req.Type != ORDER_TYPE_LIMIT || req.TimeInForce != TIME_IN_FORCE_GTC ||
+			req.ClientOrderID != "" || req.Currency != reqs[0].Currency {
+			return nil, EX_ERR_NOT_SUPPORTED
+		}
+	}
+
+	results := make([]BatchOrderResult, len(reqs))
+	for i := 0; i < len(reqs); i += 5 {
+		end := i + 5
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		ok.batchTrade(reqs[i:end], results[i:end])
+	}
+	return results, nil
+}
+
+func (ok *OKCoinCN_API) batchTrade(reqs []OrderRequest, results []BatchOrderResult) {
+	var ordersData []map[string]interface{}
+	for _, req := range reqs {
+		side := "buy"
+		if req.Side == SELL {
+			side = "sell"
+		}
+		ordersData = append(ordersData, map[string]interface{}{
+			"price":  req.Price,
+			"amount": req.Amount,
+			"type":   side})
+	}
+	ordersJson, _ := json.Marshal(ordersData)
+
+	postData := url.Values{}
+	postData.Set("symbol", strings.ToLower(reqs[0].Currency.ToSymbol("_")))
+	postData.Set("orders_data", string(ordersJson))
+	ok.buildPostForm(&postData)
+
+	var respMap map[string]interface{}
+	body, err := HttpPostForm(ok.client, ok.api_base_url+url_batch_trade, postData)
+	if err == nil {
+		err = json.Unmarshal(body, &respMap)
+	}
+	if err == nil {
+		if errCode, isok := respMap["error_code"].(float64); isok {
+			err = ok.errorWrapper(int(errCode))
+		}
+	}
+
+	orderInfos, _ := respMap["order_info"].([]interface{})
+	for i, req := range reqs {
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if i >= len(orderInfos) {
+			results[i].Err = EX_ERR_PLACE_ORDER_FAIL
+			continue
+		}
+
+		info := orderInfos[i].(map[string]interface{})
+		if errCode, isok := info["error_code"].(float64); isok {
+			results[i].Err = ok.errorWrapper(int(errCode))
+			continue
+		}
+
+		orderId := ToInt(info["order_id"])
+		results[i].Order = &Order{
+			OrderID:  orderId,
+			OrderID2: fmt.Sprint(orderId),
+			Price:    ToFloat64(req.Price),
+			Amount:   ToFloat64(req.Amount),
+			Currency: req.Currency,
+			Side:     req.Side,
+			Status:   ORDER_UNFINISH,
+			Type:     req.Type}
+	}
+}
+
+//cancel_order.do每次最多撤销3个订单,order_id用逗号分隔
+func (ok *OKCoinCN_API) BatchCancelOrders(currency CurrencyPair, orderIds []string) ([]BatchCancelResult, error) {
+	results := make([]BatchCancelResult, len(orderIds))
+	for i := 0; i < len(orderIds); i += 3 {
+		end := i + 3
+		if end > len(orderIds) {
+			end = len(orderIds)
+		}
+		ok.batchCancel(currency, orderIds[i:end], results[i:end])
+	}
+	return results, nil
+}
+
+func (ok *OKCoinCN_API) batchCancel(currency CurrencyPair, orderIds []string, results []BatchCancelResult) {
+	for i, id := range orderIds {
+		results[i].OrderID = id
+	}
+
+	if len(orderIds) == 1 {
+		_, results[0].Err = ok.CancelOrder(orderIds[0], currency)
+		return
+	}
+
+	postData := url.Values{}
+	postData.Set("order_id", strings.Join(orderIds, ","))
+	postData.Set("symbol", strings.ToLower(currency.ToSymbol("_")))
+	ok.buildPostForm(&postData)
+
+	var respMap map[string]interface{}
+	body, err := HttpPostForm(ok.client, ok.api_base_url+url_cancel_order, postData)
+	if err == nil {
+		err = json.Unmarshal(body, &respMap)
+	}
+	if err == nil {
+		if errCode, isok := respMap["error_code"].(float64); isok {
+			err = ok.errorWrapper(int(errCode))
+		}
+	}
+	if err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+		return
+	}
+
+	//{"success":"123,124","error":"125"}
+	failed := make(map[string]bool)
+	errIds, _ := respMap["error"].(string)
+	for _, id := range strings.Split(errIds, ",") {
+		failed[id] = true
+	}
+	for i, id := range orderIds {
+		if failed[id] {
+			results[i].Err = EX_ERR_CANCEL_ORDER_FAIL
+		}
+	}
+}
+
+func (ok *OKCoinCN_API) getOrders(ctx context.Context, orderId string, currency CurrencyPair) ([]Order, error) {
 	postData := url.Values{}
 	postData.Set("order_id", orderId)
 	postData.Set("symbol", strings.ToLower(currency.ToSymbol("_")))
 
-	ctx.buildPostForm(&postData)
+	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_order_info, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.api_base_url+url_order_info, postData)
 	//println(string(body))
 	if err != nil {
 		return nil, err
@@ -197,12 +403,12 @@ func (ctx *OKCoinCN_API) getOrders(orderId string, currency CurrencyPair) ([]Ord
 	}
 
 	if err, isok := respMap["error_code"].(float64); isok {
-		return nil, errors.New(fmt.Sprint(err))
+		return nil, ok.errorWrapper(int(err))
 	}
 
 	orders := respMap["orders"].([]interface{})
 
-	var orderAr []Order
+	orderAr := make([]Order, 0, len(orders))
 	for _, v := range orders {
 		orderMap := v.(map[string]interface{})
 
@@ -248,31 +454,43 @@ func (ctx *OKCoinCN_API) getOrders(orderId string, currency CurrencyPair) ([]Ord
 	return orderAr, nil
 }
 
-func (ctx *OKCoinCN_API) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	orderAr, err := ctx.getOrders(orderId, currency)
+func (ok *OKCoinCN_API) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	return ok.GetOneOrderCtx(context.Background(), orderId, currency)
+}
+
+func (ok *OKCoinCN_API) GetOneOrderCtx(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
+	orderAr, err := ok.getOrders(ctx, orderId, currency)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(orderAr) == 0 {
-		return nil, nil
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("not found the order " + orderId)
 	}
 
 	return &orderAr[0], nil
 }
 
-func (ctx *OKCoinCN_API) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return ctx.getOrders("-1", currency)
+func (ok *OKCoinCN_API) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	return ok.GetUnfinishOrdersCtx(context.Background(), currency)
+}
+
+func (ok *OKCoinCN_API) GetUnfinishOrdersCtx(ctx context.Context, currency CurrencyPair) ([]Order, error) {
+	return ok.getOrders(ctx, "-1", currency)
+}
+
+func (ok *OKCoinCN_API) GetAccount() (*Account, error) {
+	return ok.GetAccountCtx(context.Background())
 }
 
-func (ctx *OKCoinCN_API) GetAccount() (*Account, error) {
+func (ok *OKCoinCN_API) GetAccountCtx(ctx context.Context) (*Account, error) {
 	postData := url.Values{}
-	err := ctx.buildPostForm(&postData)
+	err := ok.buildPostForm(&postData)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_userinfo, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.api_base_url+url_userinfo, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -285,11 +503,11 @@ func (ctx *OKCoinCN_API) GetAccount() (*Account, error) {
 	}
 
 	if err, isok := respMap["error_code"].(float64); isok {
-		return nil, errors.New(fmt.Sprint(err))
+		return nil, ok.errorWrapper(int(err))
 	}
 
-	info, ok := respMap["info"].(map[string]interface{})
-	if !ok {
+	info, isok := respMap["info"].(map[string]interface{})
+	if !isok {
 		return nil, errors.New(string(body))
 	}
 
@@ -299,7 +517,7 @@ func (ctx *OKCoinCN_API) GetAccount() (*Account, error) {
 	freezed := funds["freezed"].(map[string]interface{})
 
 	account := new(Account)
-	account.Exchange = ctx.GetExchangeName()
+	account.Exchange = ok.GetExchangeName()
 	account.Asset, _ = strconv.ParseFloat(asset["total"].(string), 64)
 	account.NetAsset, _ = strconv.ParseFloat(asset["net"].(string), 64)
 
@@ -351,17 +569,22 @@ func (ctx *OKCoinCN_API) GetAccount() (*Account, error) {
 	return account, nil
 }
 
-func (ctx *OKCoinCN_API) GetTicker(currency CurrencyPair) (*Ticker, error) {
+func (ok *OKCoinCN_API) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	return ok.GetTickerCtx(context.Background(), currency)
+}
+
+func (ok *OKCoinCN_API) GetTickerCtx(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
 	var tickerMap map[string]interface{}
 	var ticker Ticker
 
-	url := ctx.api_base_url + url_ticker + "?symbol=" + strings.ToLower(currency.ToSymbol("_"))
-	bodyDataMap, err := HttpGet(ctx.client, url)
+	url := ok.api_base_url + url_ticker + "?symbol=" + strings.ToLower(currency.ToSymbol("_"))
+	bodyDataMap, err := HttpGetCtx(ctx, ok.client, url)
 	if err != nil {
 		return nil, err
 	}
 
 	tickerMap = bodyDataMap["ticker"].(map[string]interface{})
+	ticker.Pair = currency
 	ticker.Date, _ = strconv.ParseUint(bodyDataMap["date"].(string), 10, 64)
 	ticker.Last, _ = strconv.ParseFloat(tickerMap["last"].(string), 64)
 	ticker.Buy, _ = strconv.ParseFloat(tickerMap["buy"].(string), 64)
@@ -373,18 +596,22 @@ func (ctx *OKCoinCN_API) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	return &ticker, nil
 }
 
-func (ctx *OKCoinCN_API) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	var depth Depth
+func (ok *OKCoinCN_API) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	return ok.GetDepthCtx(context.Background(), size, currency)
+}
+
+func (ok *OKCoinCN_API) GetDepthCtx(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
+	depth := Depth{Pair: currency}
 
-	url := ctx.api_base_url + url_depth + "?symbol=" + strings.ToLower(currency.ToSymbol("_")) + "&size=" + strconv.Itoa(size)
+	url := ok.api_base_url + url_depth + "?symbol=" + strings.ToLower(currency.ToSymbol("_")) + "&size=" + strconv.Itoa(size)
 	//fmt.Println(url)
-	bodyDataMap, err := HttpGet(ctx.client, url)
+	bodyDataMap, err := HttpGetCtx(ctx, ok.client, url)
 	if err != nil {
 		return nil, err
 	}
 
 	if err, isok := bodyDataMap["error_code"].(float64); isok {
-		return nil, errors.New(fmt.Sprint(err))
+		return nil, ok.errorWrapper(int(err))
 	}
 
 	dep, isok := bodyDataMap["asks"].([]interface{})
@@ -421,20 +648,37 @@ func (ctx *OKCoinCN_API) GetDepth(size int, currency CurrencyPair) (*Depth, erro
 	return &depth, nil
 }
 
-func (ctx *OKCoinCN_API) GetExchangeName() string {
+func (ok *OKCoinCN_API) GetExchangeName() string {
 	return OKCOIN_CN
 }
 
-func (ctx *OKCoinCN_API) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
+func (ok *OKCoinCN_API) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Kline:        true,
+		Trades:       true,
+		Orders:       true,
+		OrderHistory: true,
+		Account:      true,
+		KlinePeriods: KlinePeriods(_INERNAL_KLINE_PERIOD_CONVERTER),
+		MaxDepthSize: 200}
+}
+
+func (ok *OKCoinCN_API) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
+	return ok.GetKlineRecordsCtx(context.Background(), currency, period, size, since)
+}
+
+func (ok *OKCoinCN_API) GetKlineRecordsCtx(ctx context.Context, currency CurrencyPair, period, size, since int) ([]Kline, error) {
 
-	klineUrl := ctx.api_base_url + fmt.Sprintf(url_kline,
+	klineUrl := ok.api_base_url + fmt.Sprintf(url_kline,
 		strings.ToLower(currency.ToSymbol("_")),
 		_INERNAL_KLINE_PERIOD_CONVERTER[period], size)
 	if since != -1 {
 		klineUrl += "&since=" + strconv.Itoa(since)
 	}
 
-	body, err := HttpGet5(ctx.client, klineUrl, nil)
+	body, err := HttpGet5Ctx(ctx, ok.client, klineUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -448,7 +692,7 @@ func (ctx *OKCoinCN_API) GetKlineRecords(currency CurrencyPair, period, size, si
 	var klineRecords []Kline
 
 	for _, record := range klines {
-		r := Kline{}
+		r := Kline{Pair: currency}
 		for i, e := range record {
 			switch i {
 			case 0:
@@ -471,8 +715,12 @@ func (ctx *OKCoinCN_API) GetKlineRecords(currency CurrencyPair, period, size, si
 	return klineRecords, nil
 }
 
-func (ctx *OKCoinCN_API) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	orderHistoryUrl := ctx.api_base_url + order_history_uri
+func (ok *OKCoinCN_API) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	return ok.GetOrderHistorysCtx(context.Background(), currency, currentPage, pageSize)
+}
+
+func (ok *OKCoinCN_API) GetOrderHistorysCtx(ctx context.Context, currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	orderHistoryUrl := ok.api_base_url + order_history_uri
 
 	postData := url.Values{}
 	postData.Set("status", "1")
@@ -480,12 +728,12 @@ func (ctx *OKCoinCN_API) GetOrderHistorys(currency CurrencyPair, currentPage, pa
 	postData.Set("current_page", fmt.Sprintf("%d", currentPage))
 	postData.Set("page_length", fmt.Sprintf("%d", pageSize))
 
-	err := ctx.buildPostForm(&postData)
+	err := ok.buildPostForm(&postData)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := HttpPostForm(ctx.client, orderHistoryUrl, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, orderHistoryUrl, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -498,7 +746,7 @@ func (ctx *OKCoinCN_API) GetOrderHistorys(currency CurrencyPair, currentPage, pa
 	}
 
 	if err, isok := respMap["error_code"].(float64); isok {
-		return nil, errors.New(fmt.Sprint(err))
+		return nil, ok.errorWrapper(int(err))
 	}
 
 	orders := respMap["orders"].([]interface{})
@@ -545,12 +793,16 @@ func (ctx *OKCoinCN_API) GetOrderHistorys(currency CurrencyPair, currentPage, pa
 }
 
 func (ok *OKCoinCN_API) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return ok.GetTradesCtx(context.Background(), currencyPair, since)
+}
+
+func (ok *OKCoinCN_API) GetTradesCtx(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	url := ok.api_base_url + url_trades + "?symbol=" + strings.ToLower(currencyPair.ToSymbol("_")) + "&since="
 	if since > 0 {
 		url = url + fmt.Sprintf("%d", since)
 	}
 
-	body, err := HttpGet5(ok.client, url, nil)
+	body, err := HttpGet5Ctx(ctx, ok.client, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -584,3 +836,31 @@ func (ok *OKCoinCN_API) GetTrades(currencyPair CurrencyPair, since int64) ([]Tra
 
 	return trades, nil
 }
+
+//https://github.com/okcoin-okex/API-docs-OKEx.com 现货错误码
+func (ok *OKCoinCN_API) errorWrapper(errorCode int) ApiError {
+	apiErr := API_ERR
+	switch errorCode {
+	case 10001:
+		apiErr = EX_ERR_API_LIMIT
+	case 10005:
+		apiErr = EX_ERR_NOT_FIND_SECRETKEY
+	case 10006:
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case 10007:
+		apiErr = EX_ERR_SIGN
+	case 10009, 1009:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case 10010, 10016, 10024, 1002:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case 10011, 1003:
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case 10012:
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case 1050:
+		apiErr = EX_ERR_CANCEL_ORDER_FAIL
+	}
+	errmsg := fmt.Sprintf("%d", errorCode)
+	apiErr.OriginErrMsg = errmsg
+	return apiErr.OriginErr(errmsg)
+}
@@ -2,6 +2,7 @@ package okcoin
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"net/http"
 	"testing"
 )
@@ -22,3 +23,23 @@ func TestOKCoinCN_API_GetKlineRecords(t *testing.T) {
 	klines, _ := okcn.GetKlineRecords(goex.BTC_USDT, goex.KLINE_PERIOD_1MIN, 1000, -1)
 	t.Log(klines)
 }
+
+func TestOKCoinCN_API_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return New(client, "", "")
+	}, goextest.SpotFixtures{
+		Pair:         goex.LTC_BTC,
+		Ticker:       true,
+		DepthSize:    2,
+		Depth:        &goex.Depth{AskList: goex.DepthRecords{{Price: 0.0082, Amount: 12.1}, {Price: 0.0081, Amount: 3.5}}, BidList: goex.DepthRecords{{Price: 0.008, Amount: 4.2}, {Price: 0.0079, Amount: 20}}},
+		KlinePeriod:  goex.KLINE_PERIOD_1MIN,
+		KlineSize:    2,
+		KlineSince:   -1,
+		Klines:       []goex.Kline{{Pair: goex.LTC_BTC, Timestamp: 1546300800, Open: 0.008, High: 0.0081, Low: 0.0079, Close: 0.008, Vol: 152.3}},
+		Trades:       true,
+		OrderHistory: true,
+		Order: &goex.Order{OrderID2: "10000591", Status: goex.ORDER_PART_FINISH, Side: goex.SELL, Amount: 10, DealAmount: 4,
+			AvgPrice: 0.00805},
+		UnfinishOrders: true,
+		MissingOrderId: "1"})
+}
@@ -1,8 +1,8 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	. "github.com/nntaoli-project/GoEx"
 	"net/http"
 	"net/url"
@@ -17,18 +17,30 @@ type OKCoinCOM_API struct {
 	OKCoinCN_API
 }
 
+func init() {
+	RegisterExchange(OKCOIN_COM, func(config *APIConfig) API {
+		ok := NewCOM(config.HttpClient, config.AccessKey, config.SecretKey)
+		ok.api_base_url = OverrideUrl(ok.api_base_url, config.ApiUrl)
+		return ok
+	})
+}
+
 func NewCOM(client *http.Client, api_key, secret_key string) *OKCoinCOM_API {
 	return &OKCoinCOM_API{OKCoinCN_API{client, api_key, secret_key, "https://www.okcoin.com/api/v1/"}}
 }
 
-func (ctx *OKCoinCOM_API) GetAccount() (*Account, error) {
+func (ok *OKCoinCOM_API) GetAccount() (*Account, error) {
+	return ok.GetAccountCtx(context.Background())
+}
+
+func (ok *OKCoinCOM_API) GetAccountCtx(ctx context.Context) (*Account, error) {
 	postData := url.Values{}
-	err := ctx.buildPostForm(&postData)
+	err := ok.buildPostForm(&postData)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_userinfo, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.api_base_url+url_userinfo, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -43,8 +55,7 @@ func (ctx *OKCoinCOM_API) GetAccount() (*Account, error) {
 	}
 
 	if !respMap["result"].(bool) {
-		errcode := strconv.FormatFloat(respMap["error_code"].(float64), 'f', 0, 64)
-		return nil, errors.New(errcode)
+		return nil, ok.errorWrapper(ToInt(respMap["error_code"]))
 	}
 
 	info := respMap["info"].(map[string]interface{})
@@ -54,7 +65,7 @@ func (ctx *OKCoinCOM_API) GetAccount() (*Account, error) {
 	freezed := funds["freezed"].(map[string]interface{})
 
 	account := new(Account)
-	account.Exchange = ctx.GetExchangeName()
+	account.Exchange = ok.GetExchangeName()
 	account.Asset, _ = strconv.ParseFloat(asset["total"].(string), 64)
 	account.NetAsset, _ = strconv.ParseFloat(asset["net"].(string), 64)
 
@@ -85,6 +96,6 @@ func (ctx *OKCoinCOM_API) GetAccount() (*Account, error) {
 	return account, nil
 }
 
-func (ctx *OKCoinCOM_API) GetExchangeName() string {
+func (ok *OKCoinCOM_API) GetExchangeName() string {
 	return OKCOIN_COM
 }
@@ -1,11 +1,11 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -16,6 +16,7 @@ import (
 
 const (
 	FUTURE_API_BASE_URL    = "https://www.okex.com/api/v1/"
+	OKEX_WS_URL            = "wss://real.okex.com:10440/ws/v1"
 	FUTURE_TICKER_URI      = "future_ticker.do?symbol=%s&contract_type=%s"
 	FUTURE_DEPTH_URI       = "future_depth.do?symbol=%s&contract_type=%s"
 	FUTURE_INDEX_PRICE     = "future_index.do?symbol=%s"
@@ -34,12 +35,23 @@ const (
 type OKEx struct {
 	apiKey,
 	apiSecretKey string
-	client            *http.Client
-	ws                *WsConn
-	createWsLock      sync.Mutex
-	wsTickerHandleMap map[string]func(*Ticker)
-	wsDepthHandleMap  map[string]func(*Depth)
-	wsTradeHandleMap  map[string]func(*Trade)
+	baseUrl,
+	wsUrl string
+	client        *http.Client
+	ws            *WsConn
+	wsDialOptions WsDialOptions
+	createWsLock  sync.Mutex
+	wsHandlers    *WsRegistry
+}
+
+func init() {
+	RegisterFutureExchange(OKEX_FUTURE, func(config *APIConfig) FutureRestAPI {
+		ok := NewOKEx(config.HttpClient, config.AccessKey, config.SecretKey)
+		ok.baseUrl = OverrideUrl(ok.baseUrl, config.ApiUrl)
+		ok.wsUrl = OverrideUrl(ok.wsUrl, config.WsUrl)
+		ok.wsDialOptions = config.WsDialOptions
+		return ok
+	})
 }
 
 func NewOKEx(client *http.Client, api_key, secret_key string) *OKEx {
@@ -47,6 +59,9 @@ func NewOKEx(client *http.Client, api_key, secret_key string) *OKEx {
 	ok.apiKey = api_key
 	ok.apiSecretKey = secret_key
 	ok.client = client
+	ok.baseUrl = FUTURE_API_BASE_URL
+	ok.wsUrl = OKEX_WS_URL
+	ok.wsHandlers = NewWsRegistry()
 	return ok
 }
 
@@ -74,15 +89,11 @@ func (ok *OKEx) GetExchangeName() string {
 }
 
 func (ok *OKEx) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
-	resp, err := ok.client.Get(fmt.Sprintf(FUTURE_API_BASE_URL+FUTURE_ESTIMATED_PRICE, strings.ToLower(currencyPair.ToSymbol("_"))))
-	if err != nil {
-		return 0, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	return ok.GetFutureEstimatedPriceCtx(context.Background(), currencyPair)
+}
 
+func (ok *OKEx) GetFutureEstimatedPriceCtx(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
+	body, err := HttpGet5Ctx(ctx, ok.client, fmt.Sprintf(ok.baseUrl+FUTURE_ESTIMATED_PRICE, strings.ToLower(currencyPair.ToSymbol("_"))), nil)
 	if err != nil {
 		return 0, err
 	}
@@ -99,17 +110,13 @@ func (ok *OKEx) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, err
 }
 
 func (ok *OKEx) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
-	url := FUTURE_API_BASE_URL + FUTURE_TICKER_URI
-	//fmt.Println(fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType));
-	resp, err := ok.client.Get(fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType))
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	return ok.GetFutureTickerCtx(context.Background(), currencyPair, contractType)
+}
 
+func (ok *OKEx) GetFutureTickerCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	url := ok.baseUrl + FUTURE_TICKER_URI
+	//fmt.Println(fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType));
+	body, err := HttpGet5Ctx(ctx, ok.client, fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +131,7 @@ func (ok *OKEx) GetFutureTicker(currencyPair CurrencyPair, contractType string)
 	}
 
 	if bodyMap["result"] != nil && !bodyMap["result"].(bool) {
-		return nil, errors.New(string(body))
+		return nil, ok.adaptError(bodyMap, body)
 	}
 
 	tickerMap := bodyMap["ticker"].(map[string]interface{})
@@ -143,17 +150,13 @@ func (ok *OKEx) GetFutureTicker(currencyPair CurrencyPair, contractType string)
 }
 
 func (ok *OKEx) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
-	url := FUTURE_API_BASE_URL + FUTURE_DEPTH_URI
-	//fmt.Println(fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType));
-	resp, err := ok.client.Get(fmt.Sprintf(url, strings.ToLower(strings.ToLower(currencyPair.ToSymbol("_"))), contractType))
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	return ok.GetFutureDepthCtx(context.Background(), currencyPair, contractType, size)
+}
 
+func (ok *OKEx) GetFutureDepthCtx(ctx context.Context, currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	url := ok.baseUrl + FUTURE_DEPTH_URI
+	//fmt.Println(fmt.Sprintf(url, strings.ToLower(currencyPair.ToSymbol("_")), contractType));
+	body, err := HttpGet5Ctx(ctx, ok.client, fmt.Sprintf(url, strings.ToLower(strings.ToLower(currencyPair.ToSymbol("_"))), contractType), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -169,7 +172,7 @@ func (ok *OKEx) GetFutureDepth(currencyPair CurrencyPair, contractType string, s
 
 	if bodyMap["error_code"] != nil {
 		log.Println(bodyMap)
-		return nil, errors.New(string(body))
+		return nil, ok.adaptError(bodyMap, body)
 	}
 
 	depth := new(Depth)
@@ -220,15 +223,11 @@ func (ok *OKEx) GetFutureDepth(currencyPair CurrencyPair, contractType string, s
 }
 
 func (ok *OKEx) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
-	resp, err := ok.client.Get(fmt.Sprintf(FUTURE_API_BASE_URL+FUTURE_INDEX_PRICE, strings.ToLower(currencyPair.ToSymbol("_"))))
-	if err != nil {
-		return 0, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	return ok.GetFutureIndexCtx(context.Background(), currencyPair)
+}
 
+func (ok *OKEx) GetFutureIndexCtx(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
+	body, err := HttpGet5Ctx(ctx, ok.client, fmt.Sprintf(ok.baseUrl+FUTURE_INDEX_PRICE, strings.ToLower(currencyPair.ToSymbol("_"))), nil)
 	if err != nil {
 		return 0, err
 	}
@@ -260,12 +259,16 @@ type futureUserInfoResponse struct {
 }
 
 func (ok *OKEx) GetFutureUserinfo() (*FutureAccount, error) {
-	userInfoUrl := FUTURE_API_BASE_URL + FUTURE_USERINFO_URI
+	return ok.GetFutureUserinfoCtx(context.Background())
+}
+
+func (ok *OKEx) GetFutureUserinfoCtx(ctx context.Context) (*FutureAccount, error) {
+	userInfoUrl := ok.baseUrl + FUTURE_USERINFO_URI
 
 	postData := url.Values{}
 	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ok.client, userInfoUrl, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, userInfoUrl, postData)
 
 	if err != nil {
 		return nil, err
@@ -307,6 +310,10 @@ func (ok *OKEx) GetFutureUserinfo() (*FutureAccount, error) {
 }
 
 func (ok *OKEx) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice, leverRate int) (string, error) {
+	return ok.PlaceFutureOrderCtx(context.Background(), currencyPair, contractType, price, amount, openType, matchPrice, leverRate)
+}
+
+func (ok *OKEx) PlaceFutureOrderCtx(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice, leverRate int) (string, error) {
 	postData := url.Values{}
 	postData.Set("symbol", strings.ToLower(currencyPair.ToSymbol("_")))
 	postData.Set("price", price)
@@ -318,8 +325,8 @@ func (ok *OKEx) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price,
 
 	ok.buildPostForm(&postData)
 
-	placeOrderUrl := FUTURE_API_BASE_URL + FUTURE_TRADE_URI
-	body, err := HttpPostForm(ok.client, placeOrderUrl, postData)
+	placeOrderUrl := ok.baseUrl + FUTURE_TRADE_URI
+	body, err := HttpPostFormCtx(ctx, ok.client, placeOrderUrl, postData)
 
 	if err != nil {
 		return "", err
@@ -334,13 +341,17 @@ func (ok *OKEx) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price,
 	//println(string(body));
 
 	if !respMap["result"].(bool) {
-		return "", errors.New(string(body))
+		return "", ok.adaptError(respMap, body)
 	}
 
 	return fmt.Sprintf("%.0f", respMap["order_id"].(float64)), nil
 }
 
 func (ok *OKEx) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	return ok.FutureCancelOrderCtx(context.Background(), currencyPair, contractType, orderId)
+}
+
+func (ok *OKEx) FutureCancelOrderCtx(ctx context.Context, currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
 	postData := url.Values{}
 	postData.Set("symbol", strings.ToLower(currencyPair.ToSymbol("_")))
 	postData.Set("order_id", orderId)
@@ -348,9 +359,9 @@ func (ok *OKEx) FutureCancelOrder(currencyPair CurrencyPair, contractType, order
 
 	ok.buildPostForm(&postData)
 
-	cancelUrl := FUTURE_API_BASE_URL + FUTURE_CANCEL_URI
+	cancelUrl := ok.baseUrl + FUTURE_CANCEL_URI
 
-	body, err := HttpPostForm(ok.client, cancelUrl, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, cancelUrl, postData)
 	if err != nil {
 		return false, err
 	}
@@ -362,14 +373,18 @@ func (ok *OKEx) FutureCancelOrder(currencyPair CurrencyPair, contractType, order
 	}
 
 	if respMap["result"] != nil && !respMap["result"].(bool) {
-		return false, errors.New(string(body))
+		return false, ok.adaptError(respMap, body)
 	}
 
 	return true, nil
 }
 
 func (ok *OKEx) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
-	positionUrl := FUTURE_API_BASE_URL + FUTURE_POSITION_URI
+	return ok.GetFuturePositionCtx(context.Background(), currencyPair, contractType)
+}
+
+func (ok *OKEx) GetFuturePositionCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	positionUrl := ok.baseUrl + FUTURE_POSITION_URI
 
 	postData := url.Values{}
 	postData.Set("contract_type", contractType)
@@ -377,7 +392,7 @@ func (ok *OKEx) GetFuturePosition(currencyPair CurrencyPair, contractType string
 
 	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ok.client, positionUrl, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, positionUrl, postData)
 
 	if err != nil {
 		return nil, err
@@ -391,7 +406,7 @@ func (ok *OKEx) GetFuturePosition(currencyPair CurrencyPair, contractType string
 	}
 
 	if !respMap["result"].(bool) {
-		return nil, errors.New(string(body))
+		return nil, ok.adaptError(respMap, body)
 	}
 
 	//println(string(body))
@@ -439,7 +454,7 @@ func (ok *OKEx) parseOrders(body []byte, currencyPair CurrencyPair) ([]FutureOrd
 	}
 
 	if !respMap["result"].(bool) {
-		return nil, errors.New(string(body))
+		return nil, ok.adaptError(respMap, body)
 	}
 
 	var orders []interface{}
@@ -482,13 +497,17 @@ func (ok *OKEx) parseOrders(body []byte, currencyPair CurrencyPair) ([]FutureOrd
 }
 
 func (ok *OKEx) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return ok.GetFutureOrdersCtx(context.Background(), orderIds, currencyPair, contractType)
+}
+
+func (ok *OKEx) GetFutureOrdersCtx(ctx context.Context, orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	postData := url.Values{}
 	postData.Set("order_id", strings.Join(orderIds, ","))
 	postData.Set("contract_type", contractType)
 	postData.Set("symbol", strings.ToLower(currencyPair.ToSymbol("_")))
 	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ok.client, FUTURE_API_BASE_URL+FUTURE_ORDERS_INFO_URI, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.baseUrl+FUTURE_ORDERS_INFO_URI, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -497,6 +516,10 @@ func (ok *OKEx) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, co
 }
 
 func (ok *OKEx) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return ok.GetUnfinishFutureOrdersCtx(context.Background(), currencyPair, contractType)
+}
+
+func (ok *OKEx) GetUnfinishFutureOrdersCtx(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	postData := url.Values{}
 	postData.Set("order_id", "-1")
 	postData.Set("contract_type", contractType)
@@ -507,7 +530,7 @@ func (ok *OKEx) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType
 
 	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ok.client, FUTURE_API_BASE_URL+FUTURE_ORDER_INFO_URI, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.baseUrl+FUTURE_ORDER_INFO_URI, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -518,6 +541,10 @@ func (ok *OKEx) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType
 }
 
 func (ok *OKEx) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	return ok.GetFutureOrderCtx(context.Background(), orderId, currencyPair, contractType)
+}
+
+func (ok *OKEx) GetFutureOrderCtx(ctx context.Context, orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
 	postData := url.Values{}
 	postData.Set("order_id", orderId)
 	postData.Set("contract_type", contractType)
@@ -528,7 +555,7 @@ func (ok *OKEx) GetFutureOrder(orderId string, currencyPair CurrencyPair, contra
 
 	ok.buildPostForm(&postData)
 
-	body, err := HttpPostForm(ok.client, FUTURE_API_BASE_URL+FUTURE_ORDER_INFO_URI, postData)
+	body, err := HttpPostFormCtx(ctx, ok.client, ok.baseUrl+FUTURE_ORDER_INFO_URI, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -539,7 +566,7 @@ func (ok *OKEx) GetFutureOrder(orderId string, currencyPair CurrencyPair, contra
 		return nil, err
 	}
 	if len(orders) == 0 {
-		return nil, errors.New("找不到订单")
+		return nil, EX_ERR_NOT_FIND_ORDER
 	}
 
 	return &orders[0], nil
@@ -550,7 +577,11 @@ func (ok *OKEx) GetFee() (float64, error) {
 }
 
 func (ok *OKEx) GetExchangeRate() (float64, error) {
-	respMap, err := HttpGet(ok.client, FUTURE_API_BASE_URL+_EXCHANGE_RATE_URI)
+	return ok.GetExchangeRateCtx(context.Background())
+}
+
+func (ok *OKEx) GetExchangeRateCtx(ctx context.Context) (float64, error) {
+	respMap, err := HttpGetCtx(ctx, ok.client, ok.baseUrl+_EXCHANGE_RATE_URI)
 
 	if err != nil {
 		log.Println(respMap)
@@ -585,6 +616,10 @@ func (ok *OKEx) GetDeliveryTime() (int, int, int, int) {
 }
 
 func (ok *OKEx) GetKlineRecords(contract_type string, currencyPair CurrencyPair, period, size, since int) ([]FutureKline, error) {
+	return ok.GetKlineRecordsCtx(context.Background(), contract_type, currencyPair, period, size, since)
+}
+
+func (ok *OKEx) GetKlineRecordsCtx(ctx context.Context, contract_type string, currencyPair CurrencyPair, period, size, since int) ([]FutureKline, error) {
 	params := url.Values{}
 	params.Set("symbol", strings.ToLower(currencyPair.ToSymbol("_")))
 	params.Set("type", _INERNAL_KLINE_PERIOD_CONVERTER[period])
@@ -592,13 +627,7 @@ func (ok *OKEx) GetKlineRecords(contract_type string, currencyPair CurrencyPair,
 	params.Set("size", fmt.Sprintf("%d", size))
 	params.Set("since", fmt.Sprintf("%d", since))
 	//log.Println(params.Encode())
-	resp, err := ok.client.Get(FUTURE_API_BASE_URL + _GET_KLINE_URI + "?" + params.Encode())
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := HttpGet5Ctx(ctx, ok.client, ok.baseUrl + _GET_KLINE_URI + "?" + params.Encode(), nil)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -641,13 +670,17 @@ func (ok *OKEx) GetKlineRecords(contract_type string, currencyPair CurrencyPair,
 }
 
 func (okFuture *OKEx) GetTrades(contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return okFuture.GetTradesCtx(context.Background(), contract_type, currencyPair, since)
+}
+
+func (okFuture *OKEx) GetTradesCtx(ctx context.Context, contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	params := url.Values{}
 	params.Set("symbol", strings.ToLower(currencyPair.ToSymbol("_")))
 	params.Set("contract_type", contract_type)
 	//log.Println(params.Encode())
 
-	url := FUTURE_API_BASE_URL + TRADES_URI + "?" + params.Encode()
-	body, err := HttpGet5(okFuture.client, url, nil)
+	url := okFuture.baseUrl + TRADES_URI + "?" + params.Encode()
+	body, err := HttpGet5Ctx(ctx, okFuture.client, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -681,6 +714,14 @@ func (okFuture *OKEx) GetTrades(contract_type string, currencyPair CurrencyPair,
 	return trades, nil
 }
 
+//有error_code时转换为ApiError
+func (okFuture *OKEx) adaptError(respMap map[string]interface{}, body []byte) error {
+	if errCode, isok := respMap["error_code"].(float64); isok {
+		return okFuture.errorWrapper(int(errCode))
+	}
+	return errors.New(string(body))
+}
+
 func (okFuture *OKEx) errorWrapper(errorCode int) ApiError {
 	switch errorCode {
 	case 20024:
@@ -1,6 +1,7 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,7 +9,6 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,37 +16,200 @@ import (
 	"bytes"
 	"io/ioutil"
 	"github.com/buger/jsonparser"
+	"math"
 )
 
 type OKExSpot struct {
 	OKCoinCN_API
-	ws                *WsConn
-	createWsLock      sync.Mutex
-	wsTickerHandleMap map[string]func(*Ticker)
-	wsDepthHandleMap  map[string]func(*Depth)
-	wsTradeHandleMap  map[string]func(*Trade)
+	v3BaseUrl     string
+	passphrase    string //v3接口需要创建api key时设置的passphrase
+	wsUrl         string
+	ws            *WsConn
+	wsDialOptions WsDialOptions
+	createWsLock  sync.Mutex
+	wsHandlers    *WsRegistry
+}
+
+//websocket的K线周期和rest接口的写法不同
+var _INERNAL_WS_KLINE_PERIOD_CONVERTER = map[int]string{
+	KLINE_PERIOD_1MIN:  "1min",
+	KLINE_PERIOD_3MIN:  "3min",
+	KLINE_PERIOD_5MIN:  "5min",
+	KLINE_PERIOD_15MIN: "15min",
+	KLINE_PERIOD_30MIN: "30min",
+	KLINE_PERIOD_60MIN: "1hour",
+	KLINE_PERIOD_2H:    "2hour",
+	KLINE_PERIOD_4H:    "4hour",
+	KLINE_PERIOD_6H:    "6hour",
+	KLINE_PERIOD_12H:   "12hour",
+	KLINE_PERIOD_1DAY:  "day",
+	KLINE_PERIOD_3DAY:  "3day",
+	KLINE_PERIOD_1WEEK: "week",
+}
+
+func init() {
+	RegisterExchange(OKEX, func(config *APIConfig) API {
+		okSpot := NewOKExSpot(config.HttpClient, config.AccessKey, config.SecretKey)
+		okSpot.api_base_url = OverrideUrl(okSpot.api_base_url, config.ApiUrl)
+		okSpot.v3BaseUrl = OverrideUrl(okSpot.v3BaseUrl, config.ApiUrl)
+		okSpot.wsUrl = OverrideUrl(okSpot.wsUrl, config.WsUrl)
+		okSpot.wsDialOptions = config.WsDialOptions
+		okSpot.passphrase = config.Passphrase
+		return okSpot
+	})
 }
 
 func NewOKExSpot(client *http.Client, accesskey, secretkey string) *OKExSpot {
 	return &OKExSpot{
-		OKCoinCN_API:      OKCoinCN_API{client, accesskey, secretkey, "https://www.okex.com/api/v1/"},
-		wsTickerHandleMap: make(map[string]func(*Ticker)),
-		wsDepthHandleMap:  make(map[string]func(*Depth)),
-		wsTradeHandleMap:  make(map[string]func(*Trade))}
+		OKCoinCN_API: OKCoinCN_API{client, accesskey, secretkey, "https://www.okex.com/api/v1/"},
+		v3BaseUrl:    "https://www.okex.com",
+		wsUrl:        OKEX_WS_URL,
+		wsHandlers:   NewWsRegistry()}
 }
 
-func (ctx *OKExSpot) GetExchangeName() string {
+//GetMyTrades使用v3接口, 需要passphrase
+func NewOKExSpotWithPassphrase(client *http.Client, accesskey, secretkey, passphrase string) *OKExSpot {
+	okSpot := NewOKExSpot(client, accesskey, secretkey)
+	okSpot.passphrase = passphrase
+	return okSpot
+}
+
+func (okSpot *OKExSpot) GetExchangeName() string {
 	return OKEX
 }
 
-func (ctx *OKExSpot) GetAccount() (*Account, error) {
+func (okSpot *OKExSpot) Capabilities() Capabilities {
+	c := okSpot.OKCoinCN_API.Capabilities()
+	c.Ws = true
+	return c
+}
+
+func (okSpot *OKExSpot) GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	return okSpot.GetMyTradesCtx(context.Background(), currency, since, limit)
+}
+
+/**
+ * v1接口没有个人成交明细, 使用v3的fills接口, since为毫秒时间戳
+ * v3的每笔成交有交易币和计价币两条记录, 按trade_id合并, 手续费在收到的币种的记录中
+ * 记录按ledger_id从新到旧返回, 用after向前翻页: since>0时翻到since之前, 返回since之后最早的limit条,
+ * 否则返回最近的limit条
+ */
+func (okSpot *OKExSpot) GetMyTradesCtx(ctx context.Context, currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	if okSpot.passphrase == "" {
+		return nil, EX_ERR_NOT_FIND_PASSPHRASE.OriginErr("okex v3 fills api needs passphrase")
+	}
+
+	//每笔成交两条记录, 一页最多100条
+	pageSize := 100
+	if limit > 0 && limit*2 < pageSize {
+		pageSize = limit * 2
+	}
+
+	var tradeIds []string
+	merged := make(map[string]*Fill)
+	after := ""
+	for {
+		params := url.Values{}
+		params.Set("instrument_id", currency.ToSymbol("-"))
+		params.Set("limit", fmt.Sprint(pageSize))
+		if after != "" {
+			params.Set("after", after)
+		}
+		path := "/api/spot/v3/fills?" + params.Encode()
+
+		resp, err := HttpGet3Ctx(ctx, okSpot.client, okSpot.v3BaseUrl+path, okSpot.v3Headers("GET", path, ""))
+		if err != nil {
+			return nil, err
+		}
+
+		oldest := int64(math.MaxInt64)
+		for _, v := range resp {
+			t := v.(map[string]interface{})
+			after = fmt.Sprint(t["ledger_id"])
+			tradeId := fmt.Sprint(t["trade_id"])
+			fill, ok := merged[tradeId]
+			if !ok {
+				fill = &Fill{TradeID: tradeId, Pair: currency}
+				merged[tradeId] = fill
+				tradeIds = append(tradeIds, tradeId)
+			}
+			if ts, err := time.Parse(time.RFC3339, fmt.Sprint(t["timestamp"])); err == nil {
+				fill.Time = ts.UnixNano() / int64(time.Millisecond)
+				oldest = fill.Time
+			}
+
+			if fee := math.Abs(ToFloat64(t["fee"])); fee > 0 {
+				fill.Fee = fee
+				fill.FeeCurrency = NewCurrency(fmt.Sprint(t["currency"]), "")
+			}
+			if !strings.EqualFold(fmt.Sprint(t["currency"]), currency.CurrencyA.Symbol) {
+				continue
+			}
+
+			fill.OrderID = fmt.Sprint(t["order_id"])
+			fill.Side = SELL
+			if t["side"] == "buy" {
+				fill.Side = BUY
+			}
+			fill.Price = ToFloat64(t["price"])
+			fill.Amount = ToFloat64(t["size"])
+			fill.IsMaker = t["exec_type"] == "M"
+		}
+
+		if len(resp) < pageSize {
+			break
+		}
+		if since > 0 {
+			if oldest < since {
+				break
+			}
+		} else if limit <= 0 || len(tradeIds) > limit {
+			//多取一笔, 保证前limit笔的两条记录都已取到
+			break
+		}
+	}
+
+	fills := make([]Fill, 0, len(tradeIds))
+	for _, tradeId := range tradeIds {
+		fill := merged[tradeId]
+		if fill.OrderID == "" || fill.Time < since {
+			continue
+		}
+		fills = append(fills, *fill)
+	}
+	if limit > 0 && len(fills) > limit {
+		if since > 0 {
+			fills = fills[len(fills)-limit:]
+		} else {
+			fills = fills[:limit]
+		}
+	}
+	return fills, nil
+}
+
+//v3接口的签名: base64(hmac_sha256(timestamp + method + path + body))
+func (okSpot *OKExSpot) v3Headers(method, path, body string) map[string]string {
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	sign, _ := GetParamHmacSHA256Base64Sign(okSpot.secret_key, timestamp+method+path+body)
+	return map[string]string{
+		"OK-ACCESS-KEY":        okSpot.api_key,
+		"OK-ACCESS-SIGN":       sign,
+		"OK-ACCESS-TIMESTAMP":  timestamp,
+		"OK-ACCESS-PASSPHRASE": okSpot.passphrase}
+}
+
+func (okSpot *OKExSpot) GetAccount() (*Account, error) {
+	return okSpot.GetAccountCtx(context.Background())
+}
+
+func (okSpot *OKExSpot) GetAccountCtx(ctx context.Context) (*Account, error) {
 	postData := url.Values{}
-	err := ctx.buildPostForm(&postData)
+	err := okSpot.buildPostForm(&postData)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := HttpPostForm(ctx.client, ctx.api_base_url+url_userinfo, postData)
+	body, err := HttpPostFormCtx(ctx, okSpot.client, okSpot.api_base_url+url_userinfo, postData)
 	if err != nil {
 		return nil, err
 	}
@@ -59,12 +222,11 @@ func (ctx *OKExSpot) GetAccount() (*Account, error) {
 	}
 
 	if errcode, isok := respMap["error_code"].(float64); isok {
-		errcodeStr := strconv.FormatFloat(errcode, 'f', 0, 64)
-		return nil, errors.New(errcodeStr)
+		return nil, okSpot.errorWrapper(int(errcode))
 	}
 	//log.Println(respMap)
-	info, ok := respMap["info"].(map[string]interface{})
-	if !ok {
+	info, isok := respMap["info"].(map[string]interface{})
+	if !isok {
 		return nil, errors.New(string(body))
 	}
 
@@ -73,7 +235,7 @@ func (ctx *OKExSpot) GetAccount() (*Account, error) {
 	freezed := funds["freezed"].(map[string]interface{})
 
 	account := new(Account)
-	account.Exchange = ctx.GetExchangeName()
+	account.Exchange = okSpot.GetExchangeName()
 
 	account.SubAccounts = make(map[Currency]SubAccount, 6)
 	for k, v := range free {
@@ -101,123 +263,218 @@ func (okSpot *OKExSpot) MessageDecode(msg []byte) {
 
 }
 
-func (okSpot *OKExSpot) createWsConn() {
+func (okSpot *OKExSpot) createWsConn() (*WsConn, error) {
+	okSpot.createWsLock.Lock()
+	defer okSpot.createWsLock.Unlock()
+
+	//connect wsx
 	if okSpot.ws == nil {
-		//connect wsx
-		okSpot.createWsLock.Lock()
-		defer okSpot.createWsLock.Unlock()
-
-		if okSpot.ws == nil {
-			okSpot.ws = NewWsConn("wss://real.okex.com:10440/ws/v1")
-			okSpot.ws.Heartbeat(func() interface{} { return map[string]string{"event": "ping"} }, 20*time.Second)
-			okSpot.ws.ReConnect()
-			okSpot.ws.ReceiveMessage(func(msg []byte) {
-				var err error
-				msg, err = okSpot.GzipDecode(msg)
+		ws, err := DialWsConn(okSpot.wsUrl, okSpot.wsDialOptions)
+		if err != nil {
+			return nil, err
+		}
+		okSpot.ws = ws
+		okSpot.ws.Heartbeat(func() interface{} { return map[string]string{"event": "ping"} }, 20*time.Second)
+		okSpot.ws.ReConnect()
+		okSpot.ws.ReceiveMessage(func(msg []byte) {
+			var err error
+			msg, err = okSpot.GzipDecode(msg)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if string(msg) == "{\"event\":\"pong\"}" {
+				ws.UpdateActivedTime()
+				return
+			}
+
+			var data []interface{}
+			err = json.Unmarshal(msg, &data)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if len(data) == 0 {
+				return
+			}
+
+			jsonparser.ArrayEach(msg, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+				channel, err := jsonparser.GetString(value, "channel")
 				if err != nil {
-					log.Println(err)
+					fmt.Printf("channel err :%s \n", err)
 					return
 				}
 
-				if string(msg) == "{\"event\":\"pong\"}" {
-					okSpot.ws.UpdateActivedTime()
+				m, _, _, err := jsonparser.Get(value, "data")
+				if err != nil {
+					fmt.Printf("data err :%s \n", err)
 					return
 				}
 
-				var data []interface{}
-				err = json.Unmarshal(msg, &data)
-				if err != nil {
-					log.Println(err)
+				if channel == "addChannel" {
+					fmt.Printf("msg: %s \n", m)
 					return
 				}
 
-				if len(data) == 0 {
+				if !okSpot.wsHandlers.Has(channel) {
 					return
 				}
 
-				jsonparser.ArrayEach(msg, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
-					channel, err := jsonparser.GetString(value, "channel")
-					if err != nil {
-						fmt.Printf("channel err :%s \n", err)
-						return
-					}
-
-					m, _, _, err := jsonparser.Get(value, "data")
-					if err != nil {
-						fmt.Printf("data err :%s \n", err)
-						return
-					}
-
-					if channel == "addChannel" {
-						fmt.Printf("msg: %s \n", m)
-						return
-					}
-
-					pair := okSpot.getPairFormChannel(channel)
-
-					if strings.Contains(channel, "_deals") {
-						jsonparser.ArrayEach(m, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
-							var t []string
-							err = json.Unmarshal(value, &t)
-							if err != nil {
-								fmt.Printf("tradeArr  Unmarshal err :%s \n", err)
-								return
-							}
-
-							trade := okSpot.parseTrade(t)
-							trade.Pair = pair
-
-							okSpot.wsTradeHandleMap[channel](trade)
-						})
-						return
-					}
-
-					tickmap := make(map[string]interface{})
-					err = json.Unmarshal(m, &tickmap)
-
-					if strings.HasSuffix(channel, "_ticker") {
-						ticker := okSpot.parseTicker(tickmap)
-						ticker.Pair = pair
-						okSpot.wsTickerHandleMap[channel](ticker)
-					} else if strings.Contains(channel, "depth_") {
-						dep := okSpot.parseDepth(tickmap)
-						dep.Pair = pair
-						okSpot.wsDepthHandleMap[channel](dep)
-					}
-
-				}, )
-
-			})
-		}
+				pair := okSpot.getPairFormChannel(channel)
+
+				if strings.Contains(channel, "_kline_") {
+					jsonparser.ArrayEach(m, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+						var k []string
+						err = json.Unmarshal(value, &k)
+						if err != nil || len(k) < 6 {
+							log.Println("kline unmarshal error:", err, string(value))
+							return
+						}
+
+						kline := okSpot.parseKline(k)
+						kline.Pair = pair
+						okSpot.wsHandlers.Dispatch(channel, kline)
+					})
+					return
+				}
+
+				if strings.Contains(channel, "_deals") {
+					jsonparser.ArrayEach(m, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+						var t []string
+						err = json.Unmarshal(value, &t)
+						if err != nil {
+							fmt.Printf("tradeArr  Unmarshal err :%s \n", err)
+							return
+						}
+
+						trade := okSpot.parseTrade(t)
+						trade.Pair = pair
+
+						okSpot.wsHandlers.Dispatch(channel, trade)
+					})
+					return
+				}
+
+				tickmap := make(map[string]interface{})
+				err = json.Unmarshal(m, &tickmap)
+
+				if strings.HasSuffix(channel, "_ticker") {
+					ticker := okSpot.parseTicker(tickmap)
+					ticker.Pair = pair
+					okSpot.wsHandlers.Dispatch(channel, ticker)
+				} else if strings.Contains(channel, "depth_") {
+					dep := okSpot.parseDepth(tickmap)
+					dep.Pair = pair
+					okSpot.wsHandlers.Dispatch(channel, dep)
+				}
+
+			}, )
+
+		})
 	}
+	return okSpot.ws, nil
 }
 
 func (okSpot *OKExSpot) GetDepthWithWs(pair CurrencyPair, handle func(*Depth)) error {
-	okSpot.createWsConn()
-	channel := fmt.Sprintf("ok_sub_spot_%s_depth_5", strings.ToLower(pair.ToSymbol("_")))
-	okSpot.wsDepthHandleMap[channel] = handle
-	return okSpot.ws.Subscribe(map[string]string{
-		"event":   "addChannel",
-		"channel": channel})
+	_, err := okSpot.AddWsHandle(UNSUB_ORDERBOOK, pair, 0, handle)
+	return err
 }
 
 func (okSpot *OKExSpot) GetTickerWithWs(pair CurrencyPair, handle func(*Ticker)) error {
-	okSpot.createWsConn()
-	channel := fmt.Sprintf("ok_sub_spot_%s_ticker", strings.ToLower(pair.ToSymbol("_")))
-	okSpot.wsTickerHandleMap[channel] = handle
-	return okSpot.ws.Subscribe(map[string]string{
-		"event":   "addChannel",
-		"channel": channel})
+	_, err := okSpot.AddWsHandle(UNSUB_TICKER, pair, 0, handle)
+	return err
 }
 
 func (okSpot *OKExSpot) GetTradeWithWs(pair CurrencyPair, handle func(*Trade)) error {
-	okSpot.createWsConn()
-	channel := fmt.Sprintf("ok_sub_spot_%s_deals", strings.ToLower(pair.String()))
-	fmt.Printf("channel:%s \n", channel)
-	okSpot.wsTradeHandleMap[channel] = handle
-	return okSpot.ws.Subscribe(map[string]string{
-		"event":   "addChannel",
-		"channel": channel})
+	_, err := okSpot.AddWsHandle(UNSUB_TRADE, pair, 0, handle)
+	return err
+}
+
+func (okSpot *OKExSpot) GetKLineWithWs(pair CurrencyPair, period int, handle func(*Kline)) error {
+	_, err := okSpot.AddWsHandle(UNSUB_KLINE, pair, period, handle)
+	return err
+}
+
+func (okSpot *OKExSpot) UnsubscribeDepth(pair CurrencyPair) error {
+	return okSpot.wsHandlers.Unsubscribe(okSpot.depthChannel(pair))
+}
+
+func (okSpot *OKExSpot) UnsubscribeTicker(pair CurrencyPair) error {
+	return okSpot.wsHandlers.Unsubscribe(okSpot.tickerChannel(pair))
+}
+
+func (okSpot *OKExSpot) UnsubscribeTrade(pair CurrencyPair) error {
+	return okSpot.wsHandlers.Unsubscribe(okSpot.tradeChannel(pair))
+}
+
+func (okSpot *OKExSpot) UnsubscribeKLine(pair CurrencyPair, period int) error {
+	channel, err := okSpot.klineChannel(pair, period)
+	if err != nil {
+		return err
+	}
+	return okSpot.wsHandlers.Unsubscribe(channel)
+}
+
+func (okSpot *OKExSpot) AddWsHandle(event int, pair CurrencyPair, period int, handle interface{}) (int64, error) {
+	var channel string
+	var err error
+	switch event {
+	case UNSUB_TICKER:
+		channel = okSpot.tickerChannel(pair)
+	case UNSUB_ORDERBOOK:
+		channel = okSpot.depthChannel(pair)
+	case UNSUB_TRADE:
+		channel = okSpot.tradeChannel(pair)
+	case UNSUB_KLINE:
+		channel, err = okSpot.klineChannel(pair, period)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, EX_ERR_NOT_SUPPORTED.OriginErr(fmt.Sprintf("ws event %d", event))
+	}
+
+	ws, err := okSpot.createWsConn()
+	if err != nil {
+		return 0, err
+	}
+	return okSpot.wsHandlers.Subscribe(channel, handle, func() error {
+		return ws.Subscribe(okSpot.channelEvent("addChannel", channel))
+	}, func() error {
+		return ws.Unsubscribe(okSpot.channelEvent("addChannel", channel), okSpot.channelEvent("removeChannel", channel))
+	})
+}
+
+func (okSpot *OKExSpot) RemoveWsHandle(id int64) error {
+	return okSpot.wsHandlers.Remove(id)
+}
+
+func (okSpot *OKExSpot) channelEvent(event, channel string) map[string]string {
+	return map[string]string{
+		"event":   event,
+		"channel": channel}
+}
+
+func (okSpot *OKExSpot) depthChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("ok_sub_spot_%s_depth_5", strings.ToLower(pair.ToSymbol("_")))
+}
+
+func (okSpot *OKExSpot) tickerChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("ok_sub_spot_%s_ticker", strings.ToLower(pair.ToSymbol("_")))
+}
+
+func (okSpot *OKExSpot) tradeChannel(pair CurrencyPair) string {
+	return fmt.Sprintf("ok_sub_spot_%s_deals", strings.ToLower(pair.String()))
+}
+
+func (okSpot *OKExSpot) klineChannel(pair CurrencyPair, period int) (string, error) {
+	periodS, isOk := _INERNAL_WS_KLINE_PERIOD_CONVERTER[period]
+	if !isOk {
+		return "", EX_ERR_NOT_SUPPORTED.OriginErr(fmt.Sprintf("kline period %d", period))
+	}
+	return fmt.Sprintf("ok_sub_spot_%s_kline_%s", strings.ToLower(pair.ToSymbol("_")), periodS), nil
 }
 
 func (okSpot *OKExSpot) parseTrade(arr []string) *Trade {
@@ -237,6 +494,17 @@ func (okSpot *OKExSpot) parseTrade(arr []string) *Trade {
 	return trade
 }
 
+//[时间戳(毫秒), 开, 高, 低, 收, 量]
+func (okSpot *OKExSpot) parseKline(arr []string) *Kline {
+	return &Kline{
+		Timestamp: int64(ToUint64(arr[0]) / 1000),
+		Open:      ToFloat64(arr[1]),
+		High:      ToFloat64(arr[2]),
+		Low:       ToFloat64(arr[3]),
+		Close:     ToFloat64(arr[4]),
+		Vol:       ToFloat64(arr[5])}
+}
+
 // date = 15:04:05   return ms
 func (okSpot *OKExSpot) formatTimeMs(date string) int64 {
 	const format = "2006-01-02 15:04:05"
@@ -256,7 +524,7 @@ func (okSpot *OKExSpot) parseTicker(tickmap map[string]interface{}) *Ticker {
 		Vol:  ToFloat64(tickmap["vol"]),
 		Sell: ToFloat64(tickmap["sell"]),
 		Buy:  ToFloat64(tickmap["buy"]),
-		Date: ToUint64(tickmap["timestamp"])}
+		Date: ToUint64(tickmap["timestamp"]) / 1000}
 }
 
 func (okSpot *OKExSpot) parseDepth(tickmap map[string]interface{}) *Depth {
@@ -1,10 +1,17 @@
 package okcoin
 
 import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"github.com/gorilla/websocket"
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 var okexSpot = NewOKExSpot(http.DefaultClient, "", "")
@@ -25,3 +32,165 @@ func TestOKExSpot_GetKlineRecords(t *testing.T) {
 	klines, err := okexSpot.GetKlineRecords(goex.LTC_BTC, goex.KLINE_PERIOD_1MIN, 1000, -1)
 	t.Log(err, klines)
 }
+
+func TestOKExSpot_GetMyTrades(t *testing.T) {
+	//每笔成交有交易币和计价币两条记录, 按ledger_id从新到旧
+	rows := []map[string]string{
+		{"ledger_id": "8", "trade_id": "14", "order_id": "104", "price": "3900", "size": "0.2", "side": "buy", "exec_type": "T", "fee": "-0.0002", "currency": "BTC", "timestamp": "2019-03-16T02:52:56.000Z"},
+		{"ledger_id": "7", "trade_id": "14", "order_id": "104", "price": "3900", "size": "780", "side": "sell", "exec_type": "T", "fee": "0", "currency": "USDT", "timestamp": "2019-03-16T02:52:56.000Z"},
+		{"ledger_id": "6", "trade_id": "11", "order_id": "101", "price": "3888.6", "size": "0.5", "side": "buy", "exec_type": "M", "fee": "-0.0005", "currency": "BTC", "timestamp": "2019-03-15T02:52:56.123Z"},
+		{"ledger_id": "5", "trade_id": "11", "order_id": "101", "price": "3888.6", "size": "1944.3", "side": "sell", "exec_type": "M", "fee": "0", "currency": "USDT", "timestamp": "2019-03-15T02:52:56.123Z"},
+		{"ledger_id": "4", "trade_id": "12", "order_id": "102", "price": "3890", "size": "0.1", "side": "sell", "exec_type": "T", "fee": "0", "currency": "BTC", "timestamp": "2019-03-14T02:52:56.000Z"},
+		{"ledger_id": "3", "trade_id": "12", "order_id": "102", "price": "3890", "size": "389", "side": "buy", "exec_type": "T", "fee": "-0.389", "currency": "USDT", "timestamp": "2019-03-14T02:52:56.000Z"},
+		{"ledger_id": "2", "trade_id": "10", "order_id": "100", "price": "3800", "size": "0.3", "side": "buy", "exec_type": "T", "fee": "-0.0003", "currency": "BTC", "timestamp": "2019-03-13T02:52:56.000Z"},
+		{"ledger_id": "1", "trade_id": "10", "order_id": "100", "price": "3800", "size": "1140", "side": "sell", "exec_type": "T", "fee": "0", "currency": "USDT", "timestamp": "2019-03-13T02:52:56.000Z"},
+	}
+	var limits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/spot/v3/fills", r.URL.Path)
+		assert.Equal(t, "BTC-USDT", r.URL.Query().Get("instrument_id"))
+		timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		sign, _ := goex.GetParamHmacSHA256Base64Sign("secret", timestamp+"GET"+r.URL.RequestURI())
+		assert.Equal(t, sign, r.Header.Get("OK-ACCESS-SIGN"))
+		assert.Equal(t, "key", r.Header.Get("OK-ACCESS-KEY"))
+		assert.Equal(t, "pass", r.Header.Get("OK-ACCESS-PASSPHRASE"))
+
+		limit := goex.ToInt(r.URL.Query().Get("limit"))
+		limits = append(limits, r.URL.Query().Get("limit"))
+		after := goex.ToInt(r.URL.Query().Get("after"))
+		page := []map[string]string{}
+		for _, row := range rows {
+			if (after == 0 || goex.ToInt(row["ledger_id"]) < after) && len(page) < limit {
+				page = append(page, row)
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	api, err := goex.NewExchangeAPI(goex.OKEX, &goex.APIConfig{HttpClient: http.DefaultClient, ApiUrl: server.URL,
+		Credentials: goex.Credentials{AccessKey: "key", SecretKey: "secret", Passphrase: "pass"}})
+	assert.Nil(t, err)
+	okSpot := api.(*OKExSpot)
+
+	fills, err := okSpot.GetMyTrades(goex.BTC_USDT, 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Fill{
+		{OrderID: "104", TradeID: "14", Pair: goex.BTC_USDT, Side: goex.BUY, Price: 3900, Amount: 0.2, Fee: 0.0002, FeeCurrency: goex.BTC, Time: 1552704776000},
+		{OrderID: "101", TradeID: "11", Pair: goex.BTC_USDT, Side: goex.BUY, Price: 3888.6, Amount: 0.5, Fee: 0.0005, FeeCurrency: goex.BTC, IsMaker: true, Time: 1552618376123}}, fills)
+	//一笔成交两条记录, 每页取2*limit条
+	assert.Equal(t, []string{"4", "4"}, limits)
+
+	limits = nil
+	fills, err = okSpot.GetMyTrades(goex.BTC_USDT, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, fills, 4)
+	assert.Equal(t, []string{"100"}, limits)
+
+	//翻页直到since之前, 返回since之后最早的limit笔
+	limits = nil
+	fills, err = okSpot.GetMyTrades(goex.BTC_USDT, 1552531976000, 1)
+	assert.Nil(t, err)
+	assert.Len(t, fills, 1)
+	assert.Equal(t, "12", fills[0].TradeID)
+	assert.Equal(t, goex.USDT, fills[0].FeeCurrency)
+	assert.Equal(t, 0.389, fills[0].Fee)
+	assert.Equal(t, []string{"2", "2", "2", "2"}, limits)
+
+	_, err = NewOKExSpot(http.DefaultClient, "key", "secret").GetMyTrades(goex.BTC_USDT, 0, 10)
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_PASSPHRASE.ErrCode, err.(goex.ApiError).ErrCode)
+}
+
+func TestOKExSpot_GetKLineWithWs(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	okSpot := NewOKExSpot(http.DefaultClient, "", "")
+	okSpot.wsUrl = server.WsUrl()
+	klines := make(chan *goex.Kline, 1)
+	err := okSpot.GetKLineWithWs(goex.BCH_BTC, goex.KLINE_PERIOD_1MIN, func(kline *goex.Kline) {
+		klines <- kline
+	})
+	assert.Nil(t, err)
+
+	msg, err := server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"event":"addChannel","channel":"ok_sub_spot_bch_btc_kline_1min"}`, string(msg))
+
+	assert.Nil(t, sendDeflate(server, `[{"binary":1,"channel":"ok_sub_spot_bch_btc_kline_1min","data":[["1490337840000","995.37","996.75","995.36","996.75","9.112"]]}]`))
+
+	select {
+	case kline := <-klines:
+		assert.Equal(t, goex.Kline{Pair: goex.BCH_BTC, Timestamp: 1490337840, Open: 995.37, High: 996.75, Low: 995.36, Close: 996.75, Vol: 9.112}, *kline)
+	case <-time.After(time.Second):
+		t.Fatal("no kline received")
+	}
+
+	assert.Nil(t, okSpot.UnsubscribeKLine(goex.BCH_BTC, goex.KLINE_PERIOD_1MIN))
+	msg, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"event":"removeChannel","channel":"ok_sub_spot_bch_btc_kline_1min"}`, string(msg))
+
+	err = okSpot.GetKLineWithWs(goex.BCH_BTC, goex.KLINE_PERIOD_1MONTH, func(kline *goex.Kline) {})
+	assert.True(t, goex.IsNotSupported(err))
+}
+
+func TestOKExSpot_GetTickerWithWs(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	okSpot := NewOKExSpot(http.DefaultClient, "", "")
+	okSpot.wsUrl = server.WsUrl()
+	tickers := make(chan *goex.Ticker, 1)
+	depths := make(chan *goex.Depth, 1)
+	assert.Nil(t, okSpot.GetTickerWithWs(goex.LTC_BTC, func(ticker *goex.Ticker) {
+		tickers <- ticker
+	}))
+	assert.Nil(t, okSpot.GetDepthWithWs(goex.LTC_BTC, func(depth *goex.Depth) {
+		depths <- depth
+	}))
+	server.NextMessage(time.Second)
+	server.NextMessage(time.Second)
+
+	assert.Nil(t, sendDeflate(server, `[{"binary":1,"channel":"ok_sub_spot_ltc_btc_ticker","data":{"high":"0.0082","vol":"152.3","last":"0.008","low":"0.0079","buy":"0.008","sell":"0.0081","timestamp":1546300800123}}]`))
+	assert.Nil(t, sendDeflate(server, `[{"binary":1,"channel":"ok_sub_spot_ltc_btc_depth_5","data":{"asks":[["0.0082","12.1"],["0.0081","3.5"]],"bids":[["0.008","4.2"],["0.0079","20"]],"timestamp":1546300800123}}]`))
+
+	select {
+	case ticker := <-tickers:
+		goextest.AssertTicker(t, goex.LTC_BTC, ticker)
+		assert.Equal(t, uint64(1546300800), ticker.Date)
+	case <-time.After(time.Second):
+		t.Fatal("no ticker received")
+	}
+	select {
+	case depth := <-depths:
+		goextest.AssertDepth(t, goex.LTC_BTC, depth)
+	case <-time.After(time.Second):
+		t.Fatal("no depth received")
+	}
+}
+
+//okex现货的v1 rest接口和okcoin_cn相同, 使用同一个golden文件
+func TestOKExSpot_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return NewOKExSpot(client, "", "")
+	}, goextest.SpotFixtures{
+		Pair:           goex.LTC_BTC,
+		Ticker:         true,
+		DepthSize:      2,
+		KlinePeriod:    goex.KLINE_PERIOD_1MIN,
+		KlineSize:      2,
+		KlineSince:     -1,
+		Order:          &goex.Order{OrderID2: "10000591", Status: goex.ORDER_PART_FINISH, Side: goex.SELL, Amount: 10, DealAmount: 4},
+		UnfinishOrders: true,
+		MissingOrderId: "1"})
+}
+
+//okex的websocket消息是deflate压缩的二进制消息
+func sendDeflate(server *goextest.WsServer, msg string) error {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	w.Write([]byte(msg))
+	w.Close()
+	return server.Send(websocket.BinaryMessage, buf.Bytes())
+}
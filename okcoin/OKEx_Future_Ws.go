@@ -12,86 +12,110 @@ import (
 	"time"
 )
 
-func (okFuture *OKEx) createWsConn() {
+func (okFuture *OKEx) createWsConn() (*WsConn, error) {
+	okFuture.createWsLock.Lock()
+	defer okFuture.createWsLock.Unlock()
+
+	//connect wsx
 	if okFuture.ws == nil {
-		//connect wsx
-		okFuture.createWsLock.Lock()
-		defer okFuture.createWsLock.Unlock()
-
-		if okFuture.ws == nil {
-			okFuture.wsTickerHandleMap = make(map[string]func(*Ticker))
-			okFuture.wsDepthHandleMap = make(map[string]func(*Depth))
-
-			okFuture.ws = NewWsConn("wss://real.okex.com:10440/ws/v1")
-			okFuture.ws.Heartbeat(func() interface{} { return map[string]string{"event": "ping"} }, 30*time.Second)
-			okFuture.ws.ReConnect()
-			okFuture.ws.ReceiveMessage(func(d []byte) {
-				reader := flate.NewReader(bytes.NewReader(d))
-				msg, err := ioutil.ReadAll(reader)
-
-				if err != nil {
-					log.Println(err)
-					return
-				}
-
-				if string(msg) == "{\"event\":\"pong\"}" {
-					okFuture.ws.UpdateActivedTime()
-					return
-				}
-
-				var data []interface{}
-				err = json.Unmarshal(msg, &data)
-				if err != nil {
-					log.Println(err)
-					return
-				}
-
-				if len(data) == 0 {
-					return
-				}
-
-				datamap := data[0].(map[string]interface{})
-				channel := datamap["channel"].(string)
-				if channel == "addChannel" {
-					return
-				}
-
-				tickmap := datamap["data"].(map[string]interface{})
-				pair := okFuture.getPairFromChannel(channel)
-				contractType := okFuture.getContractFromChannel(channel)
-
-				if strings.HasSuffix(channel, "_ticker") {
-					ticker := okFuture.parseTicker(tickmap)
-					ticker.Pair = pair
-					ticker.ContractType = contractType
-					okFuture.wsTickerHandleMap[channel](ticker)
-				} else if strings.Contains(channel, "depth_") {
-					dep := okFuture.parseDepth(tickmap)
-					dep.Pair = pair
-					dep.ContractType = contractType
-					okFuture.wsDepthHandleMap[channel](dep)
-				}
-			})
+		ws, err := DialWsConn(okFuture.wsUrl, okFuture.wsDialOptions)
+		if err != nil {
+			return nil, err
 		}
+		okFuture.ws = ws
+		okFuture.ws.Heartbeat(func() interface{} { return map[string]string{"event": "ping"} }, 30*time.Second)
+		okFuture.ws.ReConnect()
+		okFuture.ws.ReceiveMessage(func(d []byte) {
+			reader := flate.NewReader(bytes.NewReader(d))
+			msg, err := ioutil.ReadAll(reader)
+
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if string(msg) == "{\"event\":\"pong\"}" {
+				ws.UpdateActivedTime()
+				return
+			}
+
+			var data []interface{}
+			err = json.Unmarshal(msg, &data)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if len(data) == 0 {
+				return
+			}
+
+			datamap := data[0].(map[string]interface{})
+			channel := datamap["channel"].(string)
+			if channel == "addChannel" || !okFuture.wsHandlers.Has(channel) {
+				return
+			}
+
+			tickmap := datamap["data"].(map[string]interface{})
+			pair := okFuture.getPairFromChannel(channel)
+			contractType := okFuture.getContractFromChannel(channel)
+
+			if strings.Contains(channel, "_ticker_") {
+				ticker := okFuture.parseTicker(tickmap)
+				ticker.Pair = pair
+				ticker.ContractType = contractType
+				okFuture.wsHandlers.Dispatch(channel, ticker)
+			} else if strings.Contains(channel, "depth_") {
+				dep := okFuture.parseDepth(tickmap)
+				dep.Pair = pair
+				dep.ContractType = contractType
+				okFuture.wsHandlers.Dispatch(channel, dep)
+			}
+		})
 	}
+	return okFuture.ws, nil
 }
 
 func (okFuture *OKEx) GetDepthWithWs(pair CurrencyPair, contractType string, handle func(*Depth)) error {
-	okFuture.createWsConn()
-	channel := fmt.Sprintf("ok_sub_futureusd_%s_depth_%s_5", strings.ToLower(pair.CurrencyA.Symbol), contractType)
-	okFuture.wsDepthHandleMap[channel] = handle
-	return okFuture.ws.Subscribe(map[string]string{
-		"event":   "addChannel",
-		"channel": channel})
+	_, err := okFuture.AddWsHandle(UNSUB_ORDERBOOK, pair, contractType, handle)
+	return err
 }
 
 func (okFuture *OKEx) GetTickerWithWs(pair CurrencyPair, contractType string, handle func(*Ticker)) error {
-	okFuture.createWsConn()
-	channel := fmt.Sprintf("ok_sub_futureusd_%s_ticker_%s", strings.ToLower(pair.CurrencyA.Symbol), contractType)
-	okFuture.wsTickerHandleMap[channel] = handle
-	return okFuture.ws.Subscribe(map[string]string{
-		"event":   "addChannel",
-		"channel": channel})
+	_, err := okFuture.AddWsHandle(UNSUB_TICKER, pair, contractType, handle)
+	return err
+}
+
+func (okFuture *OKEx) AddWsHandle(event int, pair CurrencyPair, contractType string, handle interface{}) (int64, error) {
+	var channel string
+	switch event {
+	case UNSUB_TICKER:
+		channel = fmt.Sprintf("ok_sub_futureusd_%s_ticker_%s", strings.ToLower(pair.CurrencyA.Symbol), contractType)
+	case UNSUB_ORDERBOOK:
+		channel = fmt.Sprintf("ok_sub_futureusd_%s_depth_%s_5", strings.ToLower(pair.CurrencyA.Symbol), contractType)
+	default:
+		return 0, EX_ERR_NOT_SUPPORTED.OriginErr(fmt.Sprintf("ws event %d", event))
+	}
+
+	ws, err := okFuture.createWsConn()
+	if err != nil {
+		return 0, err
+	}
+	return okFuture.wsHandlers.Subscribe(channel, handle, func() error {
+		return ws.Subscribe(okFuture.channelEvent("addChannel", channel))
+	}, func() error {
+		return ws.Unsubscribe(okFuture.channelEvent("addChannel", channel), okFuture.channelEvent("removeChannel", channel))
+	})
+}
+
+func (okFuture *OKEx) RemoveWsHandle(id int64) error {
+	return okFuture.wsHandlers.Remove(id)
+}
+
+func (okFuture *OKEx) channelEvent(event, channel string) map[string]string {
+	return map[string]string{
+		"event":   event,
+		"channel": channel}
 }
 
 func (okFuture *OKEx) parseTicker(tickmap map[string]interface{}) *Ticker {
@@ -102,7 +126,7 @@ func (okFuture *OKEx) parseTicker(tickmap map[string]interface{}) *Ticker {
 		Vol:  ToFloat64(tickmap["vol"]),
 		Sell: ToFloat64(tickmap["sell"]),
 		Buy:  ToFloat64(tickmap["buy"]),
-		Date: ToUint64(tickmap["timestamp"])}
+		Date: ToUint64(tickmap["timestamp"]) / 1000}
 }
 
 func (okFuture *OKEx) parseDepth(tickmap map[string]interface{}) *Depth {
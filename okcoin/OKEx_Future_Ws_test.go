@@ -4,6 +4,8 @@ import (
 	"testing"
 	"net/http"
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
+	"github.com/stretchr/testify/assert"
 	"log"
 	"time"
 )
@@ -17,3 +19,79 @@ func TestOKEx_GetDepthWithWs(t *testing.T) {
 	time.Sleep(1 * time.Minute)
 	okexFuture.ws.CloseWs()
 }
+
+func TestOKEx_GetTickerWithWs_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	okFuture := NewOKEx(http.DefaultClient, "", "")
+	okFuture.wsUrl = server.WsUrl()
+	tickers := make(chan *goex.Ticker, 1)
+	depths := make(chan *goex.Depth, 1)
+	assert.Nil(t, okFuture.GetTickerWithWs(goex.BTC_USD, goex.QUARTER_CONTRACT, func(ticker *goex.Ticker) {
+		tickers <- ticker
+	}))
+	assert.Nil(t, okFuture.GetDepthWithWs(goex.BTC_USD, goex.QUARTER_CONTRACT, func(depth *goex.Depth) {
+		depths <- depth
+	}))
+	server.NextMessage(time.Second)
+	server.NextMessage(time.Second)
+
+	assert.Nil(t, sendDeflate(server, `[{"binary":0,"channel":"ok_sub_futureusd_btc_ticker_quarter","data":{"high":"3900","limitLow":"3700","vol":"12000","last":"3850.5","low":"3800","buy":"3850.1","hold_amount":"100","sell":"3851","contractId":201903290000034,"unitAmount":"100","limitHigh":"4000","timestamp":1546300800123}}]`))
+	assert.Nil(t, sendDeflate(server, `[{"binary":0,"channel":"ok_sub_futureusd_btc_depth_quarter_5","data":{"asks":[[3852,10,0.25,1.5,30],[3851,5,0.12,0.9,20]],"bids":[[3850.1,8,0.2,0.2,8],[3849,3,0.07,0.27,11]],"timestamp":1546300800123}}]`))
+
+	select {
+	case ticker := <-tickers:
+		goextest.AssertTicker(t, goex.BTC_USD, ticker)
+		assert.Equal(t, uint64(1546300800), ticker.Date)
+		assert.Equal(t, goex.QUARTER_CONTRACT, ticker.ContractType)
+	case <-time.After(time.Second):
+		t.Fatal("no ticker received")
+	}
+	select {
+	case depth := <-depths:
+		goextest.AssertDepth(t, goex.BTC_USD, depth)
+		assert.Equal(t, goex.QUARTER_CONTRACT, depth.ContractType)
+	case <-time.After(time.Second):
+		t.Fatal("no depth received")
+	}
+}
+
+func TestOKEx_RemoveWsHandle_Local(t *testing.T) {
+	server := goextest.NewWsServer()
+	defer server.Close()
+
+	okFuture := NewOKEx(http.DefaultClient, "", "")
+	okFuture.wsUrl = server.WsUrl()
+	var api goex.FutureWsHandleAPI = okFuture
+	tickers1 := make(chan *goex.Ticker, 1)
+	tickers2 := make(chan *goex.Ticker, 1)
+	id1, err := api.AddWsHandle(goex.UNSUB_TICKER, goex.BTC_USD, goex.QUARTER_CONTRACT, func(ticker *goex.Ticker) { tickers1 <- ticker })
+	assert.Nil(t, err)
+	id2, err := api.AddWsHandle(goex.UNSUB_TICKER, goex.BTC_USD, goex.QUARTER_CONTRACT, func(ticker *goex.Ticker) { tickers2 <- ticker })
+	assert.Nil(t, err)
+	_, err = server.NextMessage(time.Second)
+	assert.Nil(t, err)
+
+	//删除一个handle不影响其他handle, 也不取消交易所的订阅
+	assert.Nil(t, api.RemoveWsHandle(id1))
+	_, err = server.NextMessage(100 * time.Millisecond)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, sendDeflate(server, `[{"binary":0,"channel":"ok_sub_futureusd_btc_ticker_quarter","data":{"last":"3850.5","buy":"3850.1","sell":"3851","timestamp":1546300800123}}]`))
+	select {
+	case ticker := <-tickers2:
+		assert.Equal(t, 3850.5, ticker.Last)
+	case <-time.After(time.Second):
+		t.Fatal("no ticker received")
+	}
+	assert.Len(t, tickers1, 0)
+
+	assert.Nil(t, api.RemoveWsHandle(id2))
+	msg, err := server.NextMessage(time.Second)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"event":"removeChannel","channel":"ok_sub_futureusd_btc_ticker_quarter"}`, string(msg))
+
+	_, err = api.AddWsHandle(goex.UNSUB_TRADE, goex.BTC_USD, goex.QUARTER_CONTRACT, func(*goex.Trade) {})
+	assert.True(t, goex.IsNotSupported(err))
+}
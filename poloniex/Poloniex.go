@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -27,19 +28,38 @@ type Poloniex struct {
 	accessKey,
 	secretKey string
 	client *http.Client
+	tradeApi,
+	publicUrl string
+}
+
+func init() {
+	RegisterExchange(POLONIEX, func(config *APIConfig) API {
+		poloniex := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		poloniex.tradeApi = OverrideUrl(poloniex.tradeApi, config.ApiUrl)
+		poloniex.publicUrl = OverrideUrl(poloniex.publicUrl, config.ApiUrl)
+		return poloniex
+	})
 }
 
 func New(client *http.Client, accessKey, secretKey string) *Poloniex {
-	return &Poloniex{accessKey, secretKey, client}
+	return &Poloniex{accessKey, secretKey, client, TRADE_API, PUBLIC_URL}
 }
 
 func (poloniex *Poloniex) GetExchangeName() string {
 	return POLONIEX
 }
 
+func (poloniex *Poloniex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (poloniex *Poloniex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	//log.Println(poloniex.adaptCurrencyPair(currency).ToSymbol2("_"))
-	respmap, err := HttpGet(poloniex.client, PUBLIC_URL+TICKER_API)
+	respmap, err := HttpGet(poloniex.client, poloniex.publicUrl+TICKER_API)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -66,7 +86,7 @@ func (poloniex *Poloniex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	return ticker, nil
 }
 func (poloniex *Poloniex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	respmap, err := HttpGet(poloniex.client, PUBLIC_URL+
+	respmap, err := HttpGet(poloniex.client, poloniex.publicUrl+
 		fmt.Sprintf(ORDER_BOOK_API, currency.AdaptUsdToUsdt().Reverse().ToSymbol("_"), size))
 
 	if err != nil {
@@ -113,10 +133,13 @@ func (poloniex *Poloniex) GetDepth(size int, currency CurrencyPair) (*Depth, err
 		depth.BidList = append(depth.BidList, dr)
 	}
 
+	//统一按价格从高到低排列, AskList最后一个是卖一
+	sort.Sort(sort.Reverse(depth.AskList))
+	sort.Sort(sort.Reverse(depth.BidList))
 	return &depth, nil
 }
 func (Poloniex *Poloniex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (poloniex *Poloniex) placeLimitOrder(command, amount, price string, currency CurrencyPair) (*Order, error) {
@@ -132,7 +155,7 @@ func (poloniex *Poloniex) placeLimitOrder(command, amount, price string, currenc
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
 
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, postData, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -142,7 +165,7 @@ func (poloniex *Poloniex) placeLimitOrder(command, amount, price string, currenc
 	err = json.Unmarshal(resp, &respmap)
 	if err != nil || respmap["error"] != nil {
 		log.Println(err, string(resp))
-		return nil, err
+		return nil, poloniex.adaptError(resp, err)
 	}
 
 	orderNumber := respmap["orderNumber"].(string)
@@ -188,7 +211,7 @@ func (poloniex *Poloniex) CancelOrder(orderId string, currency CurrencyPair) (bo
 	headers := map[string]string{
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, postData, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
 	if err != nil {
 		log.Println(err)
 		return false, err
@@ -200,7 +223,7 @@ func (poloniex *Poloniex) CancelOrder(orderId string, currency CurrencyPair) (bo
 	err = json.Unmarshal(resp, &respmap)
 	if err != nil || respmap["error"] != nil {
 		//log.Println(err, string(resp))
-		return false, errors.New(string(resp))
+		return false, poloniex.adaptError(resp, nil)
 	}
 
 	success := int(respmap["success"].(float64))
@@ -223,7 +246,7 @@ func (poloniex *Poloniex) GetOneOrder(orderId string, currency CurrencyPair) (*O
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
 
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, postData, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -246,7 +269,7 @@ func (poloniex *Poloniex) GetOneOrder(orderId string, currency CurrencyPair) (*O
 			}
 		}
 		//log.Println(string(resp))
-		return nil, errors.New(string(resp))
+		return nil, poloniex.adaptError(resp, nil)
 	}
 
 	respmap := make([]interface{}, 0)
@@ -284,6 +307,60 @@ func (poloniex *Poloniex) GetOneOrder(orderId string, currency CurrencyPair) (*O
 	return order, nil
 }
 
+//fee是手续费率,买入扣base币,卖出扣quote币; 不返回maker/taker
+func (poloniex *Poloniex) GetMyTrades(currency CurrencyPair, since int64, limit int) ([]Fill, error) {
+	postData := url.Values{}
+	postData.Set("command", "returnTradeHistory")
+	postData.Set("currencyPair", currency.AdaptUsdToUsdt().Reverse().ToSymbol("_"))
+	if since > 0 {
+		postData.Set("start", fmt.Sprint(since/1000))
+		postData.Set("end", fmt.Sprint(time.Now().Unix()))
+	}
+	if limit > 0 {
+		postData.Set("limit", fmt.Sprint(limit))
+	}
+
+	sign, _ := poloniex.buildPostForm(&postData)
+
+	headers := map[string]string{
+		"Key":  poloniex.accessKey,
+		"Sign": sign}
+
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []map[string]interface{}
+	err = json.Unmarshal(resp, &trades)
+	if err != nil {
+		return nil, errors.New(string(resp))
+	}
+
+	var fills []Fill
+	for _, t := range trades {
+		date, _ := time.Parse("2006-01-02 15:04:05", fmt.Sprint(t["date"]))
+		fill := Fill{
+			OrderID:     fmt.Sprint(t["orderNumber"]),
+			TradeID:     fmt.Sprint(t["tradeID"]),
+			Pair:        currency,
+			Side:        SELL,
+			Price:       ToFloat64(t["rate"]),
+			Amount:      ToFloat64(t["amount"]),
+			Fee:         ToFloat64(t["total"]) * ToFloat64(t["fee"]),
+			FeeCurrency: currency.CurrencyB,
+			Time:        date.UnixNano() / int64(time.Millisecond)}
+		if t["type"] == "buy" {
+			fill.Side = BUY
+			fill.Fee = fill.Amount * ToFloat64(t["fee"])
+			fill.FeeCurrency = currency.CurrencyA
+		}
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
+}
+
 func (poloniex *Poloniex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 	postData := url.Values{}
 	postData.Set("command", "returnOpenOrders")
@@ -298,7 +375,7 @@ func (poloniex *Poloniex) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 	headers := map[string]string{
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, postData, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -337,7 +414,7 @@ func (poloniex *Poloniex) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 	return orders, nil
 }
 func (Poloniex *Poloniex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (poloniex *Poloniex) GetAccount() (*Account, error) {
@@ -351,7 +428,7 @@ func (poloniex *Poloniex) GetAccount() (*Account, error) {
 	headers := map[string]string{
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, postData, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, postData, headers)
 
 	if err != nil {
 		log.Println(err)
@@ -363,7 +440,7 @@ func (poloniex *Poloniex) GetAccount() (*Account, error) {
 
 	if err != nil || respmap["error"] != nil {
 		log.Println(err)
-		return nil, err
+		return nil, poloniex.adaptError(resp, err)
 	}
 
 	acc := new(Account)
@@ -386,15 +463,25 @@ func (poloniex *Poloniex) GetAccount() (*Account, error) {
 	return acc, nil
 }
 
+// Deprecated: 使用SubmitWithdraw
 func (p *Poloniex) Withdraw(amount string, currency Currency, fees, receiveAddr, safePwd string) (string, error) {
+	return p.SubmitWithdraw(WithdrawRequest{Currency: currency, Amount: amount, Address: receiveAddr, Fee: fees, SafePwd: safePwd})
+}
+
+//poloniex不返回提现id,成功时返回空字符串,交易所的提示信息(比如 Withdrew 2398 NXT.)只打印到日志
+func (p *Poloniex) SubmitWithdraw(req WithdrawRequest) (string, error) {
+	currency := req.Currency
 	if currency == BCC {
 		currency = BCH
 	}
 	params := url.Values{}
 	params.Add("command", "withdraw")
-	params.Add("address", receiveAddr)
-	params.Add("amount", amount)
+	params.Add("address", req.Address)
+	params.Add("amount", req.Amount)
 	params.Add("currency", strings.ToUpper(currency.String()))
+	if req.Tag != "" {
+		params.Add("paymentId", req.Tag)
+	}
 
 	sign, err := p.buildPostForm(&params)
 	if err != nil {
@@ -405,13 +492,12 @@ func (p *Poloniex) Withdraw(amount string, currency Currency, fees, receiveAddr,
 		"Key":  p.accessKey,
 		"Sign": sign}
 
-	resp, err := HttpPostForm2(p.client, TRADE_API, params, headers)
+	resp, err := HttpPostForm2(p.client, p.tradeApi, params, headers)
 
 	if err != nil {
 		log.Println(err)
 		return "", err
 	}
-	println(string(resp))
 
 	respMap := make(map[string]interface{})
 
@@ -422,10 +508,138 @@ func (p *Poloniex) Withdraw(amount string, currency Currency, fees, receiveAddr,
 	}
 
 	if respMap["error"] == nil {
-		return string(resp), nil
+		log.Println("poloniex withdraw:", respMap["response"])
+		return "", nil
+	}
+
+	return "", p.adaptError(resp, nil)
+}
+
+func (p *Poloniex) CancelWithdraw(id string, currency Currency, safePwd string) (bool, error) {
+	return false, EX_ERR_NOT_SUPPORTED
+}
+
+func (p *Poloniex) GetDepositAddress(currency Currency) (*DepositAddress, error) {
+	symbol := strings.ToUpper(currency.AdaptBccToBch().String())
+
+	resp, err := p.privateRequest(url.Values{"command": {"returnDepositAddresses"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses map[string]string
+	err = json.Unmarshal(resp, &addresses)
+	if err != nil {
+		return nil, errors.New(string(resp))
+	}
+
+	if addr, ok := addresses[symbol]; ok {
+		return &DepositAddress{Currency: currency, Address: addr}, nil
+	}
+
+	//还没有充值地址时生成一个
+	resp, err = p.privateRequest(url.Values{"command": {"generateNewAddress"}, "currency": {symbol}})
+	if err != nil {
+		return nil, err
+	}
+
+	var respMap map[string]interface{}
+	err = json.Unmarshal(resp, &respMap)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, _ := respMap["response"].(string)
+	if ToInt(respMap["success"]) != 1 || addr == "" {
+		return nil, errors.New(string(resp))
+	}
+	return &DepositAddress{Currency: currency, Address: addr}, nil
+}
+
+func (p *Poloniex) GetDepositHistory(currency Currency) ([]Deposit, error) {
+	records, err := p.GetDepositsWithdrawals("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := strings.ToUpper(currency.AdaptBccToBch().String())
+	var deposits []Deposit
+	for _, r := range records.Deposits {
+		if r.Currency != symbol {
+			continue
+		}
+
+		status := DepositStatus(DEPOSIT_PENDING)
+		if r.Status == "COMPLETE" {
+			status = DEPOSIT_SUCCESS
+		}
+
+		deposits = append(deposits, Deposit{
+			Id:            r.TransactionID,
+			Currency:      currency,
+			Amount:        r.Amount,
+			Address:       r.Address,
+			TxId:          r.TransactionID,
+			Confirmations: r.Confirmations,
+			Status:        status,
+			Time:          r.Timestamp * 1000})
+	}
+	return deposits, nil
+}
+
+func (p *Poloniex) GetWithdrawHistory(currency Currency) ([]Withdrawal, error) {
+	records, err := p.GetDepositsWithdrawals("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := strings.ToUpper(currency.AdaptBccToBch().String())
+	var withdrawals []Withdrawal
+	for _, r := range records.Withdrawals {
+		if r.Currency != symbol {
+			continue
+		}
+
+		w := Withdrawal{
+			Id:       strconv.FormatInt(r.WithdrawalNumber, 10),
+			Currency: currency,
+			Amount:   r.Amount,
+			Address:  r.Address,
+			TxId:     r.TransactionID,
+			Time:     r.Timestamp * 1000}
+
+		//status 如 COMPLETE: <txid>, PENDING, AWAITING APPROVAL, CANCELED
+		switch {
+		case strings.HasPrefix(r.Status, "COMPLETE"):
+			w.Status = WITHDRAW_SUCCESS
+			if i := strings.Index(r.Status, ":"); i > 0 && w.TxId == "" {
+				w.TxId = strings.TrimSpace(r.Status[i+1:])
+			}
+		case strings.HasPrefix(r.Status, "CANCEL"):
+			w.Status = WITHDRAW_CANCELED
+		case strings.HasPrefix(r.Status, "FAIL"), strings.HasPrefix(r.Status, "ERROR"):
+			w.Status = WITHDRAW_FAILED
+		case strings.HasPrefix(r.Status, "PROCESSING"):
+			w.Status = WITHDRAW_PROCESSING
+		default:
+			w.Status = WITHDRAW_PENDING
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, nil
+}
+
+func (p *Poloniex) privateRequest(params url.Values) ([]byte, error) {
+	sign, err := p.buildPostForm(&params)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", errors.New(string(resp))
+	headers := map[string]string{
+		"Key":  p.accessKey,
+		"Sign": sign}
+
+	return HttpPostForm2(p.client, p.tradeApi, params, headers)
 }
 
 type PoloniexDepositsWithdrawals struct {
@@ -435,7 +649,7 @@ type PoloniexDepositsWithdrawals struct {
 		Amount        float64   `json:"amount,string"`
 		Confirmations int       `json:"confirmations"`
 		TransactionID string    `json:"txid"`
-		Timestamp     time.Time `json:"timestamp"`
+		Timestamp     int64     `json:"timestamp"`
 		Status        string    `json:"status"`
 	} `json:"deposits"`
 	Withdrawals []struct {
@@ -445,7 +659,7 @@ type PoloniexDepositsWithdrawals struct {
 		Amount           float64   `json:"amount,string"`
 		Confirmations    int       `json:"confirmations"`
 		TransactionID    string    `json:"txid"`
-		Timestamp        time.Time `json:"timestamp"`
+		Timestamp        int64     `json:"timestamp"`
 		Status           string    `json:"status"`
 		IPAddress        string    `json:"ipAddress"`
 	} `json:"withdrawals"`
@@ -454,7 +668,6 @@ type PoloniexDepositsWithdrawals struct {
 func (poloniex *Poloniex) GetDepositsWithdrawals(start, end string) (*PoloniexDepositsWithdrawals, error) {
 	params := url.Values{}
 	params.Set("command", "returnDepositsWithdrawals")
-	println(start)
 	if start != "" {
 		params.Set("start", start)
 	} else {
@@ -476,14 +689,12 @@ func (poloniex *Poloniex) GetDepositsWithdrawals(start, end string) (*PoloniexDe
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
 
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, params, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, params, headers)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	println(string(resp))
-
 	records := new(PoloniexDepositsWithdrawals)
 	err = json.Unmarshal(resp, records)
 
@@ -503,13 +714,45 @@ func (poloniex *Poloniex) buildPostForm(postForm *url.Values) (string, error) {
 }
 
 func (poloniex *Poloniex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("unimplements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (poloniex *Poloniex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (poloniex *Poloniex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+//返回的错误是 {"error":"Not enough BTC."}, 没有错误码只能按内容判断
+func (poloniex *Poloniex) adaptError(resp []byte, err error) error {
+	var respmap map[string]interface{}
+	if json.Unmarshal(resp, &respmap) != nil || respmap["error"] == nil {
+		if err != nil {
+			return err
+		}
+		return errors.New(string(resp))
+	}
+
+	msg := fmt.Sprint(respmap["error"])
+	apiErr := API_ERR
+	switch {
+	case strings.HasPrefix(msg, "Not enough"):
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case strings.Contains(msg, "Invalid order number"), strings.Contains(msg, "Order not found"):
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case strings.Contains(msg, "Invalid API key"):
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case strings.Contains(msg, "Invalid currency pair"):
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	case strings.HasPrefix(msg, "Amount must be at least"):
+		apiErr = EX_ERR_AMOUNT_TOO_SMALL
+	case strings.HasPrefix(msg, "Total must be at least"):
+		apiErr = EX_ERR_NOTIONAL_TOO_SMALL
+	case strings.Contains(msg, "API calls per second"):
+		apiErr = EX_ERR_API_LIMIT
+	}
+	apiErr.OriginErrMsg = string(resp)
+	return apiErr.OriginErr(msg)
 }
@@ -65,7 +65,7 @@ func (poloniex *Poloniex) sendAuthenticatedRequest(values url.Values, result int
 		"Key":  poloniex.accessKey,
 		"Sign": sign}
 
-	resp, err := HttpPostForm2(poloniex.client, TRADE_API, values, headers)
+	resp, err := HttpPostForm2(poloniex.client, poloniex.tradeApi, values, headers)
 	if err != nil {
 		log.Println(err)
 		return err
@@ -0,0 +1,493 @@
+package simulated
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/nntaoli-project/GoEx"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_MAKER_FEE = 0.001
+	DEFAULT_TAKER_FEE = 0.002
+
+	epsilon = 1e-12
+)
+
+func init() {
+	RegisterExchange(SIMULATED, func(config *APIConfig) API {
+		return New()
+	})
+}
+
+/**
+ * 共享的实例, 只有直接使用它的代码共享余额和订单
+ * builder每次Build("simulated")都创建新的实例, 通过api.(*simulated.Simulated)注入行情和余额
+ */
+var Default = New()
+
+/**
+ * 内存中的模拟交易所, 用于策略的单元测试
+ * 行情由SetDepth, AddTrade, SetTicker, AddKline注入, 订单和注入的深度或成交撮合:
+ *  新订单作为taker和当前深度成交, 吃掉的深度从当前深度中扣除; 限价单未成交部分挂单
+ *  挂单在新的深度或成交穿过挂单价格时以挂单价格作为maker成交
+ *  市价单的amount是基础币数量, 深度不够时未成交部分撤销
+ * 手续费从收到的币中扣除, 买单扣基础币, 卖单扣计价币
+ */
+type Simulated struct {
+	lock     sync.Mutex
+	makerFee float64
+	takerFee float64
+	balances map[Currency]*SubAccount
+	tickers  map[CurrencyPair]Ticker
+	books    map[CurrencyPair]*book
+	trades   map[CurrencyPair][]Trade
+	klines   map[CurrencyPair]map[int][]Kline
+	orders   map[string]*order
+	nextId   int
+}
+
+//可以成交的深度, asks价格从低到高, bids价格从高到低
+type book struct {
+	asks, bids DepthRecords
+}
+
+type order struct {
+	Order
+	frozen float64 //冻结的资金, 买单为计价币, 卖单为基础币
+}
+
+func New() *Simulated {
+	ex := new(Simulated)
+	ex.Reset()
+	return ex
+}
+
+//清空余额, 行情和订单, 手续费恢复默认
+func (ex *Simulated) Reset() {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.makerFee = DEFAULT_MAKER_FEE
+	ex.takerFee = DEFAULT_TAKER_FEE
+	ex.balances = make(map[Currency]*SubAccount)
+	ex.tickers = make(map[CurrencyPair]Ticker)
+	ex.books = make(map[CurrencyPair]*book)
+	ex.trades = make(map[CurrencyPair][]Trade)
+	ex.klines = make(map[CurrencyPair]map[int][]Kline)
+	ex.orders = make(map[string]*order)
+	ex.nextId = 0
+}
+
+func (ex *Simulated) SetFee(makerFee, takerFee float64) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.makerFee = makerFee
+	ex.takerFee = takerFee
+}
+
+//增加可用余额, amount为负数时减少
+func (ex *Simulated) Deposit(currency Currency, amount float64) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.balance(currency).Amount += amount
+}
+
+func (ex *Simulated) SetTicker(ticker Ticker) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.tickers[ticker.Pair] = ticker
+}
+
+//替换交易对的深度, 穿过深度的挂单立即成交
+func (ex *Simulated) SetDepth(depth Depth) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	b := &book{
+		asks: append(DepthRecords{}, depth.AskList...),
+		bids: append(DepthRecords{}, depth.BidList...)}
+	sort.Sort(b.asks)
+	sort.Sort(sort.Reverse(b.bids))
+	ex.books[depth.Pair] = b
+
+	for _, o := range ex.openOrders(depth.Pair) {
+		ex.matchBook(o, b, false)
+	}
+}
+
+//交易所的一笔成交, 价格穿过的挂单按挂单价格成交, 成交数量不超过trade.Amount
+func (ex *Simulated) AddTrade(trade Trade) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.trades[trade.Pair] = append(ex.trades[trade.Pair], trade)
+
+	remaining := trade.Amount
+	for _, o := range ex.openOrders(trade.Pair) {
+		if remaining <= epsilon {
+			break
+		}
+		if (o.Side == BUY && o.Price < trade.Price) || (o.Side == SELL && o.Price > trade.Price) {
+			continue
+		}
+		amount := ex.fillable(o, o.Price, remaining)
+		ex.fill(o, o.Price, amount, ex.makerFee)
+		remaining -= amount
+	}
+}
+
+func (ex *Simulated) AddKline(period int, kline Kline) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	periods, ok := ex.klines[kline.Pair]
+	if !ok {
+		periods = make(map[int][]Kline)
+		ex.klines[kline.Pair] = periods
+	}
+	periods[period] = append(periods[period], kline)
+}
+
+func (ex *Simulated) GetExchangeName() string {
+	return SIMULATED
+}
+
+func (ex *Simulated) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:       true,
+		Depth:        true,
+		Kline:        true,
+		Trades:       true,
+		Orders:       true,
+		OrderHistory: true,
+		Account:      true}
+}
+
+func (ex *Simulated) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(BUY, ORDER_TYPE_LIMIT, amount, price, currency)
+}
+
+func (ex *Simulated) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(SELL, ORDER_TYPE_LIMIT, amount, price, currency)
+}
+
+func (ex *Simulated) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(BUY, ORDER_TYPE_MARKET, amount, price, currency)
+}
+
+func (ex *Simulated) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(SELL, ORDER_TYPE_MARKET, amount, price, currency)
+}
+
+func (ex *Simulated) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	o, ok := ex.orders[orderId]
+	if !ok || o.Currency != currency {
+		return false, EX_ERR_NOT_FIND_ORDER.OriginErr("not found the order " + orderId)
+	}
+	if !isOpen(o) {
+		return false, EX_ERR_CANCEL_ORDER_FAIL.OriginErr("order " + orderId + " is " + o.Status.String())
+	}
+	ex.closeOrder(o, ORDER_CANCEL)
+	return true, nil
+}
+
+func (ex *Simulated) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	o, ok := ex.orders[orderId]
+	if !ok || o.Currency != currency {
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("not found the order " + orderId)
+	}
+	ord := o.Order
+	return &ord, nil
+}
+
+func (ex *Simulated) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	orders := make([]Order, 0)
+	for _, o := range ex.openOrders(currency) {
+		orders = append(orders, o.Order)
+	}
+	return orders, nil
+}
+
+//已完成和已撤销的订单, 按下单时间倒序, currentPage从1开始
+func (ex *Simulated) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	var history []*order
+	for _, o := range ex.orders {
+		if o.Currency == currency && !isOpen(o) {
+			history = append(history, o)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].OrderID > history[j].OrderID })
+
+	orders := make([]Order, 0)
+	if currentPage < 1 || pageSize <= 0 {
+		return orders, nil
+	}
+	for i := (currentPage - 1) * pageSize; i < len(history) && i < currentPage*pageSize; i++ {
+		orders = append(orders, history[i].Order)
+	}
+	return orders, nil
+}
+
+func (ex *Simulated) GetAccount() (*Account, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	acc := &Account{Exchange: SIMULATED, SubAccounts: make(map[Currency]SubAccount, len(ex.balances))}
+	for currency, sub := range ex.balances {
+		acc.SubAccounts[currency] = *sub
+	}
+	return acc, nil
+}
+
+func (ex *Simulated) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	ticker, ok := ex.tickers[currency]
+	if !ok {
+		return nil, errors.New("simulated: no ticker for " + currency.String())
+	}
+	return &ticker, nil
+}
+
+//返回注入的深度中还没有被成交的部分, AskList和BidList都按价格从高到低排列
+func (ex *Simulated) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	b, ok := ex.books[currency]
+	if !ok {
+		return nil, errors.New("simulated: no depth for " + currency.String())
+	}
+	asks, bids := b.asks, b.bids
+	if size > 0 && len(asks) > size {
+		asks = asks[:size]
+	}
+	if size > 0 && len(bids) > size {
+		bids = bids[:size]
+	}
+
+	dep := &Depth{Pair: currency, UTime: time.Now()}
+	dep.AskList = append(DepthRecords{}, asks...)
+	dep.BidList = append(DepthRecords{}, bids...)
+	sort.Sort(sort.Reverse(dep.AskList))
+	return dep, nil
+}
+
+//since为秒级时间戳, <=0时不限制, 最多返回最近的size条
+func (ex *Simulated) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	klines := make([]Kline, 0)
+	for _, k := range ex.klines[currency][period] {
+		if since <= 0 || k.Timestamp >= int64(since) {
+			klines = append(klines, k)
+		}
+	}
+	if size > 0 && len(klines) > size {
+		klines = klines[len(klines)-size:]
+	}
+	return klines, nil
+}
+
+//since为毫秒时间戳, 返回Date不早于since的成交
+func (ex *Simulated) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	trades := make([]Trade, 0)
+	for _, t := range ex.trades[currencyPair] {
+		if t.Date >= since {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
+func (ex *Simulated) placeOrder(side TradeSide, orderType OrderType, amount, price string, pair CurrencyPair) (*Order, error) {
+	amt, err := strconv.ParseFloat(amount, 64)
+	if err != nil || amt <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("invalid amount " + amount)
+	}
+	var limitPrice float64
+	if orderType == ORDER_TYPE_LIMIT {
+		limitPrice, err = strconv.ParseFloat(price, 64)
+		if err != nil || limitPrice <= 0 {
+			return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("invalid price " + price)
+		}
+	}
+
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	o := &order{Order: Order{
+		Price:     limitPrice,
+		Amount:    amt,
+		Currency:  pair,
+		Side:      side,
+		Type:      orderType,
+		Status:    ORDER_UNFINISH,
+		OrderTime: int(time.Now().UnixNano() / int64(time.Millisecond))}}
+
+	//冻结资金, 市价买单冻结全部可用的计价币, 结束时解冻剩余部分
+	switch {
+	case side == BUY && orderType == ORDER_TYPE_LIMIT:
+		o.frozen = amt * limitPrice
+	case side == BUY:
+		o.frozen = ex.balance(pair.CurrencyB).Amount
+	default:
+		o.frozen = amt
+	}
+	frozenCurrency := ex.frozenCurrency(o)
+	if o.frozen > ex.balance(frozenCurrency).Amount+epsilon || (side == BUY && o.frozen <= epsilon) {
+		return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("%s available %f", frozenCurrency, ex.balance(frozenCurrency).Amount))
+	}
+	ex.balance(frozenCurrency).Amount -= o.frozen
+	ex.balance(frozenCurrency).ForzenAmount += o.frozen
+
+	ex.nextId++
+	o.OrderID = ex.nextId
+	o.OrderID2 = strconv.Itoa(ex.nextId)
+	ex.orders[o.OrderID2] = o
+
+	if b, ok := ex.books[pair]; ok {
+		ex.matchBook(o, b, true)
+	}
+	if orderType == ORDER_TYPE_MARKET && isOpen(o) {
+		ex.closeOrder(o, ORDER_CANCEL)
+	}
+
+	ord := o.Order
+	return &ord, nil
+}
+
+/**
+ * 订单和深度成交, 市价单不限价格
+ * 新订单(taker)按深度的价格成交, 挂单(maker)按挂单价格成交
+ */
+func (ex *Simulated) matchBook(o *order, b *book, taker bool) {
+	feeRate := ex.makerFee
+	if taker {
+		feeRate = ex.takerFee
+	}
+	levels := &b.asks
+	if o.Side == SELL {
+		levels = &b.bids
+	}
+
+	for isOpen(o) && len(*levels) > 0 {
+		level := &(*levels)[0]
+		if o.Type == ORDER_TYPE_LIMIT && ((o.Side == BUY && level.Price > o.Price) || (o.Side == SELL && level.Price < o.Price)) {
+			break
+		}
+		price := level.Price
+		if !taker {
+			price = o.Price
+		}
+		amount := ex.fillable(o, price, level.Amount)
+		if amount <= epsilon {
+			break
+		}
+		ex.fill(o, price, amount, feeRate)
+		level.Amount -= amount
+		if level.Amount <= epsilon {
+			*levels = (*levels)[1:]
+		}
+	}
+}
+
+//按price最多能成交的数量, 市价买单受冻结的计价币限制
+func (ex *Simulated) fillable(o *order, price, available float64) float64 {
+	amount := o.Amount - o.DealAmount
+	if available < amount {
+		amount = available
+	}
+	if o.Side == BUY && o.Type == ORDER_TYPE_MARKET && amount*price > o.frozen {
+		amount = o.frozen / price
+	}
+	return amount
+}
+
+func (ex *Simulated) fill(o *order, price, amount, feeRate float64) {
+	base, quote := ex.balance(o.Currency.CurrencyA), ex.balance(o.Currency.CurrencyB)
+	value := price * amount
+
+	if o.Side == BUY {
+		o.frozen -= value
+		quote.ForzenAmount -= value
+		fee := amount * feeRate
+		base.Amount += amount - fee
+		o.Fee += fee
+	} else {
+		o.frozen -= amount
+		base.ForzenAmount -= amount
+		fee := value * feeRate
+		quote.Amount += value - fee
+		o.Fee += fee
+	}
+
+	o.AvgPrice = (o.AvgPrice*o.DealAmount + value) / (o.DealAmount + amount)
+	o.DealAmount += amount
+	if o.Amount-o.DealAmount <= epsilon {
+		ex.closeOrder(o, ORDER_FINISH)
+	} else {
+		o.Status = ORDER_PART_FINISH
+	}
+}
+
+//订单结束, 解冻剩余的资金
+func (ex *Simulated) closeOrder(o *order, status TradeStatus) {
+	o.Status = status
+	if o.frozen > 0 {
+		sub := ex.balance(ex.frozenCurrency(o))
+		sub.ForzenAmount -= o.frozen
+		sub.Amount += o.frozen
+		o.frozen = 0
+	}
+}
+
+func (ex *Simulated) frozenCurrency(o *order) Currency {
+	if o.Side == BUY {
+		return o.Currency.CurrencyB
+	}
+	return o.Currency.CurrencyA
+}
+
+//交易对的挂单, 按下单顺序
+func (ex *Simulated) openOrders(pair CurrencyPair) []*order {
+	var orders []*order
+	for _, o := range ex.orders {
+		if o.Currency == pair && isOpen(o) {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders
+}
+
+func (ex *Simulated) balance(currency Currency) *SubAccount {
+	sub, ok := ex.balances[currency]
+	if !ok {
+		sub = &SubAccount{Currency: currency}
+		ex.balances[currency] = sub
+	}
+	return sub
+}
+
+func isOpen(o *order) bool {
+	return o.Status == ORDER_UNFINISH || o.Status == ORDER_PART_FINISH
+}
@@ -0,0 +1,159 @@
+package simulated
+
+import (
+	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestExchange() *Simulated {
+	ex := New()
+	ex.SetDepth(goex.Depth{
+		Pair:    goex.BTC_USDT,
+		AskList: goex.DepthRecords{{Price: 3802, Amount: 2}, {Price: 3801, Amount: 1}},
+		BidList: goex.DepthRecords{{Price: 3799, Amount: 0.5}, {Price: 3790, Amount: 0.3}}})
+	return ex
+}
+
+func TestSimulated_LimitBuy(t *testing.T) {
+	ex := newTestExchange()
+	ex.Deposit(goex.USDT, 10000)
+
+	//吃掉卖一, 剩余部分挂单
+	ord, err := ex.LimitBuy("1.5", "3801.5", goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.EqualValues(t, goex.ORDER_PART_FINISH, ord.Status)
+	assert.Equal(t, 1.0, ord.DealAmount)
+	assert.Equal(t, 3801.0, ord.AvgPrice)
+	assert.InDelta(t, 0.002, ord.Fee, 1e-9)
+
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 0.998, acc.SubAccounts[goex.BTC].Amount, 1e-9)
+	assert.InDelta(t, 10000-1.5*3801.5, acc.SubAccounts[goex.USDT].Amount, 1e-9)
+	assert.InDelta(t, 1.5*3801.5-3801, acc.SubAccounts[goex.USDT].ForzenAmount, 1e-9)
+
+	dep, _ := ex.GetDepth(5, goex.BTC_USDT)
+	assert.Equal(t, goex.DepthRecords{{Price: 3802, Amount: 2}}, dep.AskList)
+
+	//价格穿过挂单的成交按挂单价格作为maker成交
+	ex.AddTrade(goex.Trade{Pair: goex.BTC_USDT, Price: 3801, Amount: 0.2})
+	ord, _ = ex.GetOneOrder(ord.OrderID2, goex.BTC_USDT)
+	assert.InDelta(t, 1.2, ord.DealAmount, 1e-9)
+	assert.InDelta(t, 0.002+0.2*0.001, ord.Fee, 1e-9)
+
+	ok, err := ex.CancelOrder(ord.OrderID2, goex.BTC_USDT)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ord, _ = ex.GetOneOrder(ord.OrderID2, goex.BTC_USDT)
+	assert.EqualValues(t, goex.ORDER_CANCEL, ord.Status)
+
+	acc, _ = ex.GetAccount()
+	assert.InDelta(t, 0, acc.SubAccounts[goex.USDT].ForzenAmount, 1e-9)
+	assert.InDelta(t, 10000-3801-0.2*3801.5, acc.SubAccounts[goex.USDT].Amount, 1e-9)
+
+	_, err = ex.CancelOrder(ord.OrderID2, goex.BTC_USDT)
+	assert.Equal(t, goex.EX_ERR_CANCEL_ORDER_FAIL.ErrCode, err.(goex.ApiError).ErrCode)
+
+	history, _ := ex.GetOrderHistorys(goex.BTC_USDT, 1, 10)
+	assert.Len(t, history, 1)
+}
+
+func TestSimulated_SetDepth(t *testing.T) {
+	ex := newTestExchange()
+	ex.Deposit(goex.BTC, 1)
+
+	ord, err := ex.LimitSell("1", "3805", goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.EqualValues(t, goex.ORDER_UNFINISH, ord.Status)
+
+	orders, _ := ex.GetUnfinishOrders(goex.BTC_USDT)
+	assert.Len(t, orders, 1)
+
+	ex.SetDepth(goex.Depth{
+		Pair:    goex.BTC_USDT,
+		AskList: goex.DepthRecords{{Price: 3810, Amount: 1}},
+		BidList: goex.DepthRecords{{Price: 3806, Amount: 3}}})
+	ord, _ = ex.GetOneOrder(ord.OrderID2, goex.BTC_USDT)
+	assert.EqualValues(t, goex.ORDER_FINISH, ord.Status)
+	assert.Equal(t, 3805.0, ord.AvgPrice)
+
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 0, acc.SubAccounts[goex.BTC].Amount+acc.SubAccounts[goex.BTC].ForzenAmount, 1e-9)
+	assert.InDelta(t, 3805*(1-DEFAULT_MAKER_FEE), acc.SubAccounts[goex.USDT].Amount, 1e-9)
+
+	orders, _ = ex.GetUnfinishOrders(goex.BTC_USDT)
+	assert.NotNil(t, orders)
+	assert.Len(t, orders, 0)
+}
+
+func TestSimulated_MarketSell(t *testing.T) {
+	ex := newTestExchange()
+	ex.Deposit(goex.BTC, 1)
+
+	//深度不够, 未成交部分撤销
+	ord, err := ex.MarketSell("1", "", goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.EqualValues(t, goex.ORDER_CANCEL, ord.Status)
+	assert.InDelta(t, 0.8, ord.DealAmount, 1e-9)
+	assert.InDelta(t, (3799*0.5+3790*0.3)/0.8, ord.AvgPrice, 1e-9)
+
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 0.2, acc.SubAccounts[goex.BTC].Amount, 1e-9)
+	assert.InDelta(t, 0, acc.SubAccounts[goex.BTC].ForzenAmount, 1e-9)
+	assert.InDelta(t, (3799*0.5+3790*0.3)*(1-DEFAULT_TAKER_FEE), acc.SubAccounts[goex.USDT].Amount, 1e-9)
+}
+
+func TestSimulated_InsufficientBalance(t *testing.T) {
+	ex := newTestExchange()
+	ex.Deposit(goex.USDT, 100)
+
+	_, err := ex.LimitBuy("1", "3800", goex.BTC_USDT)
+	assert.True(t, goex.IsInsufficientBalance(err))
+	_, err = ex.MarketSell("1", "", goex.BTC_USDT)
+	assert.True(t, goex.IsInsufficientBalance(err))
+	_, err = ex.LimitBuy("abc", "3800", goex.BTC_USDT)
+	assert.NotNil(t, err)
+
+	orders, _ := ex.GetUnfinishOrders(goex.BTC_USDT)
+	assert.Len(t, orders, 0)
+}
+
+func TestSimulated_NewExchangeAPI(t *testing.T) {
+	//每次创建的实例互不影响
+	api1, err := goex.NewExchangeAPI(goex.SIMULATED, &goex.APIConfig{})
+	assert.Nil(t, err)
+	api2, err := goex.NewExchangeAPI(goex.SIMULATED, &goex.APIConfig{})
+	assert.Nil(t, err)
+	assert.False(t, api1 == api2)
+	assert.False(t, api1 == Default)
+
+	api1.(*Simulated).Deposit(goex.USDT, 100)
+	acc, _ := api2.GetAccount()
+	assert.Equal(t, 0.0, acc.SubAccounts[goex.USDT].Amount)
+}
+
+func TestSimulated_Conformance(t *testing.T) {
+	ex := newTestExchange()
+	ex.Deposit(goex.USDT, 10000)
+	ex.Deposit(goex.BTC, 1)
+	ex.SetTicker(goex.Ticker{Pair: goex.BTC_USDT, Last: 3800, Buy: 3799, Sell: 3801, Date: 1546300800})
+	ex.AddKline(goex.KLINE_PERIOD_1MIN, goex.Kline{Pair: goex.BTC_USDT, Timestamp: 1546300800, Open: 3790, Close: 3800, High: 3805, Low: 3788, Vol: 10})
+	ex.AddKline(goex.KLINE_PERIOD_1MIN, goex.Kline{Pair: goex.BTC_USDT, Timestamp: 1546300860, Open: 3800, Close: 3801, High: 3802, Low: 3799, Vol: 8})
+	ex.AddTrade(goex.Trade{Pair: goex.BTC_USDT, Type: goex.SELL, Amount: 0.5, Price: 3800, Date: 1546300800123})
+	canceled, _ := ex.LimitSell("1", "3900", goex.BTC_USDT)
+	ex.CancelOrder(canceled.OrderID2, goex.BTC_USDT)
+	ord, _ := ex.LimitBuy("2", "3795", goex.BTC_USDT)
+
+	goextest.RunSpotConformance(t, ex, goextest.SpotFixtures{
+		Pair:           goex.BTC_USDT,
+		Ticker:         true,
+		DepthSize:      5,
+		KlinePeriod:    goex.KLINE_PERIOD_1MIN,
+		KlineSize:      10,
+		Trades:         true,
+		OrderHistory:   true,
+		Order:          &goex.Order{OrderID2: ord.OrderID2, Status: goex.ORDER_UNFINISH, Side: goex.BUY, Amount: 2},
+		UnfinishOrders: true,
+		MissingOrderId: "100"})
+}
@@ -1,24 +1,45 @@
 package goex
 
 import (
+	"crypto/tls"
+	"fmt"
 	"github.com/gorilla/websocket"
 	"log"
+	"net/http"
+	"net/url"
+	"reflect"
 	"time"
 	"sync"
 )
 
 type WsConn struct {
 	*websocket.Conn
-	lock                     sync.Mutex
+	lock                     sync.Mutex //保护Conn, actived和state, 重连时替换Conn
+	writeLock                sync.Mutex
+	subsLock                 sync.Mutex
 	url                      string
 	heartbeatIntervalTime    time.Duration
 	checkConnectIntervalTime time.Duration
 	actived                  time.Time
-	close                    chan int
-	isClose                  bool
+	state                    WsState
+	closed                   chan struct{}
+	closeOnce                sync.Once
 	subs                     []interface{}
+	opts                     WsDialOptions
 }
 
+//建立websocket连接的选项, 为空的项使用websocket.DefaultDialer的设置, 重连时使用同样的选项
+type WsDialOptions struct {
+	Proxy             func(*http.Request) (*url.URL, error) //同http.Transport.Proxy, 支持http, https, socks5代理, 为nil时使用环境变量中的代理
+	Header            http.Header                           //握手请求的header
+	HandshakeTimeout  time.Duration
+	TLSClientConfig   *tls.Config
+	ReadLimit         int64 //单条消息的最大字节数, 超过时连接会被关闭, 0表示不限制
+	EnableCompression bool  //和服务端协商permessage-deflate压缩
+
+	ReconnectPolicy RetryPolicy          //断开后的重连策略, InitialInterval为0时使用DEFAULT_WS_RECONNECT_POLICY
+	OnStateChange   func(e WsStateEvent) //连接状态变化时在websocket的goroutine中调用, 不要阻塞
+}
 
 const (
 	SUB_TICKER      = 1 + iota
@@ -29,14 +50,54 @@ const (
 	SUB_KLINE_1D
 	UNSUB_TICKER
 	UNSUB_ORDERBOOK
+	UNSUB_TRADE
+	UNSUB_KLINE
 )
 
+//连接失败时panic, 需要处理连接错误时使用DialWsConn
 func NewWsConn(wsurl string) *WsConn {
-	wsConn, _, err := websocket.DefaultDialer.Dial(wsurl, nil)
+	ws, err := DialWsConn(wsurl, WsDialOptions{})
 	if err != nil {
 		panic(err)
 	}
-	return &WsConn{Conn: wsConn, url: wsurl, actived: time.Now(), checkConnectIntervalTime: 30 * time.Second, close: make(chan int, 1)}
+	return ws
+}
+
+func DialWsConn(wsurl string, opts WsDialOptions) (*WsConn, error) {
+	ws := &WsConn{url: wsurl, opts: opts, actived: time.Now(), checkConnectIntervalTime: 30 * time.Second, closed: make(chan struct{})}
+	ws.notify(WS_CONNECTING, 0, nil)
+	wsConn, err := ws.dial()
+	if err != nil {
+		ws.notify(WS_DISCONNECTED, 0, err)
+		return nil, err
+	}
+	ws.Conn = wsConn
+	ws.notify(WS_CONNECTED, 0, nil)
+	return ws, nil
+}
+
+func (ws *WsConn) dial() (*websocket.Conn, error) {
+	dialer := *websocket.DefaultDialer
+	if ws.opts.Proxy != nil {
+		dialer.Proxy = ws.opts.Proxy
+	}
+	if ws.opts.HandshakeTimeout > 0 {
+		dialer.HandshakeTimeout = ws.opts.HandshakeTimeout
+	}
+	dialer.TLSClientConfig = ws.opts.TLSClientConfig
+	dialer.EnableCompression = ws.opts.EnableCompression
+
+	wsConn, resp, err := dialer.Dial(ws.url, ws.opts.Header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("goex: dial websocket %s: %w (http status %s)", ws.url, err, resp.Status)
+		}
+		return nil, fmt.Errorf("goex: dial websocket %s: %w", ws.url, err)
+	}
+	if ws.opts.ReadLimit > 0 {
+		wsConn.SetReadLimit(ws.opts.ReadLimit)
+	}
+	return wsConn, nil
 }
 
 func (ws *WsConn) setActived(t time.Time) {
@@ -51,14 +112,62 @@ func (ws *WsConn) getActived() time.Time {
 	return ws.actived
 }
 
-//并发安全写入，  不要用WriteJSON，或者会导致DATA RACE
-func (ws *WsConn) SendWriteJSON(v interface{}) error {
+//当前的连接, 重连后会变化, 不要保存
+func (ws *WsConn) getConn() *websocket.Conn {
+	defer ws.lock.Unlock()
+	ws.lock.Lock()
+	return ws.Conn
+}
+
+//替换成重连的连接, 已经CloseWs时返回false
+func (ws *WsConn) setConn(wsConn *websocket.Conn) bool {
+	defer ws.lock.Unlock()
+	ws.lock.Lock()
+	if ws.isClosed() {
+		return false
+	}
+	ws.Conn.Close()
+	ws.Conn = wsConn
+	return true
+}
+
+func (ws *WsConn) State() WsState {
 	defer ws.lock.Unlock()
 	ws.lock.Lock()
+	return ws.state
+}
+
+func (ws *WsConn) notify(state WsState, attempt int, err error) {
+	ws.lock.Lock()
+	ws.state = state
+	ws.lock.Unlock()
+
+	if ws.opts.OnStateChange != nil {
+		ws.opts.OnStateChange(WsStateEvent{Url: ws.url, State: state, Attempt: attempt, Err: err, Time: time.Now()})
+	}
+}
 
-	return ws.WriteJSON(v)
+func (ws *WsConn) isClosed() bool {
+	select {
+	case <-ws.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+//并发安全写入，  不要用WriteJSON，或者会导致DATA RACE
+func (ws *WsConn) SendWriteJSON(v interface{}) error {
+	defer ws.writeLock.Unlock()
+	ws.writeLock.Lock()
+
+	return ws.getConn().WriteJSON(v)
 }
 
+/**
+ * 检查连接是否活跃, 超过2个检查周期没有收到消息时断开连接
+ * 断开后由ReceiveMessage的goroutine按ReconnectPolicy重连并重新订阅
+ */
 func (ws *WsConn) ReConnect() {
 
 	timer := time.NewTimer(ws.checkConnectIntervalTime)
@@ -67,23 +176,12 @@ func (ws *WsConn) ReConnect() {
 			select {
 			case <-timer.C:
 				if time.Now().Sub(ws.getActived()) >= 2*ws.checkConnectIntervalTime {
-					ws.Close()
-					log.Println("start reconnect websocket:", ws.url)
-					wsConn, _, err := websocket.DefaultDialer.Dial(ws.url, nil)
-					if err != nil {
-						log.Println("reconnect fail ???")
-					} else {
-						ws.Conn = wsConn
-						ws.UpdateActivedTime()
-						//re subscribe
-						for _, sub := range ws.subs {
-							log.Println("subscribe:", sub)
-							ws.SendWriteJSON(sub)
-						}
-					}
+					log.Println("websocket inactive, close and reconnect:", ws.url)
+					ws.getConn().Close()
+					ws.UpdateActivedTime()
 				}
 				timer.Reset(ws.checkConnectIntervalTime)
-			case <-ws.close:
+			case <-ws.closed:
 				timer.Stop()
 				log.Println("close websocket connect, exiting reconnect goroutine.")
 				return
@@ -92,6 +190,72 @@ func (ws *WsConn) ReConnect() {
 	}()
 }
 
+//断开后按重连策略重连并重新订阅, 已经CloseWs或者重连次数用完时返回false
+func (ws *WsConn) reconnect(cause error) bool {
+	ws.notify(WS_DISCONNECTED, 0, cause)
+
+	policy := ws.opts.ReconnectPolicy
+	if policy.InitialInterval <= 0 {
+		policy = DEFAULT_WS_RECONNECT_POLICY
+	}
+	interval := policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+			log.Println("reconnect websocket give up after", policy.MaxAttempts, "attempts:", ws.url)
+			ws.CloseWs()
+			return false
+		}
+
+		timer := time.NewTimer(policy.jitter(interval))
+		select {
+		case <-ws.closed:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		interval = policy.next(interval)
+
+		ws.notify(WS_CONNECTING, attempt, nil)
+		wsConn, err := ws.dial()
+		if err != nil {
+			log.Println("reconnect websocket fail:", err)
+			ws.notify(WS_DISCONNECTED, attempt, err)
+			continue
+		}
+		if !ws.setConn(wsConn) {
+			wsConn.Close()
+			return false
+		}
+		ws.UpdateActivedTime()
+		ws.notify(WS_CONNECTED, attempt, nil)
+
+		err = ws.resubscribe()
+		if err != nil {
+			log.Println("resubscribe websocket fail:", err)
+			wsConn.Close()
+			ws.notify(WS_DISCONNECTED, attempt, err)
+			continue
+		}
+		ws.notify(WS_RESUBSCRIBED, attempt, nil)
+		return true
+	}
+}
+
+func (ws *WsConn) resubscribe() error {
+	ws.subsLock.Lock()
+	subs := append([]interface{}{}, ws.subs...)
+	ws.subsLock.Unlock()
+
+	for _, sub := range subs {
+		log.Println("subscribe:", sub)
+		err := ws.SendWriteJSON(sub)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (ws *WsConn) Heartbeat(heartbeat func() interface{}, interval time.Duration) {
 	ws.heartbeatIntervalTime = interval
 	ws.checkConnectIntervalTime = 2 * ws.heartbeatIntervalTime
@@ -107,7 +271,7 @@ func (ws *WsConn) Heartbeat(heartbeat func() interface{}, interval time.Duration
 					time.Sleep(time.Second)
 				}
 				timer.Reset(interval)
-			case <-ws.close:
+			case <-ws.closed:
 				timer.Stop()
 				log.Println("close websocket connect , exiting heartbeat goroutine.")
 				return
@@ -121,21 +285,40 @@ func (ws *WsConn) Subscribe(subEvent interface{}) error {
 	if err != nil {
 		return err
 	}
+	ws.subsLock.Lock()
 	ws.subs = append(ws.subs, subEvent)
+	ws.subsLock.Unlock()
 	return nil
 }
 
+//取消订阅, subEvent是订阅时传给Subscribe的参数, 取消后重连时不再重新订阅
+func (ws *WsConn) Unsubscribe(subEvent, unsubEvent interface{}) error {
+	ws.subsLock.Lock()
+	for i, sub := range ws.subs {
+		if reflect.DeepEqual(sub, subEvent) {
+			ws.subs = append(ws.subs[:i], ws.subs[i+1:]...)
+			break
+		}
+	}
+	ws.subsLock.Unlock()
+	return ws.SendWriteJSON(unsubEvent)
+}
+
+//连接断开时按ReconnectPolicy重连, 直到CloseWs
 func (ws *WsConn) ReceiveMessage(handle func(msg []byte)) {
 	go func() {
 		for {
-			t, msg, err := ws.ReadMessage()
+			t, msg, err := ws.getConn().ReadMessage()
 			if err != nil {
+				if ws.isClosed() {
+					log.Println("exiting receive message goroutine.")
+					return
+				}
 				log.Println(err)
-				if ws.isClose {
+				if !ws.reconnect(err) {
 					log.Println("exiting receive message goroutine.")
-					break
+					return
 				}
-				time.Sleep(time.Second)
 				continue
 			}
 			switch t {
@@ -160,16 +343,20 @@ func (ws *WsConn) UpdateActivedTime() {
 	ws.actived = time.Now()
 }
 
+//关闭连接, 退出心跳, 重连和接收消息的goroutine, 可以重复调用
 func (ws *WsConn) CloseWs() {
-	ws.close <- 1 //exit reconnect goroutine
-	if ws.heartbeatIntervalTime > 0 {
-		ws.close <- 1 //exit heartbeat goroutine
+	closing := false
+	ws.closeOnce.Do(func() {
+		close(ws.closed)
+		closing = true
+	})
+	if !closing {
+		return
 	}
 
-	err := ws.Close()
+	err := ws.getConn().Close()
 	if err != nil {
 		log.Println("close websocket connect error , ", err)
 	}
-
-	ws.isClose = true
+	ws.notify(WS_CLOSED, 0, nil)
 }
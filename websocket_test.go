@@ -4,7 +4,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,6 +20,130 @@ func Test_time(t *testing.T) {
 	t.Log(time.Now().Unix())
 }
 
+func TestDialWsConn(t *testing.T) {
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "goex", r.Header.Get("User-Agent"))
+		assert.Contains(t, r.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+		large := make([]byte, 1000)
+		rand.Read(large) //随机数据压缩后仍然超过ReadLimit
+		conn.WriteMessage(websocket.BinaryMessage, large)
+		time.Sleep(time.Second)
+	}))
+	defer server.Close()
+
+	ws, err := DialWsConn("ws"+strings.TrimPrefix(server.URL, "http"), WsDialOptions{
+		Header:            http.Header{"User-Agent": {"goex"}},
+		HandshakeTimeout:  time.Second,
+		ReadLimit:         100,
+		EnableCompression: true})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	_, msg, err := ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(msg))
+	_, _, err = ws.ReadMessage()
+	assert.Equal(t, websocket.ErrReadLimit, err)
+
+	//连接失败返回错误, 不panic
+	server.Close()
+	_, err = DialWsConn("ws"+strings.TrimPrefix(server.URL, "http"), WsDialOptions{HandshakeTimeout: time.Second})
+	assert.Error(t, err)
+}
+
+func TestWsConn_Reconnect(t *testing.T) {
+	var lock sync.Mutex
+	var conns []*websocket.Conn
+	received := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		lock.Lock()
+		conns = append(conns, conn)
+		lock.Unlock()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- strings.TrimSpace(string(msg))
+		}
+	}))
+	closeClients := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		conns = nil
+	}
+	defer server.Close()
+
+	events := make(chan WsStateEvent, 20)
+	expectStates := func(states ...WsState) {
+		for _, state := range states {
+			select {
+			case e := <-events:
+				assert.Equal(t, state, e.State, "event %+v", e)
+			case <-time.After(time.Second):
+				t.Fatalf("expect websocket state %s", state)
+			}
+		}
+	}
+
+	ws, err := DialWsConn("ws"+strings.TrimPrefix(server.URL, "http"), WsDialOptions{
+		ReconnectPolicy: RetryPolicy{InitialInterval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, Multiplier: 2, MaxAttempts: 2},
+		OnStateChange: func(e WsStateEvent) {
+			events <- e
+		}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	expectStates(WS_CONNECTING, WS_CONNECTED)
+
+	messages := make(chan string, 10)
+	ws.ReceiveMessage(func(msg []byte) {
+		messages <- string(msg)
+	})
+	assert.Nil(t, ws.Subscribe(map[string]string{"sub": "ticker"}))
+	assert.Equal(t, `{"sub":"ticker"}`, <-received)
+
+	//服务端断开后重连并重新订阅
+	closeClients()
+	expectStates(WS_DISCONNECTED, WS_CONNECTING, WS_CONNECTED, WS_RESUBSCRIBED)
+	assert.Equal(t, `{"sub":"ticker"}`, <-received)
+	assert.Equal(t, WS_RESUBSCRIBED, ws.State())
+
+	lock.Lock()
+	conns[0].WriteMessage(websocket.TextMessage, []byte("hello"))
+	lock.Unlock()
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "hello", msg)
+	case <-time.After(time.Second):
+		t.Fatal("no message received after reconnect")
+	}
+
+	//重连次数用完后关闭
+	server.Close()
+	closeClients()
+	expectStates(WS_DISCONNECTED, WS_CONNECTING, WS_DISCONNECTED, WS_CONNECTING, WS_DISCONNECTED, WS_CLOSED)
+	assert.Equal(t, WS_CLOSED, ws.State())
+	ws.CloseWs()
+}
+
 func TestNewWsConn(t *testing.T) {
 	//os.Setenv("https_proxy" , "socks5://127.0.0.1:1080")
 	ws := NewWsConn("wss://api.huobipro.com/ws")
@@ -1,7 +1,6 @@
 package wex
 
 import (
-	"errors"
 	. "github.com/nntaoli-project/GoEx"
 	"log"
 	"net/http"
@@ -13,61 +12,70 @@ import (
 type Wex struct {
 	client *http.Client
 	accesskey,
-	secretkey string
+	secretkey,
+	baseUrl string
 }
 
 var (
 	baseurl = "https://wex.nz/api/3"
 )
 
+func init() {
+	RegisterExchange("wex.nz", func(config *APIConfig) API {
+		wex := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		wex.baseUrl = OverrideUrl(wex.baseUrl, config.ApiUrl)
+		return wex
+	})
+}
+
 func New(client *http.Client, accesskey, secretkey string) *Wex {
-	return &Wex{client, accesskey, secretkey}
+	return &Wex{client, accesskey, secretkey, baseurl}
 }
 
 func (wex *Wex) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implements")
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 func (wex *Wex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetAccount() (*Account, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	respmap, err := HttpGet(wex.client, baseurl+"/ticker/"+strings.ToLower(currency.ToSymbol("_")))
+	respmap, err := HttpGet(wex.client, wex.baseUrl+"/ticker/"+strings.ToLower(currency.ToSymbol("_")))
 	if err != nil {
 		return nil, err
 	}
 
 	if errmsg, isok := respmap["error"].(string); isok {
 		log.Println(errmsg)
-		return nil, errors.New(errmsg)
+		return nil, wex.adaptError(errmsg)
 	}
 
 	for _, v := range respmap {
@@ -85,18 +93,33 @@ func (wex *Wex) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (wex *Wex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (wex *Wex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (wex *Wex) GetExchangeName() string {
 	return "wex.nz"
 }
+
+func (wex *Wex) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true}
+}
+
+//错误是 {"success":0,"error":"Invalid pair name: btc_xxx"}
+func (wex *Wex) adaptError(errmsg string) ApiError {
+	apiErr := API_ERR
+	if strings.HasPrefix(errmsg, "Invalid pair name") {
+		apiErr = EX_ERR_INVALID_CURRENCY_PAIR
+	}
+	apiErr.OriginErrMsg = errmsg
+	return apiErr.OriginErr(errmsg)
+}
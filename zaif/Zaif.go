@@ -16,6 +16,14 @@ type Zaif struct {
 	secretKey string
 }
 
+func init() {
+	RegisterExchange("zaif.jp", func(config *APIConfig) API {
+		zf := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		zf.baseUrl = OverrideUrl(zf.baseUrl, config.ApiUrl)
+		return zf
+	})
+}
+
 func New(httpClient *http.Client, accessKey, secretKey string) *Zaif {
 	zaif := new(Zaif)
 	zaif.accessKey = accessKey
@@ -29,6 +37,12 @@ func (zf *Zaif) GetExchangeName() string {
 	return "zaif.jp"
 }
 
+func (zf *Zaif) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker: true,
+		Depth:  true}
+}
+
 func (zf *Zaif) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	tickerUrl := fmt.Sprintf(zf.baseUrl+"1/ticker/%s_jpy", strings.ToLower(currency.CurrencyA.Symbol))
 	//println(tickerUrl)
@@ -105,46 +119,46 @@ func (zf *Zaif) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (zf *Zaif) LimitBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) LimitSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	return false, nil
+	return false, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) GetAccount() (*Account, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zf *Zaif) GetKlineRecords(currency CurrencyPair , period int, size, since int) ([]Kline, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 //非个人，整个交易所的交易记录
 func (zf *Zaif) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	. "github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/internal/zbapi"
 	"log"
 	"net/http"
 	"net/url"
@@ -26,25 +27,47 @@ const (
 	PLACE_ORDER_API           = "order"
 	WITHDRAW_API              = "withdraw"
 	CANCELWITHDRAW_API        = "cancelWithdraw"
+	GET_USER_ADDRESS_API      = "getUserAddress"
+	GET_CHARGE_RECORD_API     = "getChargeRecordList"
+	GET_WITHDRAW_RECORD_API   = "getWithdrawRecord"
 )
 
 type Zb struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	marketUrl,
+	tradeUrl string
+}
+
+func init() {
+	RegisterExchange(ZB, func(config *APIConfig) API {
+		zb := New(config.HttpClient, config.AccessKey, config.SecretKey)
+		zb.marketUrl = OverrideUrl(zb.marketUrl, config.ApiUrl)
+		zb.tradeUrl = OverrideUrl(zb.tradeUrl, config.ApiUrl)
+		return zb
+	})
 }
 
 func New(httpClient *http.Client, accessKey, secretKey string) *Zb {
-	return &Zb{httpClient, accessKey, secretKey}
+	return &Zb{httpClient, accessKey, secretKey, MARKET_URL, TRADE_URL}
 }
 
 func (zb *Zb) GetExchangeName() string {
 	return ZB
 }
 
+func (zb *Zb) Capabilities() Capabilities {
+	return Capabilities{
+		Ticker:  true,
+		Depth:   true,
+		Orders:  true,
+		Account: true}
+}
+
 func (zb *Zb) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	symbol := currency.AdaptBchToBcc().AdaptUsdToUsdt().ToSymbol("_")
-	resp, err := HttpGet(zb.httpClient, MARKET_URL+fmt.Sprintf(TICKER_API, symbol))
+	resp, err := HttpGet(zb.httpClient, zb.marketUrl+fmt.Sprintf(TICKER_API, symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +77,7 @@ func (zb *Zb) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	ticker := new(Ticker)
 	ticker.Pair = currency
 	ticker.Date, _ = strconv.ParseUint(resp["date"].(string), 10, 64)
+	ticker.Date /= 1000 //毫秒
 	ticker.Buy, _ = strconv.ParseFloat(tickermap["buy"].(string), 64)
 	ticker.Sell, _ = strconv.ParseFloat(tickermap["sell"].(string), 64)
 	ticker.Last, _ = strconv.ParseFloat(tickermap["last"].(string), 64)
@@ -66,7 +90,7 @@ func (zb *Zb) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 func (zb *Zb) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	symbol := currency.AdaptBchToBcc().AdaptUsdToUsdt().ToSymbol("_")
-	resp, err := HttpGet(zb.httpClient, MARKET_URL+fmt.Sprintf(DEPTH_API, symbol, size))
+	resp, err := HttpGet(zb.httpClient, zb.marketUrl+fmt.Sprintf(DEPTH_API, symbol, size))
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +152,7 @@ func (zb *Zb) GetAccount() (*Account, error) {
 	params.Set("method", "getAccountInfo")
 	zb.buildPostForm(&params)
 	//log.Println(params.Encode())
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+GET_ACCOUNT_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+GET_ACCOUNT_API, params)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +165,7 @@ func (zb *Zb) GetAccount() (*Account, error) {
 	}
 
 	if respmap["code"] != nil && respmap["code"].(float64) != 1000 {
-		return nil, errors.New(string(resp))
+		return nil, zb.adaptError(int(respmap["code"].(float64)), string(resp))
 	}
 
 	acc := new(Account)
@@ -179,7 +203,7 @@ func (zb *Zb) placeOrder(amount, price string, currency CurrencyPair, tradeType
 	params.Set("tradeType", fmt.Sprintf("%d", tradeType))
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+PLACE_ORDER_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+PLACE_ORDER_API, params)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -197,7 +221,7 @@ func (zb *Zb) placeOrder(amount, price string, currency CurrencyPair, tradeType
 	code := respmap["code"].(float64)
 	if code != 1000 {
 		log.Println(string(resp))
-		return nil, errors.New(fmt.Sprintf("%.0f", code))
+		return nil, zb.adaptError(int(code), string(resp))
 	}
 
 	orid := respmap["id"].(string)
@@ -236,7 +260,7 @@ func (zb *Zb) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
 	params.Set("currency", symbol)
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+CANCEL_ORDER_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+CANCEL_ORDER_API, params)
 	if err != nil {
 		log.Println(err)
 		return false, err
@@ -256,7 +280,7 @@ func (zb *Zb) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
 	}
 
 	//log.Println(respmap)
-	return false, errors.New(fmt.Sprintf("%.0f", code))
+	return false, zb.adaptError(int(code), string(resp))
 }
 
 func parseOrder(order *Order, ordermap map[string]interface{}) {
@@ -308,7 +332,7 @@ func (zb *Zb) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error)
 	params.Set("currency", symbol)
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+GET_ORDER_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+GET_ORDER_API, params)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -321,6 +345,10 @@ func (zb *Zb) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error)
 		return nil, err
 	}
 
+	if code, isok := ordermap["code"].(float64); isok && code != 1000 {
+		return nil, zb.adaptError(int(code), string(resp))
+	}
+
 	order := new(Order)
 	order.Currency = currency
 
@@ -338,7 +366,7 @@ func (zb *Zb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 	params.Set("pageSize", "100")
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+GET_UNFINISHED_ORDERS_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+GET_UNFINISHED_ORDERS_API, params)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -348,8 +376,7 @@ func (zb *Zb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 	//println(respstr)
 
 	if strings.Contains(respstr, "\"code\":3001") {
-		log.Println(respstr)
-		return nil, nil
+		return []Order{}, nil
 	}
 
 	var resps []interface{}
@@ -359,7 +386,7 @@ func (zb *Zb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 		return nil, err
 	}
 
-	var orders []Order
+	orders := make([]Order, 0, len(resps))
 	for _, v := range resps {
 		ordermap := v.(map[string]interface{})
 		order := Order{}
@@ -372,24 +399,36 @@ func (zb *Zb) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (zb *Zb) GetOrderHistorys(currency CurrencyPair, currentPage, pageSize int) ([]Order, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zb *Zb) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
-	return nil, nil
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
+// Deprecated: 使用SubmitWithdraw
 func (zb *Zb) Withdraw(amount string, currency Currency, fees, receiveAddr, safePwd string) (string, error) {
+	return zb.SubmitWithdraw(WithdrawRequest{Currency: currency, Amount: amount, Address: receiveAddr, Fee: fees, SafePwd: safePwd})
+}
+
+func (zb *Zb) SubmitWithdraw(req WithdrawRequest) (string, error) {
+	currency := req.Currency
+	receiveAddr := req.Address
+	//需要memo的币种,地址格式为 地址_memo
+	if req.Tag != "" {
+		receiveAddr += "_" + req.Tag
+	}
+
 	params := url.Values{}
 	params.Set("method", "withdraw")
 	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
-	params.Set("amount", amount)
-	params.Set("fees", fees)
+	params.Set("amount", req.Amount)
+	params.Set("fees", req.Fee)
 	params.Set("receiveAddr", receiveAddr)
-	params.Set("safePwd", safePwd)
+	params.Set("safePwd", req.SafePwd)
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+WITHDRAW_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+WITHDRAW_API, params)
 	if err != nil {
 		log.Println("withdraw fail.", err)
 		return "", err
@@ -406,7 +445,7 @@ func (zb *Zb) Withdraw(amount string, currency Currency, fees, receiveAddr, safe
 		return respMap["id"].(string), nil
 	}
 
-	return "", errors.New(string(resp))
+	return "", zb.adaptError(ToInt(respMap["code"]), string(resp))
 }
 
 func (zb *Zb) CancelWithdraw(id string, currency Currency, safePwd string) (bool, error) {
@@ -417,7 +456,7 @@ func (zb *Zb) CancelWithdraw(id string, currency Currency, safePwd string) (bool
 	params.Set("safePwd", safePwd)
 	zb.buildPostForm(&params)
 
-	resp, err := HttpPostForm(zb.httpClient, TRADE_URL+CANCELWITHDRAW_API, params)
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+CANCELWITHDRAW_API, params)
 	if err != nil {
 		log.Println("cancel withdraw fail.", err)
 		return false, err
@@ -434,17 +473,84 @@ func (zb *Zb) CancelWithdraw(id string, currency Currency, safePwd string) (bool
 		return true, nil
 	}
 
-	return false, errors.New(string(resp))
+	return false, zb.adaptError(ToInt(respMap["code"]), string(resp))
+}
+
+func (zb *Zb) GetDepositAddress(currency Currency) (*DepositAddress, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	datas, err := zb.doWalletRequest(GET_USER_ADDRESS_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseDepositAddress(currency, datas), nil
+}
+
+//最近的充值记录
+func (zb *Zb) GetDepositHistory(currency Currency) ([]Deposit, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	params.Set("pageIndex", "1")
+	params.Set("pageSize", "50")
+	datas, err := zb.doWalletRequest(GET_CHARGE_RECORD_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseDeposits(currency, datas), nil
+}
+
+//最近的提现记录
+func (zb *Zb) GetWithdrawHistory(currency Currency) ([]Withdrawal, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.AdaptBchToBcc().String()))
+	params.Set("pageIndex", "1")
+	params.Set("pageSize", "50")
+	datas, err := zb.doWalletRequest(GET_WITHDRAW_RECORD_API, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseWithdrawals(currency, datas), nil
+}
+
+//充提相关接口,返回message.datas
+func (zb *Zb) doWalletRequest(method string, params url.Values) (map[string]interface{}, error) {
+	params.Set("method", method)
+	zb.buildPostForm(&params)
+
+	resp, err := HttpPostForm(zb.httpClient, zb.tradeUrl+method, params)
+	if err != nil {
+		return nil, err
+	}
+	return zbapi.ParseWalletResponse(resp, zb.adaptError)
 }
 
 func (zb *Zb) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("unimplements")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zb *Zb) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
 }
 
 func (zb *Zb) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("unsupport the market order")
+	return nil, EX_ERR_NOT_SUPPORTED
+}
+
+//错误码 1001~1009:通用错误 2001~2009:余额不足 3001:订单不存在 4002:请求过于频繁
+func (zb *Zb) adaptError(code int, resp string) ApiError {
+	apiErr := API_ERR
+	switch {
+	case code == 1003:
+		apiErr = EX_ERR_SIGN
+	case code >= 2001 && code <= 2009:
+		apiErr = EX_ERR_INSUFFICIENT_BALANCE
+	case code == 3001:
+		apiErr = EX_ERR_NOT_FIND_ORDER
+	case code == 4001:
+		apiErr = EX_ERR_NOT_FIND_APIKEY
+	case code == 4002:
+		apiErr = EX_ERR_API_LIMIT
+	}
+	apiErr.OriginErrMsg = resp
+	return apiErr.OriginErr(resp)
 }
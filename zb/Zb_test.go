@@ -2,6 +2,7 @@ package zb
 
 import (
 	"github.com/nntaoli-project/GoEx"
+	"github.com/nntaoli-project/GoEx/goextest"
 	"net/http"
 	"testing"
 )
@@ -57,3 +58,17 @@ func TestZb_GetOneOrder(t *testing.T) {
 	t.Log(err)
 	t.Log(ord)
 }
+
+func TestZb_Conformance(t *testing.T) {
+	goextest.ReplayConformance(t, func(client *http.Client) goex.API {
+		return New(client, api_key, api_secretkey)
+	}, goextest.SpotFixtures{
+		Pair:      goex.BTC_USDT,
+		Ticker:    true,
+		DepthSize: 2,
+		Depth:     &goex.Depth{AskList: goex.DepthRecords{{Price: 3802.5, Amount: 0.8}, {Price: 3801.9, Amount: 1.1}}, BidList: goex.DepthRecords{{Price: 3800, Amount: 0.4}, {Price: 3799.5, Amount: 2}}},
+		Order: &goex.Order{OrderID2: "20180522105585216", Status: goex.ORDER_FINISH, Side: goex.BUY, Amount: 0.5, DealAmount: 0.5,
+			AvgPrice: 3800, OrderTime: 1546300800000},
+		UnfinishOrders: true,
+		MissingOrderId: "1"}, "reqTime")
+}